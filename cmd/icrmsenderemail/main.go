@@ -36,7 +36,7 @@ func main() {
 	svcConfig := service.Config{
 		Name:        "icrmsenderemail",
 		DisplayName: "iCRM Sender Email",
-		Description: "Serviço de envio de e-mail usando SMTP, SendGrid, Zenvia, Pontaltech ou mock",
+		Description: "Serviço de envio de e-mail usando SMTP, SendGrid, Zenvia, Pontaltech, Amazon SES, Mailgun, Microsoft Graph ou mock",
 		Logger:      log,
 		RunFunc:     runApplication,
 	}
@@ -240,51 +240,35 @@ func runApplication(ctx context.Context) error {
 
 	log.Info("Conexão com banco de dados estabelecida")
 
-	// Inicializar provedor de e-mail
-	log.Info("Inicializando provedor de e-mail",
-		zap.String("provider", cfg.Email.Provider))
-
-	var provider email.Provider
-	switch cfg.Email.Provider {
-	case "mock":
-		provider = email.NewMockProvider(log)
-	case "smtp":
-		provider = email.NewSMTPProvider(
-			cfg.Email.SMTPHost,
-			cfg.Email.SMTPPort,
-			cfg.Email.SMTPUsername,
-			cfg.Email.SMTPPassword,
-			cfg.Email.SMTPUseTLS,
-			log,
-		)
-	case "sendgrid":
-		provider = email.NewSendGridProvider(
-			cfg.Email.SendGridAPIKey,
-			log,
-		)
-	case "zenvia":
-		provider = email.NewZenviaProvider(
-			cfg.Email.ZenviaAPIToken,
-			log,
-		)
-	case "pontaltech":
-		provider = email.NewPontaltechProvider(
-			cfg.Email.PontaltechUsername,
-			cfg.Email.PontaltechPassword,
-			cfg.Email.PontaltechAccountID,
-			cfg.Email.PontaltechAPIURL,
-			cfg.Email.PontaltechCallbackURL,
-			log,
-		)
-	default:
-		log.Fatal("Provedor de e-mail não suportado",
-			zap.String("provider", cfg.Email.Provider))
+	// Inicializar provedores de e-mail (cadeia de failover)
+	log.Info("Inicializando provedores de e-mail",
+		zap.Strings("providers", cfg.Email.Providers))
+
+	providers := make([]email.Provider, 0, len(cfg.Email.Providers))
+	for _, name := range cfg.Email.Providers {
+		provider, err := email.NewProvider(name, &cfg.Email, log)
+		if err != nil {
+			log.Fatal("Erro ao inicializar provedor de e-mail",
+				zap.String("provider", name),
+				zap.Error(err))
+		}
+		providers = append(providers, provider)
 	}
 
 	// Criar componentes
 	repo := message.NewRepository(db, log)
-	sender := email.NewSender(provider, log)
+	templateRepo := template.NewRepository(db, log)
+	sender := email.NewSender(
+		providers,
+		cfg.Email.ProviderWeights,
+		cfg.Email.ProviderCircuitThreshold,
+		cfg.Email.ProviderCircuitTimeout,
+		log,
+	)
 	metricsCollector := metrics.NewPerformanceMetrics()
+	for name, weight := range cfg.Email.ProviderWeights {
+		metricsCollector.SetProviderWeight(name, weight)
+	}
 
 	// Criar processador
 	processor := message.NewProcessor(
@@ -292,13 +276,15 @@ func runApplication(ctx context.Context) error {
 		sender,
 		metricsCollector,
 		&cfg.Performance,
-		cfg.Email.DefaultFrom,
+		message.NewDomainThrottler(cfg.DomainThrottle, metricsCollector),
+		message.NewSenderResolver(cfg.Senders, cfg.Email.DefaultFrom),
+		templateRepo,
 		log,
 	)
 
 	// Iniciar health check server
 	if cfg.Health.Enabled {
-		healthChecker := health.NewHealthChecker(db, log)
+		healthChecker := health.NewHealthChecker(db, sender, log)
 		health.StartHealthServer(cfg.Health.HTTPPort, healthChecker, log)
 	}
 
@@ -308,23 +294,34 @@ func runApplication(ctx context.Context) error {
 		dashboardConfig := dashboard.Config{
 			Port:            cfg.Dashboard.DashboardPort,
 			EnableDashboard: true,
-			ProviderName:    cfg.Email.Provider,
+			ProviderName:    strings.Join(cfg.Email.Providers, ","),
 			DaysOffset:      cfg.Performance.DataDisparoOffset,
 			MaxTentativas:   cfg.Performance.MaxTentativas,
+			RateLimitPerMin: cfg.Performance.EmailRateLimitPerMin,
 		}
 		dashboardServer = dashboard.NewDashboard(dashboardConfig, metricsCollector, repo, log)
+		dashboardServer.RegisterProviderCircuits(sender)
+		dashboardServer.RegisterEmailEvents(repo.Events())
 
 		// Registrar endpoints de templates
 		clienteRepo := cliente.NewRepository(db, log)
-		templateRepo := template.NewRepository(db, log)
 		macroProcessor := template.NewMacroProcessor(clienteRepo, "ICRMSenderEmail", log)
 		templateHandler := template.NewHandler(templateRepo, macroProcessor, log)
 		dashboardServer.RegisterTemplateEndpoints(templateHandler)
 
 		// Registrar endpoints de disparo manual (com suporte a templates)
-		manualHandler := manual.NewHandler(clienteRepo, repo, templateRepo, macroProcessor, cfg.Email.Provider)
+		manualHandler := manual.NewHandler(clienteRepo, repo, templateRepo, macroProcessor, cfg.Email.Provider, cfg.Email.DefaultFrom)
 		dashboardServer.RegisterManualEndpoints(manualHandler)
 
+		// Registrar callbacks de eventos de entrega dos providers
+		if err := dashboardServer.RegisterDeliveryCallbacks(repo, dashboard.CallbackConfig{
+			PontaltechToken:   cfg.Email.PontaltechCallbackToken,
+			SendGridPublicKey: cfg.Email.SendGridWebhookPublicKey,
+			ZenviaToken:       cfg.Email.ZenviaCallbackToken,
+		}); err != nil {
+			log.Fatal("Erro ao configurar callbacks de entrega", zap.Error(err))
+		}
+
 		go func() {
 			if err := dashboardServer.Start(); err != nil && err != http.ErrServerClosed {
 				log.Error("Erro no dashboard", zap.Error(err))
@@ -420,6 +417,11 @@ func runApplication(ctx context.Context) error {
 		log.Error("Erro ao parar processador", zap.Error(err))
 	}
 
+	// Encerrar conexões mantidas pelos providers
+	if err := sender.Close(); err != nil {
+		log.Error("Erro ao encerrar providers", zap.Error(err))
+	}
+
 	// Parar dashboard
 	if dashboardServer != nil {
 		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -450,6 +452,8 @@ func printDBStats(log *zap.Logger, repo *message.Repository) {
 			zap.Int64("status_2_enviados", dbStats["status_2"]),
 			zap.Int64("status_3_erros", dbStats["status_3"]),
 			zap.Int64("status_4_falhas_permanentes", dbStats["status_4"]),
-			zap.Int64("status_125_invalidos", dbStats["status_125"]))
+			zap.Int64("status_125_invalidos", dbStats["status_125"]),
+			zap.Int64("status_126_provider_nao_configurado", dbStats["status_126"]),
+			zap.Int64("status_127_remetente_nao_permitido", dbStats["status_127"]))
 	}
 }
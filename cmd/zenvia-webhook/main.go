@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/config"
+	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/email"
+)
+
+// Cria e lista a assinatura de webhook MESSAGE_STATUS da Zenvia que envia os
+// eventos de entrega para /api/callback/zenvia do dashboard
+func main() {
+	if len(os.Args) < 2 || (os.Args[1] != "list" && os.Args[1] != "create") ||
+		(os.Args[1] == "create" && len(os.Args) < 3) {
+		fmt.Println("Uso: zenvia-webhook <comando>")
+		fmt.Println("\nComandos disponíveis:")
+		fmt.Println("  list          - Lista as assinaturas de webhook da conta")
+		fmt.Println("  create <url>  - Cria a assinatura de status de e-mail para a URL")
+		fmt.Println("                  (ex: https://seu-servidor:3101/api/callback/zenvia)")
+		os.Exit(2)
+	}
+
+	// Carregar configurações
+	cfg, err := config.LoadConfig("dbinit.ini")
+	if err != nil {
+		log.Fatalf("Erro ao carregar configurações: %v", err)
+	}
+	if cfg.Email.ZenviaAPIToken == "" {
+		log.Fatalf("zenvia_api_token não configurado no dbinit.ini")
+	}
+
+	client := email.NewZenviaSubscriptionClient(cfg.Email.ZenviaAPIToken, "")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	switch os.Args[1] {
+	case "list":
+		subscriptions, err := client.List(ctx)
+		if err != nil {
+			log.Fatalf("Erro: %v", err)
+		}
+		if len(subscriptions) == 0 {
+			fmt.Println("Nenhuma assinatura encontrada.")
+			return
+		}
+		for _, subscription := range subscriptions {
+			printSubscription(subscription)
+		}
+
+	case "create":
+		url := os.Args[2]
+
+		// Evitar assinatura duplicada (a Zenvia enviaria cada evento duas vezes)
+		subscriptions, err := client.List(ctx)
+		if err != nil {
+			log.Fatalf("Erro: %v", err)
+		}
+		for _, subscription := range subscriptions {
+			if subscription.EventType == "MESSAGE_STATUS" && subscription.Criteria.Channel == "email" &&
+				subscription.Webhook.URL == url {
+				fmt.Println("Assinatura já existe:")
+				printSubscription(subscription)
+				return
+			}
+		}
+
+		if cfg.Email.ZenviaCallbackToken == "" {
+			fmt.Println("⚠️  zenvia_callback_token não configurado: o dashboard não aceitará os eventos")
+		}
+
+		subscription, err := client.CreateStatusWebhook(ctx, url, cfg.Email.ZenviaCallbackToken)
+		if err != nil {
+			log.Fatalf("Erro: %v", err)
+		}
+		fmt.Println("✅ Assinatura criada:")
+		printSubscription(*subscription)
+	}
+}
+
+// printSubscription exibe os dados principais da assinatura
+func printSubscription(subscription email.ZenviaSubscription) {
+	fmt.Printf("  %s  %-15s canal=%-6s status=%-8s %s\n",
+		subscription.ID,
+		subscription.EventType,
+		subscription.Criteria.Channel,
+		subscription.Status,
+		subscription.Webhook.URL)
+}
@@ -2,6 +2,9 @@ package config
 
 import (
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-ini/ini"
@@ -15,6 +18,9 @@ type Config struct {
 	Health      HealthConfig
 	Performance PerformanceConfig
 	Dashboard   DashboardConfig
+
+	DomainThrottle DomainThrottleConfig
+	Senders        []SenderIdentity
 }
 
 // DatabaseConfig configurações do banco de dados
@@ -26,27 +32,74 @@ type DatabaseConfig struct {
 
 // EmailConfig configurações do provedor Email
 type EmailConfig struct {
-	Provider string // mock, smtp, sendgrid, zenvia, pontaltech
+	Provider  string   // mock, smtp, sendgrid, zenvia, pontaltech, ses, mailgun, graph, http (provider principal)
+	Providers []string // Cadeia de failover em ordem de preferência (o primeiro é o principal)
+
+	// Divisão de tráfego por peso (provider -> peso). Vazio = todo o tráfego no principal.
+	ProviderWeights map[string]int
+
+	// Circuit breaker por provider (failover)
+	ProviderCircuitThreshold int           // Falhas consecutivas para abrir o circuito
+	ProviderCircuitTimeout   time.Duration // Tempo com o circuito aberto antes de testar novamente
 
 	// SMTP genérico
 	SMTPHost     string
 	SMTPPort     int
 	SMTPUsername string
 	SMTPPassword string
-	SMTPUseTLS   bool
+	SMTPUseTLS   bool          // Legado: usado apenas quando smtp_security não é informado
+	SMTPSecurity string        // starttls, tls (implícito) ou none
+	SMTPPoolSize int           // Conexões autenticadas mantidas abertas para reuso
+	SMTPIdle     time.Duration // Tempo máximo de uma conexão ociosa no pool
+	SMTPTimeout  time.Duration // Timeout de conexão e de cada comando SMTP
+
+	// DKIM para o SMTP (opcional, sobrescrito por smtp.dkim_* da identidade)
+	SMTPDKIMDomain   string // Vazio = domínio do remetente
+	SMTPDKIMSelector string
+	SMTPDKIMKeyFile  string // Chave privada PEM (RSA ou Ed25519)
 
 	// SendGrid
-	SendGridAPIKey string
+	SendGridAPIKey           string
+	SendGridWebhookPublicKey string // Chave pública do Event Webhook assinado (opcional)
 
 	// Zenvia
-	ZenviaAPIToken string
+	ZenviaAPIToken      string
+	ZenviaCallbackToken string // Token exigido no webhook de status do dashboard (header X-Callback-Token)
 
 	// Pontaltech
-	PontaltechUsername    string
-	PontaltechPassword    string
-	PontaltechAccountID   int
-	PontaltechAPIURL      string // URL customizada da API (opcional)
-	PontaltechCallbackURL string // URL de callback para notificações (opcional)
+	PontaltechUsername      string
+	PontaltechPassword      string
+	PontaltechAccountID     int
+	PontaltechAPIURL        string // URL customizada da API (opcional)
+	PontaltechCallbackURL   string // URL de callback para notificações (opcional)
+	PontaltechCallbackToken string // Token exigido no endpoint de callback do dashboard
+
+	// Amazon SES (API v2)
+	SESRegion           string
+	SESAccessKeyID      string // Vazio = variáveis de ambiente AWS_*
+	SESSecretAccessKey  string
+	SESSessionToken     string
+	SESConfigurationSet string // Configuration set (eventos de entrega, IP dedicado)
+	SESEndpoint         string // URL customizada da API (opcional)
+
+	// Mailgun
+	MailgunAPIKey string
+	MailgunDomain string
+	MailgunRegion string   // us ou eu
+	MailgunTags   []string // Tags aplicadas a todas as mensagens
+	MailgunAPIURL string   // URL customizada da API (opcional)
+
+	// Microsoft Graph (Microsoft 365)
+	GraphTenantID        string
+	GraphClientID        string
+	GraphClientSecret    string
+	GraphUserID          string // Caixa de envio (vazio = endereço do remetente)
+	GraphSaveToSentItems bool
+	GraphAuthorityURL    string // URL customizada do login (opcional)
+	GraphAPIURL          string // URL customizada da API (opcional)
+
+	// Provider HTTP/JSON genérico (seção [http_provider])
+	HTTP HTTPProviderConfig
 
 	// Comum a todos
 	DefaultFrom   string // Remetente padrão
@@ -76,12 +129,69 @@ type PerformanceConfig struct {
 	FetchIntervalSeconds         int
 	SendTimeoutSeconds           int
 	RetryAttempts                int
-	EnableBatching               bool
-	EmailRateLimitPerMin         int
+	EnableBatching               bool // Agrupar emails de mesmo conteúdo em uma chamada ao provider
+	EmailRateLimitPerMin         int  // Limite global de envios por minuto (0 = sem limite)
+	EmailRateLimitBurst          int  // Rajada máxima permitida pelo limitador
 	CircuitBreakerThreshold      int
 	CircuitBreakerTimeoutSeconds int
 	DataDisparoOffset            int // Offset em dias para filtro de DATA_AGENDAMENTO
 	MaxTentativas                int // Número máximo de tentativas de envio por Email
+
+	// Retentativas agendadas de emails com erro temporário (status 3)
+	RetryBackoffInitialSeconds int     // Espera antes da 2ª tentativa
+	RetryBackoffMaxSeconds     int     // Espera máxima entre tentativas
+	RetryBackoffMultiplier     float64 // Multiplicador do backoff exponencial
+
+	// Workers dedicados por PRIORIDADE (além dos worker_count compartilhados)
+	PriorityHighWorkers   int
+	PriorityNormalWorkers int
+	PriorityLowWorkers    int
+
+	// Processamento com múltiplas instâncias
+	InstanceID   string // Identificação desta instância (padrão: hostname-pid)
+	LeaseSeconds int    // Tempo de reserva de um email por esta instância
+}
+
+// HTTPProviderConfig configurações do provider HTTP/JSON genérico (seção [http_provider])
+type HTTPProviderConfig struct {
+	URL           string
+	Method        string
+	ContentType   string
+	AuthHeader    string
+	AuthValue     string
+	Headers       map[string]string // Chaves "header.<Nome>"
+	BodyTemplate  string            // body_template ou conteúdo de body_template_file
+	SuccessStatus []int
+	SuccessPath   string
+	SuccessValue  string
+	IDPath        string
+	ErrorPath     string
+	Timeout       time.Duration
+}
+
+// DomainThrottleConfig limites de envio por domínio do destinatário
+type DomainThrottleConfig struct {
+	Enabled bool
+	Rules   []DomainThrottleRule
+}
+
+// DomainThrottleRule limite aplicado a um grupo de domínios que compartilham
+// a mesma infraestrutura (ex: uol.com.br e bol.com.br)
+type DomainThrottleRule struct {
+	Domains        []string
+	RatePerMin     int // Envios por minuto para o grupo (0 = sem limite)
+	MaxConcurrency int // Envios simultâneos para o grupo (0 = sem limite)
+}
+
+// SenderIdentity identidade de remetente permitida, carregada de uma seção
+// [sender.<nome>]. Quando há identidades configuradas, somente mensagens cujo
+// REMETENTE corresponda a uma delas são enviadas.
+type SenderIdentity struct {
+	Name        string // Nome da seção (ex: "financeiro" em [sender.financeiro])
+	Email       string
+	DisplayName string
+	ReplyTo     string
+	Settings    map[string]string // Configurações por provider: "<provider>.<chave>" (ex: "sendgrid.api_key")
 }
 
 // DashboardConfig configurações do dashboard
@@ -118,25 +228,94 @@ func LoadConfig(path string) (*Config, error) {
 		SMTPUsername: emailSection.Key("smtp_username").String(),
 		SMTPPassword: emailSection.Key("smtp_password").String(),
 		SMTPUseTLS:   emailSection.Key("smtp_use_tls").MustBool(true),
+		SMTPSecurity: strings.ToLower(strings.TrimSpace(emailSection.Key("smtp_security").String())),
+		SMTPPoolSize: emailSection.Key("smtp_pool_size").MustInt(2),
+		SMTPIdle:     time.Duration(emailSection.Key("smtp_pool_idle_seconds").MustInt(60)) * time.Second,
+		SMTPTimeout:  time.Duration(emailSection.Key("smtp_timeout_seconds").MustInt(30)) * time.Second,
+
+		SMTPDKIMDomain:   emailSection.Key("smtp_dkim_domain").String(),
+		SMTPDKIMSelector: emailSection.Key("smtp_dkim_selector").String(),
+		SMTPDKIMKeyFile:  emailSection.Key("smtp_dkim_private_key_file").String(),
 
 		// SendGrid
-		SendGridAPIKey: emailSection.Key("sendgrid_api_key").String(),
+		SendGridAPIKey:           emailSection.Key("sendgrid_api_key").String(),
+		SendGridWebhookPublicKey: emailSection.Key("sendgrid_webhook_public_key").String(),
 
 		// Zenvia
-		ZenviaAPIToken: emailSection.Key("zenvia_api_token").String(),
+		ZenviaAPIToken:      emailSection.Key("zenvia_api_token").String(),
+		ZenviaCallbackToken: emailSection.Key("zenvia_callback_token").String(),
 
 		// Pontaltech
-		PontaltechUsername:    emailSection.Key("pontaltech_username").String(),
-		PontaltechPassword:    emailSection.Key("pontaltech_password").String(),
-		PontaltechAccountID:   emailSection.Key("pontaltech_account_id").MustInt(0),
-		PontaltechAPIURL:      emailSection.Key("pontaltech_api_url").String(),
-		PontaltechCallbackURL: emailSection.Key("pontaltech_callback_url").String(),
+		PontaltechUsername:      emailSection.Key("pontaltech_username").String(),
+		PontaltechPassword:      emailSection.Key("pontaltech_password").String(),
+		PontaltechAccountID:     emailSection.Key("pontaltech_account_id").MustInt(0),
+		PontaltechAPIURL:        emailSection.Key("pontaltech_api_url").String(),
+		PontaltechCallbackURL:   emailSection.Key("pontaltech_callback_url").String(),
+		PontaltechCallbackToken: emailSection.Key("pontaltech_callback_token").String(),
+
+		// Amazon SES
+		SESRegion:           emailSection.Key("ses_region").MustString("us-east-1"),
+		SESAccessKeyID:      emailSection.Key("ses_access_key_id").String(),
+		SESSecretAccessKey:  emailSection.Key("ses_secret_access_key").String(),
+		SESSessionToken:     emailSection.Key("ses_session_token").String(),
+		SESConfigurationSet: emailSection.Key("ses_configuration_set").String(),
+		SESEndpoint:         emailSection.Key("ses_endpoint").String(),
+
+		// Mailgun
+		MailgunAPIKey: emailSection.Key("mailgun_api_key").String(),
+		MailgunDomain: emailSection.Key("mailgun_domain").String(),
+		MailgunRegion: strings.ToLower(emailSection.Key("mailgun_region").MustString("us")),
+		MailgunTags:   emailSection.Key("mailgun_tags").Strings(","),
+		MailgunAPIURL: emailSection.Key("mailgun_api_url").String(),
+
+		// Microsoft Graph
+		GraphTenantID:        emailSection.Key("graph_tenant_id").String(),
+		GraphClientID:        emailSection.Key("graph_client_id").String(),
+		GraphClientSecret:    emailSection.Key("graph_client_secret").String(),
+		GraphUserID:          emailSection.Key("graph_user_id").String(),
+		GraphSaveToSentItems: emailSection.Key("graph_save_to_sent_items").MustBool(false),
+		GraphAuthorityURL:    emailSection.Key("graph_authority_url").String(),
+		GraphAPIURL:          emailSection.Key("graph_api_url").String(),
 
 		// Comum
 		DefaultFrom:   emailSection.Key("default_from").MustString("noreply@example.com"),
 		MaxRetries:    emailSection.Key("max_retries").MustInt(3),
 		RetryInterval: time.Duration(emailSection.Key("retry_interval_seconds").MustInt(300)) * time.Second,
+
+		// Failover
+		ProviderCircuitThreshold: emailSection.Key("provider_circuit_threshold").MustInt(5),
+		ProviderCircuitTimeout:   time.Duration(emailSection.Key("provider_circuit_timeout_seconds").MustInt(60)) * time.Second,
+	}
+
+	// Cadeia de failover: "providers" tem precedência sobre "provider"
+	for _, name := range emailSection.Key("providers").Strings(",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			config.Email.Providers = append(config.Email.Providers, name)
+		}
+	}
+	if len(config.Email.Providers) > 0 {
+		config.Email.Provider = config.Email.Providers[0]
+	} else if config.Email.Provider != "" {
+		config.Email.Providers = []string{config.Email.Provider}
+	}
+
+	// Compatibilidade: smtp_use_tls=true na porta 465 é TLS implícito, nas demais STARTTLS
+	if config.Email.SMTPSecurity == "" {
+		switch {
+		case !config.Email.SMTPUseTLS:
+			config.Email.SMTPSecurity = "none"
+		case config.Email.SMTPPort == 465:
+			config.Email.SMTPSecurity = "tls"
+		default:
+			config.Email.SMTPSecurity = "starttls"
+		}
+	}
+
+	providerWeights, err := loadProviderWeights(emailSection.Key("provider_weights").String())
+	if err != nil {
+		return nil, err
 	}
+	config.Email.ProviderWeights = providerWeights
 
 	// Logger
 	logSection := cfg.Section("logger")
@@ -163,13 +342,39 @@ func LoadConfig(path string) (*Config, error) {
 		FetchIntervalSeconds:         perfSection.Key("fetch_interval_seconds").MustInt(5),
 		SendTimeoutSeconds:           perfSection.Key("send_timeout_seconds").MustInt(30),
 		RetryAttempts:                perfSection.Key("retry_attempts").MustInt(3),
-		EnableBatching:               perfSection.Key("enable_batching").MustBool(true),
+		EnableBatching:               perfSection.Key("enable_batching").MustBool(false),
 		EmailRateLimitPerMin:         perfSection.Key("email_rate_limit_per_min").MustInt(300),
+		EmailRateLimitBurst:          perfSection.Key("email_rate_limit_burst").MustInt(1),
 		CircuitBreakerThreshold:      perfSection.Key("circuit_breaker_threshold").MustInt(10),
 		CircuitBreakerTimeoutSeconds: perfSection.Key("circuit_breaker_timeout_seconds").MustInt(30),
 		DataDisparoOffset:            perfSection.Key("data_disparo_days_offset").MustInt(0),
 		MaxTentativas:                perfSection.Key("max_tentativas").MustInt(5),
+		RetryBackoffInitialSeconds:   perfSection.Key("retry_backoff_initial_seconds").MustInt(60),
+		RetryBackoffMaxSeconds:       perfSection.Key("retry_backoff_max_seconds").MustInt(3600),
+		RetryBackoffMultiplier:       perfSection.Key("retry_backoff_multiplier").MustFloat64(2.0),
+		PriorityHighWorkers:          perfSection.Key("priority_high_workers").MustInt(1),
+		PriorityNormalWorkers:        perfSection.Key("priority_normal_workers").MustInt(0),
+		PriorityLowWorkers:           perfSection.Key("priority_low_workers").MustInt(0),
+		InstanceID:                   perfSection.Key("instance_id").MustString(defaultInstanceID()),
+		LeaseSeconds:                 perfSection.Key("lease_seconds").MustInt(300),
+	}
+
+	// Limites por domínio
+	domainThrottle, err := loadDomainThrottle(cfg.Section("domain_throttle"))
+	if err != nil {
+		return nil, err
+	}
+	config.DomainThrottle = domainThrottle
+
+	// Identidades de remetente
+	config.Senders = loadSenderIdentities(cfg)
+
+	// Provider HTTP genérico
+	httpProvider, err := loadHTTPProvider(cfg.Section("http_provider"))
+	if err != nil {
+		return nil, err
 	}
+	config.Email.HTTP = httpProvider
 
 	// Dashboard
 	dashSection := cfg.Section("dashboard")
@@ -181,6 +386,168 @@ func LoadConfig(path string) (*Config, error) {
 	return config, nil
 }
 
+// loadSenderIdentities carrega as seções [sender.<nome>]. Além de email,
+// display_name e reply_to, chaves no formato "<provider>.<chave>" definem
+// configurações da identidade para cada provider, por exemplo:
+//
+//	[sender.financeiro]
+//	email = financeiro@empresa.com.br
+//	display_name = Empresa - Financeiro
+//	pontaltech.account_id = 456
+func loadSenderIdentities(cfg *ini.File) []SenderIdentity {
+	var identities []SenderIdentity
+
+	for _, section := range cfg.Sections() {
+		name, ok := strings.CutPrefix(section.Name(), "sender.")
+		if !ok || name == "" {
+			continue
+		}
+
+		identity := SenderIdentity{
+			Name:        name,
+			Email:       strings.TrimSpace(section.Key("email").String()),
+			DisplayName: section.Key("display_name").String(),
+			ReplyTo:     strings.TrimSpace(section.Key("reply_to").String()),
+			Settings:    make(map[string]string),
+		}
+
+		for _, key := range section.Keys() {
+			switch key.Name() {
+			case "email", "display_name", "reply_to":
+				continue
+			}
+			identity.Settings[strings.ToLower(key.Name())] = key.String()
+		}
+
+		identities = append(identities, identity)
+	}
+
+	return identities
+}
+
+// loadHTTPProvider carrega a seção [http_provider]. Cabeçalhos extras são
+// definidos com chaves "header.<Nome>" e o corpo da requisição é um template
+// Go sobre EmailData, informado em body_template ou em body_template_file:
+//
+//	[http_provider]
+//	url = https://api.gateway.com.br/v1/email
+//	auth_header = X-Api-Key
+//	auth_value = chave
+//	header.X-Versao = 2
+//	body_template_file = http_provider.json.tmpl
+//	id_path = data.id
+func loadHTTPProvider(section *ini.Section) (HTTPProviderConfig, error) {
+	httpProvider := HTTPProviderConfig{
+		URL:          strings.TrimSpace(section.Key("url").String()),
+		Method:       strings.ToUpper(section.Key("method").MustString("POST")),
+		ContentType:  section.Key("content_type").MustString("application/json"),
+		AuthHeader:   section.Key("auth_header").String(),
+		AuthValue:    section.Key("auth_value").String(),
+		Headers:      make(map[string]string),
+		BodyTemplate: section.Key("body_template").String(),
+		SuccessPath:  section.Key("success_path").String(),
+		SuccessValue: section.Key("success_value").String(),
+		IDPath:       section.Key("id_path").String(),
+		ErrorPath:    section.Key("error_path").String(),
+		Timeout:      time.Duration(section.Key("timeout_seconds").MustInt(30)) * time.Second,
+	}
+
+	if file := section.Key("body_template_file").String(); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return httpProvider, fmt.Errorf("http_provider.body_template_file: %w", err)
+		}
+		httpProvider.BodyTemplate = string(data)
+	}
+
+	for _, value := range section.Key("success_status").Strings(",") {
+		status, err := strconv.Atoi(value)
+		if err != nil {
+			return httpProvider, fmt.Errorf("http_provider.success_status: status inválido %q", value)
+		}
+		httpProvider.SuccessStatus = append(httpProvider.SuccessStatus, status)
+	}
+
+	for _, key := range section.Keys() {
+		if name, ok := strings.CutPrefix(key.Name(), "header."); ok && name != "" {
+			httpProvider.Headers[name] = key.String()
+		}
+	}
+
+	return httpProvider, nil
+}
+
+// loadDomainThrottle carrega a seção [domain_throttle]. Cada chave (exceto
+// "enabled") é uma lista de domínios separados por vírgula e o valor é
+// "envios_por_minuto,max_simultaneos", por exemplo:
+//
+//	uol.com.br,bol.com.br = 60,2
+func loadDomainThrottle(section *ini.Section) (DomainThrottleConfig, error) {
+	throttle := DomainThrottleConfig{
+		Enabled: section.Key("enabled").MustBool(false),
+	}
+
+	for _, key := range section.Keys() {
+		if key.Name() == "enabled" {
+			continue
+		}
+
+		var domains []string
+		for _, domain := range strings.Split(key.Name(), ",") {
+			domain = strings.ToLower(strings.TrimSpace(domain))
+			if domain != "" {
+				domains = append(domains, domain)
+			}
+		}
+
+		values := strings.Split(key.Value(), ",")
+		if len(domains) == 0 || len(values) != 2 {
+			return throttle, fmt.Errorf("domain_throttle.%s inválido: use \"envios_por_minuto,max_simultaneos\"", key.Name())
+		}
+
+		rate, errRate := strconv.Atoi(strings.TrimSpace(values[0]))
+		concurrency, errConc := strconv.Atoi(strings.TrimSpace(values[1]))
+		if errRate != nil || errConc != nil || rate < 0 || concurrency < 0 {
+			return throttle, fmt.Errorf("domain_throttle.%s inválido: valores devem ser inteiros não negativos", key.Name())
+		}
+
+		throttle.Rules = append(throttle.Rules, DomainThrottleRule{
+			Domains:        domains,
+			RatePerMin:     rate,
+			MaxConcurrency: concurrency,
+		})
+	}
+
+	return throttle, nil
+}
+
+// loadProviderWeights lê a divisão de tráfego no formato "pontaltech:80,sendgrid:20"
+func loadProviderWeights(value string) (map[string]int, error) {
+	weights := make(map[string]int)
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("email.provider_weights: entrada %q inválida (formato provider:peso)", entry)
+		}
+
+		name := strings.ToLower(strings.TrimSpace(parts[0]))
+		weight, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("email.provider_weights: peso inválido para %q", name)
+		}
+
+		weights[name] = weight
+	}
+
+	return weights, nil
+}
+
 // Validate valida as configurações
 func (c *Config) Validate() error {
 	// Validar database
@@ -198,6 +565,72 @@ func (c *Config) Validate() error {
 	if c.Email.Provider == "" {
 		return fmt.Errorf("email.provider não pode ser vazio")
 	}
+	seenProviders := make(map[string]bool, len(c.Email.Providers))
+	for _, name := range c.Email.Providers {
+		if seenProviders[name] {
+			return fmt.Errorf("email.providers contém o provider %q mais de uma vez", name)
+		}
+		seenProviders[name] = true
+	}
+	if len(c.Email.ProviderWeights) > 0 {
+		totalWeight := 0
+		for name, weight := range c.Email.ProviderWeights {
+			if !seenProviders[name] {
+				return fmt.Errorf("email.provider_weights: provider %q não está em email.providers", name)
+			}
+			totalWeight += weight
+		}
+		if totalWeight == 0 {
+			return fmt.Errorf("email.provider_weights: a soma dos pesos deve ser maior que 0")
+		}
+	}
+	seenSenders := make(map[string]bool, len(c.Senders))
+	for _, identity := range c.Senders {
+		if identity.Email == "" {
+			return fmt.Errorf("sender.%s: email não pode ser vazio", identity.Name)
+		}
+		email := strings.ToLower(identity.Email)
+		if seenSenders[email] {
+			return fmt.Errorf("sender.%s: email %s já usado por outra identidade", identity.Name, identity.Email)
+		}
+		seenSenders[email] = true
+
+		for key := range identity.Settings {
+			if !strings.Contains(key, ".") {
+				return fmt.Errorf("sender.%s: chave %q inválida (use <provider>.<chave>)", identity.Name, key)
+			}
+		}
+
+		if keyFile := identity.Settings["smtp.dkim_private_key_file"]; keyFile != "" {
+			selector := identity.Settings["smtp.dkim_selector"]
+			if selector == "" {
+				selector = c.Email.SMTPDKIMSelector
+			}
+			if err := validateDKIMKey(keyFile, selector); err != nil {
+				return fmt.Errorf("sender.%s: %w", identity.Name, err)
+			}
+		}
+	}
+
+	if c.Email.SMTPDKIMKeyFile != "" {
+		if err := validateDKIMKey(c.Email.SMTPDKIMKeyFile, c.Email.SMTPDKIMSelector); err != nil {
+			return fmt.Errorf("email: %w", err)
+		}
+	}
+	switch c.Email.SMTPSecurity {
+	case "starttls", "tls", "none":
+	default:
+		return fmt.Errorf("email.smtp_security inválido: %s (use starttls, tls ou none)", c.Email.SMTPSecurity)
+	}
+	if c.Email.MailgunRegion != "us" && c.Email.MailgunRegion != "eu" {
+		return fmt.Errorf("email.mailgun_region inválido: %s (use us ou eu)", c.Email.MailgunRegion)
+	}
+	if c.Email.SMTPPoolSize < 0 {
+		return fmt.Errorf("email.smtp_pool_size não pode ser negativo")
+	}
+	if c.Email.ProviderCircuitThreshold < 0 {
+		return fmt.Errorf("email.provider_circuit_threshold não pode ser negativo")
+	}
 
 	// Validar performance
 	if c.Performance.BatchSize <= 0 {
@@ -206,6 +639,44 @@ func (c *Config) Validate() error {
 	if c.Performance.WorkerCount <= 0 {
 		return fmt.Errorf("performance.worker_count deve ser maior que 0")
 	}
+	if c.Performance.RetryBackoffInitialSeconds < 0 || c.Performance.RetryBackoffMaxSeconds < 0 {
+		return fmt.Errorf("performance.retry_backoff_*_seconds não pode ser negativo")
+	}
+	if c.Performance.PriorityHighWorkers < 0 || c.Performance.PriorityNormalWorkers < 0 || c.Performance.PriorityLowWorkers < 0 {
+		return fmt.Errorf("performance.priority_*_workers não pode ser negativo")
+	}
+	if c.Performance.EmailRateLimitPerMin < 0 || c.Performance.EmailRateLimitBurst < 0 {
+		return fmt.Errorf("performance.email_rate_limit_* não pode ser negativo")
+	}
+	if c.Performance.BatchSize > 1000 {
+		return fmt.Errorf("performance.batch_size não pode ser maior que 1000")
+	}
+	if c.Performance.LeaseSeconds <= c.Performance.SendTimeoutSeconds {
+		return fmt.Errorf("performance.lease_seconds deve ser maior que performance.send_timeout_seconds")
+	}
+	if c.Performance.RetryBackoffMultiplier < 1 {
+		return fmt.Errorf("performance.retry_backoff_multiplier deve ser maior ou igual a 1")
+	}
+
+	return nil
+}
 
+// defaultInstanceID gera a identificação padrão da instância (hostname-pid)
+func defaultInstanceID() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "icrmsenderemail"
+	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
+// validateDKIMKey verifica se a chave DKIM existe e se há seletor definido
+func validateDKIMKey(keyFile, selector string) error {
+	if selector == "" {
+		return fmt.Errorf("seletor DKIM (smtp_dkim_selector ou smtp.dkim_selector) não pode ser vazio quando há chave DKIM")
+	}
+	if _, err := os.Stat(keyFile); err != nil {
+		return fmt.Errorf("chave DKIM %s não encontrada: %w", keyFile, err)
+	}
 	return nil
 }
@@ -0,0 +1,194 @@
+package dashboard
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/email"
+	"go.uber.org/zap"
+)
+
+// maxCallbackBodySize tamanho máximo aceito no corpo de um callback de provider
+const maxCallbackBodySize = 5 << 20 // 5MB
+
+// DeliveryEventRecorder interface para gravar os eventos de entrega recebidos
+// dos providers (retorna false se nenhum email corresponde ao evento)
+type DeliveryEventRecorder interface {
+	RecordDeliveryEvent(ctx context.Context, event email.DeliveryEvent) (bool, error)
+}
+
+// CallbackConfig credenciais dos endpoints de callback dos providers. Endpoint
+// sem credencial configurada não é registrado.
+type CallbackConfig struct {
+	PontaltechToken   string // Token exigido em /api/callback/pontaltech
+	SendGridPublicKey string // Chave pública de verificação do Event Webhook (/api/callback/sendgrid)
+	ZenviaToken       string // Token exigido em /api/callback/zenvia
+}
+
+// callbackAuthenticator valida a origem do callback (corpo já lido)
+type callbackAuthenticator func(r *http.Request, body []byte) bool
+
+// callbackParser converte o corpo do callback em eventos de entrega
+type callbackParser func(body []byte) ([]email.DeliveryEvent, error)
+
+// RegisterDeliveryCallbacks registra os endpoints que recebem eventos de
+// entrega dos providers. Retorna erro se alguma credencial for inválida.
+func (d *Dashboard) RegisterDeliveryCallbacks(recorder DeliveryEventRecorder, config CallbackConfig) error {
+	if config.SendGridPublicKey != "" {
+		verifier, err := email.NewSendGridWebhookVerifier(config.SendGridPublicKey)
+		if err != nil {
+			return err
+		}
+		d.sendGridVerifier = verifier
+	}
+
+	d.deliveryRecorder = recorder
+	d.callbackConfig = config
+	return nil
+}
+
+// registerCallbackRoutes adiciona ao mux os callbacks com credencial configurada
+func (d *Dashboard) registerCallbackRoutes() {
+	if d.deliveryRecorder == nil {
+		return
+	}
+
+	if d.callbackConfig.PontaltechToken != "" {
+		d.mux.HandleFunc("/api/callback/pontaltech", d.deliveryCallback("pontaltech",
+			tokenAuthenticator(d.callbackConfig.PontaltechToken),
+			email.ParsePontaltechCallback))
+		d.logger.Info("Callback de entrega Pontaltech habilitado",
+			zap.String("path", "/api/callback/pontaltech"))
+	}
+
+	if d.sendGridVerifier != nil {
+		d.mux.HandleFunc("/api/callback/sendgrid", d.deliveryCallback("sendgrid",
+			sendGridAuthenticator(d.sendGridVerifier),
+			email.ParseSendGridEvents))
+		d.logger.Info("Event Webhook SendGrid habilitado",
+			zap.String("path", "/api/callback/sendgrid"))
+	}
+
+	if d.callbackConfig.ZenviaToken != "" {
+		d.mux.HandleFunc("/api/callback/zenvia", d.deliveryCallback("zenvia",
+			tokenAuthenticator(d.callbackConfig.ZenviaToken),
+			email.ParseZenviaStatusEvent))
+		d.logger.Info("Webhook de status Zenvia habilitado",
+			zap.String("path", "/api/callback/zenvia"))
+	}
+}
+
+// tokenAuthenticator exige o token no parâmetro "token" da URL ou no header
+// X-Callback-Token
+func tokenAuthenticator(token string) callbackAuthenticator {
+	return func(r *http.Request, body []byte) bool {
+		received := r.Header.Get("X-Callback-Token")
+		if received == "" {
+			received = r.URL.Query().Get("token")
+		}
+		return subtle.ConstantTimeCompare([]byte(received), []byte(token)) == 1
+	}
+}
+
+// sendGridAuthenticator exige a assinatura ECDSA do Event Webhook
+func sendGridAuthenticator(verifier *email.SendGridWebhookVerifier) callbackAuthenticator {
+	return func(r *http.Request, body []byte) bool {
+		return verifier.Verify(
+			r.Header.Get(email.SendGridSignatureHeader),
+			r.Header.Get(email.SendGridTimestampHeader),
+			body)
+	}
+}
+
+// deliveryCallback monta o handler de callback de um provider: autentica,
+// interpreta e grava cada evento. Falha ao gravar retorna 500 para que o
+// provider reenvie o callback.
+func (d *Dashboard) deliveryCallback(provider string, authenticate callbackAuthenticator, parse callbackParser) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Método não permitido", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxCallbackBodySize))
+		if err != nil {
+			http.Error(w, "Corpo da requisição inválido", http.StatusBadRequest)
+			return
+		}
+
+		if !authenticate(r, body) {
+			d.logger.Warn("Callback de entrega não autorizado",
+				zap.String("provider", provider),
+				zap.String("remote_addr", r.RemoteAddr))
+			http.Error(w, "Não autorizado", http.StatusUnauthorized)
+			return
+		}
+
+		events, err := parse(body)
+		if err != nil {
+			d.logger.Warn("Callback de entrega inválido",
+				zap.String("provider", provider),
+				zap.Error(err))
+			http.Error(w, "Callback inválido", http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		defer cancel()
+
+		recorded := 0
+		for _, event := range events {
+			if event.Type == "" {
+				d.logger.Warn("Evento de entrega com status desconhecido ignorado",
+					zap.String("provider", provider),
+					zap.String("provider_id", event.ProviderID),
+					zap.String("status", event.Status))
+				continue
+			}
+
+			found, err := d.deliveryRecorder.RecordDeliveryEvent(ctx, event)
+			if err != nil {
+				d.logger.Error("Erro ao registrar evento de entrega",
+					zap.String("provider", provider),
+					zap.String("provider_id", event.ProviderID),
+					zap.Int64("mensagem_id", event.MessageID),
+					zap.Error(err))
+				http.Error(w, "Erro ao registrar evento", http.StatusInternalServerError)
+				return
+			}
+			if !found {
+				d.logger.Debug("Evento de entrega sem email correspondente",
+					zap.String("provider", provider),
+					zap.String("provider_id", event.ProviderID),
+					zap.Int64("mensagem_id", event.MessageID),
+					zap.String("evento", string(event.Type)))
+				continue
+			}
+
+			recorded++
+			if event.HardBounce() {
+				d.logger.Warn("Hard bounce recebido, email marcado como inválido",
+					zap.String("provider", provider),
+					zap.String("provider_id", event.ProviderID),
+					zap.Int64("mensagem_id", event.MessageID),
+					zap.String("destinatario", event.Recipient),
+					zap.String("detalhes", event.Detail))
+			}
+		}
+
+		d.logger.Info("Callback de entrega recebido",
+			zap.String("provider", provider),
+			zap.Int("eventos", len(events)),
+			zap.Int("registrados", recorded))
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]int{
+			"eventos":     len(events),
+			"registrados": recorded,
+		})
+	}
+}
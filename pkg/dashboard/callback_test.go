@@ -0,0 +1,113 @@
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/email"
+	"go.uber.org/zap"
+)
+
+// recorderStandIn grava os eventos recebidos; err simula falha do banco e
+// known lista os IDs de provider que correspondem a um email
+type recorderStandIn struct {
+	events []email.DeliveryEvent
+	known  map[string]bool
+	err    error
+}
+
+func (r *recorderStandIn) RecordDeliveryEvent(ctx context.Context, event email.DeliveryEvent) (bool, error) {
+	if r.err != nil {
+		return false, r.err
+	}
+	r.events = append(r.events, event)
+	return r.known[event.ProviderID], nil
+}
+
+func newCallbackTestDashboard(recorder DeliveryEventRecorder) *Dashboard {
+	return &Dashboard{logger: zap.NewNop(), deliveryRecorder: recorder}
+}
+
+// postCallback envia o corpo ao handler de callback da Pontaltech com o token informado
+func postCallback(d *Dashboard, target, token, body string) *httptest.ResponseRecorder {
+	handler := d.deliveryCallback("pontaltech", tokenAuthenticator("segredo"), email.ParsePontaltechCallback)
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("X-Callback-Token", token)
+	}
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func TestDeliveryCallbackToken(t *testing.T) {
+	body := `{"id": 101, "status": "delivered"}`
+
+	tests := []struct {
+		name   string
+		target string
+		header string
+		want   int
+	}{
+		{"token no header", "/api/callback/pontaltech", "segredo", http.StatusOK},
+		{"token no parâmetro", "/api/callback/pontaltech?token=segredo", "", http.StatusOK},
+		{"header incorreto", "/api/callback/pontaltech", "outro", http.StatusUnauthorized},
+		{"parâmetro incorreto", "/api/callback/pontaltech?token=outro", "", http.StatusUnauthorized},
+		{"sem token", "/api/callback/pontaltech", "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		recorder := &recorderStandIn{known: map[string]bool{"101": true}}
+		rec := postCallback(newCallbackTestDashboard(recorder), tt.target, tt.header, body)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status %d, esperado %d", tt.name, rec.Code, tt.want)
+		}
+		if tt.want == http.StatusUnauthorized && len(recorder.events) > 0 {
+			t.Errorf("%s: callback não autorizado gravou %d eventos", tt.name, len(recorder.events))
+		}
+	}
+}
+
+func TestDeliveryCallbackRecordsEvents(t *testing.T) {
+	recorder := &recorderStandIn{known: map[string]bool{"101": true, "102": true}}
+	body := `{"messages": [
+		{"id": 101, "status": "DELIVERED"},
+		{"id": 102, "status": "Hard-Bounce", "reason": "caixa inexistente"},
+		{"id": 103, "status": "opened"},
+		{"id": 104, "status": "status novo"}
+	]}`
+
+	rec := postCallback(newCallbackTestDashboard(recorder), "/api/callback/pontaltech", "segredo", body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+	// Status desconhecido não é gravado; 103 não corresponde a nenhum email
+	if len(recorder.events) != 3 {
+		t.Fatalf("%d eventos gravados, esperado 3", len(recorder.events))
+	}
+	if recorder.events[1].Type != email.DeliveryBounced || recorder.events[1].Detail != "caixa inexistente" {
+		t.Errorf("evento 102 = %+v, esperado bounce com motivo", recorder.events[1])
+	}
+	if got := strings.TrimSpace(rec.Body.String()); got != `{"eventos":4,"registrados":2}` {
+		t.Errorf("resposta = %s", got)
+	}
+}
+
+func TestDeliveryCallbackRecorderError(t *testing.T) {
+	recorder := &recorderStandIn{err: errors.New("ORA-03113: end-of-file on communication channel")}
+
+	// Falha ao gravar: 500 para que o provider reenvie o callback
+	rec := postCallback(newCallbackTestDashboard(recorder), "/api/callback/pontaltech", "segredo", `{"id": 101, "status": "delivered"}`)
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status %d, esperado 500", rec.Code)
+	}
+
+	rec = postCallback(newCallbackTestDashboard(recorder), "/api/callback/pontaltech", "segredo", `{"status": "delivered"}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("callback sem ID: status %d, esperado 400", rec.Code)
+	}
+}
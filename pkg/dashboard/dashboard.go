@@ -9,6 +9,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/email"
 	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/metrics"
 	"go.uber.org/zap"
 )
@@ -30,9 +31,16 @@ type Dashboard struct {
 	clients         map[chan []byte]bool
 	port            int
 	providerName    string
+	providerDisplay string // Nomes de exibição da cadeia de providers
+	rateLimitPerMin int
 	mux             *http.ServeMux
 	manualHandler   ManualHandler
 	templateHandler TemplateHandler
+	circuitSource   ProviderCircuitSource
+	deliveryRecorder DeliveryEventRecorder
+	callbackConfig   CallbackConfig
+	sendGridVerifier *email.SendGridWebhookVerifier
+	eventSource      EmailEventSource
 }
 
 // Config contém as configurações do dashboard
@@ -42,6 +50,12 @@ type Config struct {
 	ProviderName    string
 	DaysOffset      int
 	MaxTentativas   int
+	RateLimitPerMin int // Limite global de envios por minuto (0 = sem limite)
+}
+
+// ProviderCircuitSource interface para consultar o circuit breaker dos providers
+type ProviderCircuitSource interface {
+	CircuitStates() []email.ProviderCircuitState
 }
 
 // ManualHandler interface para handlers de disparo manual
@@ -72,6 +86,7 @@ type TemplateHandler interface {
 type MetricsSnapshot struct {
 	Timestamp              time.Time `json:"timestamp"`
 	ProviderName           string    `json:"provider_name"`
+	ProviderDisplayName    string    `json:"provider_display_name"`
 	TotalMessagesProcessed int64     `json:"total_messages_processed"`
 	SuccessCount           int64     `json:"success_count"`
 	ErrorCount             int64     `json:"error_count"`
@@ -87,6 +102,13 @@ type MetricsSnapshot struct {
 	EmailSendErrorCount    int64     `json:"email_send_error_count"`
 	EmailSendSuccessRate   float64   `json:"email_send_success_rate"`
 	PendingMessagesCount   int64     `json:"pending_messages_count"`
+	RateLimitPerMin        int       `json:"rate_limit_per_min"`
+	RateLimitWaitCount     int64     `json:"rate_limit_wait_count"`
+	AvgRateLimitWait       float64   `json:"avg_rate_limit_wait_ms"`
+	TotalRateLimitWait     float64   `json:"total_rate_limit_wait_ms"`
+	DomainStats            []metrics.DomainStat `json:"domain_stats"`
+	ProviderSplit          []metrics.ProviderStat `json:"provider_split"`
+	ProviderCircuits       []email.ProviderCircuitState `json:"provider_circuits"`
 }
 
 // NewDashboard cria uma nova instância do dashboard
@@ -100,7 +122,19 @@ func NewDashboard(config Config, metricsSource *metrics.PerformanceMetrics, mess
 		clients:       make(map[chan []byte]bool),
 		port:          config.Port,
 		providerName:  config.ProviderName,
+		providerDisplay: providerDisplayName(config.ProviderName),
+		rateLimitPerMin: config.RateLimitPerMin,
+	}
+}
+
+// providerDisplayName monta o nome de exibição da cadeia de failover
+// ("pontaltech,sendgrid" -> "📡 Pontaltech → 📨 SendGrid")
+func providerDisplayName(providerName string) string {
+	names := strings.Split(providerName, ",")
+	for i, name := range names {
+		names[i] = email.ProviderDisplayName(strings.TrimSpace(name))
 	}
+	return strings.Join(names, " → ")
 }
 
 // RegisterManualEndpoints registra os endpoints de disparo manual
@@ -108,6 +142,11 @@ func (d *Dashboard) RegisterManualEndpoints(handler ManualHandler) {
 	d.manualHandler = handler
 }
 
+// RegisterProviderCircuits registra a fonte do estado do circuit breaker dos providers
+func (d *Dashboard) RegisterProviderCircuits(source ProviderCircuitSource) {
+	d.circuitSource = source
+}
+
 // RegisterTemplateEndpoints registra os endpoints de templates
 func (d *Dashboard) RegisterTemplateEndpoints(handler TemplateHandler) {
 	d.templateHandler = handler
@@ -144,6 +183,14 @@ func (d *Dashboard) Start() error {
 		d.mux.HandleFunc("/api/templates", d.handleTemplatesAPI)
 	}
 
+	// Histórico de eventos dos e-mails (se configurado)
+	if d.eventSource != nil {
+		d.mux.HandleFunc("/api/emails/", d.handleEmailEvents)
+	}
+
+	// Callbacks de eventos de entrega dos providers (se configurado)
+	d.registerCallbackRoutes()
+
 	// Servir página principal do dashboard
 	d.mux.HandleFunc("/", d.handleIndex)
 
@@ -269,9 +316,15 @@ func (d *Dashboard) getMetricsSnapshot() MetricsSnapshot {
 		}
 	}
 
+	var providerCircuits []email.ProviderCircuitState
+	if d.circuitSource != nil {
+		providerCircuits = d.circuitSource.CircuitStates()
+	}
+
 	return MetricsSnapshot{
 		Timestamp:              time.Now(),
 		ProviderName:           d.providerName,
+		ProviderDisplayName:    d.providerDisplay,
 		TotalMessagesProcessed: stats.TotalMessagesProcessed,
 		SuccessCount:           stats.SuccessCount,
 		ErrorCount:             stats.ErrorCount,
@@ -287,6 +340,13 @@ func (d *Dashboard) getMetricsSnapshot() MetricsSnapshot {
 		EmailSendErrorCount:    stats.PushSendErrorCount,
 		EmailSendSuccessRate:   emailSendSuccessRate,
 		PendingMessagesCount:   pendingCount,
+		RateLimitPerMin:        d.rateLimitPerMin,
+		RateLimitWaitCount:     stats.RateLimitWaitCount,
+		AvgRateLimitWait:       stats.AvgRateLimitWaitMs,
+		TotalRateLimitWait:     stats.TotalRateLimitWaitMs,
+		DomainStats:            d.metricsSource.GetDomainStats(),
+		ProviderSplit:          d.metricsSource.GetProviderStats(),
+		ProviderCircuits:       providerCircuits,
 	}
 }
 
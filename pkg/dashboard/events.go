@@ -0,0 +1,74 @@
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/message"
+	"go.uber.org/zap"
+)
+
+// EmailEventSource interface para consultar o histórico de eventos de um email
+type EmailEventSource interface {
+	ListByEmail(ctx context.Context, emailID int64) ([]message.Event, error)
+}
+
+// EmailEventsResponse resposta de GET /api/emails/{id}/events
+type EmailEventsResponse struct {
+	EmailID int64           `json:"emailId"`
+	Events  []message.Event `json:"events"`
+}
+
+// RegisterEmailEvents registra o endpoint do histórico de eventos dos emails
+func (d *Dashboard) RegisterEmailEvents(source EmailEventSource) {
+	d.eventSource = source
+}
+
+// handleEmailEvents retorna a linha do tempo do email (GET /api/emails/{id}/events)
+func (d *Dashboard) handleEmailEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// /api/emails/{id}/events
+	path := strings.TrimPrefix(r.URL.Path, "/api/emails/")
+	idStr, ok := strings.CutSuffix(path, "/events")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	emailID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || emailID <= 0 {
+		http.Error(w, "ID do e-mail inválido", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	events, err := d.eventSource.ListByEmail(ctx, emailID)
+	if err != nil {
+		d.logger.Error("Erro ao buscar eventos do e-mail",
+			zap.Int64("email_id", emailID),
+			zap.Error(err))
+		http.Error(w, "Erro ao buscar eventos do e-mail", http.StatusInternalServerError)
+		return
+	}
+
+	// Linha do tempo pela data do evento no provider (callbacks chegam fora de ordem)
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(EmailEventsResponse{
+		EmailID: emailID,
+		Events:  events,
+	})
+}
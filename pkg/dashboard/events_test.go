@@ -0,0 +1,117 @@
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/message"
+	"go.uber.org/zap"
+)
+
+// eventSourceStandIn devolve os eventos cadastrados por email
+type eventSourceStandIn struct {
+	events map[int64][]message.Event
+	err    error
+}
+
+func (s *eventSourceStandIn) ListByEmail(ctx context.Context, emailID int64) ([]message.Event, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	events := s.events[emailID]
+	if events == nil {
+		events = []message.Event{}
+	}
+	return events, nil
+}
+
+// getEmailEvents chama GET no caminho informado
+func getEmailEvents(source EmailEventSource, path string) *httptest.ResponseRecorder {
+	d := &Dashboard{logger: zap.NewNop(), eventSource: source}
+	rec := httptest.NewRecorder()
+	d.handleEmailEvents(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+func TestEmailEventsTimeline(t *testing.T) {
+	sentAt := time.Date(2025, 12, 16, 10, 0, 0, 0, time.UTC)
+	source := &eventSourceStandIn{events: map[int64][]message.Event{
+		42: {
+			{ID: 1, EmailID: 42, Type: message.EventQueued, Timestamp: sentAt},
+			{ID: 2, EmailID: 42, Type: message.EventAccepted, Timestamp: sentAt.Add(2 * time.Second)},
+			// Abertura gravada antes da entrega (callbacks fora de ordem)
+			{ID: 4, EmailID: 42, Type: message.EventOpened, Timestamp: sentAt.Add(5 * time.Minute)},
+			{ID: 3, EmailID: 42, Type: message.EventDelivered, Timestamp: sentAt.Add(30 * time.Second)},
+			{ID: 5, EmailID: 42, Type: message.EventAttemptStarted, Timestamp: sentAt.Add(time.Second)},
+		},
+	}}
+
+	rec := getEmailEvents(source, "/api/emails/42/events")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var response EmailEventsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("resposta inválida: %v", err)
+	}
+	if response.EmailID != 42 {
+		t.Errorf("emailId = %d, esperado 42", response.EmailID)
+	}
+
+	want := []message.EventType{
+		message.EventQueued, message.EventAttemptStarted, message.EventAccepted,
+		message.EventDelivered, message.EventOpened,
+	}
+	if len(response.Events) != len(want) {
+		t.Fatalf("%d eventos, esperado %d", len(response.Events), len(want))
+	}
+	for i, event := range response.Events {
+		if event.Type != want[i] {
+			t.Errorf("evento %d = %s, esperado %s (ordem pela data do evento)", i, event.Type, want[i])
+		}
+	}
+
+	// Email sem eventos: lista vazia, não null
+	rec = getEmailEvents(source, "/api/emails/7/events")
+	if rec.Code != http.StatusOK || rec.Body.String() != "{\"emailId\":7,\"events\":[]}\n" {
+		t.Errorf("email sem eventos: %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestEmailEventsBadRequest(t *testing.T) {
+	source := &eventSourceStandIn{}
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/api/emails/abc/events", http.StatusBadRequest},
+		{"/api/emails/0/events", http.StatusBadRequest},
+		{"/api/emails/-5/events", http.StatusBadRequest},
+		{"/api/emails//events", http.StatusBadRequest},
+		{"/api/emails/42", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if rec := getEmailEvents(source, tt.path); rec.Code != tt.want {
+			t.Errorf("%s: status %d, esperado %d", tt.path, rec.Code, tt.want)
+		}
+	}
+
+	d := &Dashboard{logger: zap.NewNop(), eventSource: source}
+	rec := httptest.NewRecorder()
+	d.handleEmailEvents(rec, httptest.NewRequest(http.MethodPost, "/api/emails/42/events", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: status %d, esperado 405", rec.Code)
+	}
+
+	source.err = errors.New("ORA-12541: TNS:no listener")
+	if rec := getEmailEvents(source, "/api/emails/42/events"); rec.Code != http.StatusInternalServerError {
+		t.Errorf("falha na consulta: status %d, esperado 500", rec.Code)
+	}
+}
@@ -173,6 +173,28 @@ const dashboardHTML = `<!DOCTYPE html>
             font-weight: 600;
         }
 
+        .stats-table {
+            width: 100%;
+            border-collapse: collapse;
+        }
+
+        .stats-table th, .stats-table td {
+            padding: 10px;
+            text-align: left;
+            border-bottom: 1px solid #eee;
+        }
+
+        .stats-table th {
+            color: #666;
+            font-size: 0.85em;
+            text-transform: uppercase;
+        }
+
+        .stats-empty {
+            color: #999;
+            font-style: italic;
+        }
+
         canvas {
             max-height: 300px;
         }
@@ -298,6 +320,11 @@ const dashboardHTML = `<!DOCTYPE html>
                     <div class="metric-label">Tempo de Query</div>
                     <div class="metric-value" id="avg-query-time">0ms</div>
                 </div>
+                <div class="metric-item">
+                    <div class="metric-label">Espera Rate Limit (<span id="rate-limit-per-min">-</span>/min)</div>
+                    <div class="metric-value" id="avg-rate-limit-wait">0ms</div>
+                    <div class="metric-label" id="rate-limit-wait-count">0 envios aguardaram</div>
+                </div>
             </div>
             <canvas id="timeChart"></canvas>
         </div>
@@ -324,6 +351,59 @@ const dashboardHTML = `<!DOCTYPE html>
             </div>
         </div>
 
+        <div class="chart-container">
+            <div class="chart-title">🌐 Limites por Domínio do Destinatário</div>
+            <table class="stats-table">
+                <thead>
+                    <tr>
+                        <th>Domínio</th>
+                        <th>Liberados</th>
+                        <th>Adiados</th>
+                        <th>Em andamento</th>
+                    </tr>
+                </thead>
+                <tbody id="domain-stats">
+                    <tr><td colspan="4" class="stats-empty">Nenhum limite por domínio configurado</td></tr>
+                </tbody>
+            </table>
+        </div>
+
+        <div class="chart-container">
+            <div class="chart-title">🔀 Divisão de Tráfego por Provider</div>
+            <table class="stats-table">
+                <thead>
+                    <tr>
+                        <th>Provider</th>
+                        <th>Peso</th>
+                        <th>Direcionados</th>
+                        <th>% do Tráfego</th>
+                        <th>Enviados</th>
+                        <th>Falhas</th>
+                    </tr>
+                </thead>
+                <tbody id="provider-split">
+                    <tr><td colspan="6" class="stats-empty">Nenhum envio registrado</td></tr>
+                </tbody>
+            </table>
+        </div>
+
+        <div class="chart-container">
+            <div class="chart-title">🛡️ Circuit Breaker por Provider</div>
+            <table class="stats-table">
+                <thead>
+                    <tr>
+                        <th>Provider</th>
+                        <th>Estado</th>
+                        <th>Falhas Consecutivas</th>
+                        <th>Novo Teste</th>
+                    </tr>
+                </thead>
+                <tbody id="provider-circuits">
+                    <tr><td colspan="4" class="stats-empty">Nenhum provider registrado</td></tr>
+                </tbody>
+            </table>
+        </div>
+
         <div class="timestamp" id="last-update">Última atualização: --</div>
     </div>
 
@@ -450,17 +530,10 @@ const dashboardHTML = `<!DOCTYPE html>
         };
 
         function updateDashboard(metrics) {
-            // Atualizar nome do provider
+            // Atualizar nome do provider (cadeia de failover: "📡 Pontaltech → 📨 SendGrid")
             if (metrics.provider_name) {
-                const providerNames = {
-                    'mock': '🧪 Mock (Teste)',
-                    'smtp': '📧 SMTP',
-                    'sendgrid': '📨 SendGrid',
-                    'zenvia': '🇧🇷 Zenvia',
-                    'pontaltech': '📡 Pontaltech'
-                };
-                const displayName = providerNames[metrics.provider_name] || metrics.provider_name.toUpperCase();
-                document.getElementById('provider-name').textContent = displayName;
+                document.getElementById('provider-name').textContent =
+                    metrics.provider_display_name || metrics.provider_name.toUpperCase();
             }
 
             // Atualizar cards principais
@@ -479,12 +552,57 @@ const dashboardHTML = `<!DOCTYPE html>
             document.getElementById('avg-send-time').textContent = metrics.avg_send_time_ms.toFixed(2) + 'ms';
             document.getElementById('avg-query-time').textContent = metrics.avg_query_time_ms.toFixed(2) + 'ms';
 
+            // Atualizar limitador de taxa
+            document.getElementById('rate-limit-per-min').textContent =
+                metrics.rate_limit_per_min > 0 ? metrics.rate_limit_per_min.toLocaleString() : 'sem limite';
+            document.getElementById('avg-rate-limit-wait').textContent = metrics.avg_rate_limit_wait_ms.toFixed(2) + 'ms';
+            document.getElementById('rate-limit-wait-count').textContent =
+                metrics.rate_limit_wait_count.toLocaleString() + ' envios aguardaram';
+
             // Atualizar métricas de email
             document.getElementById('email-success-count').textContent = metrics.email_send_success_count.toLocaleString();
             document.getElementById('email-error-count').textContent = metrics.email_send_error_count.toLocaleString();
             document.getElementById('email-success-rate').textContent = metrics.email_send_success_rate.toFixed(1) + '%';
             document.getElementById('queries-executed').textContent = metrics.queries_executed.toLocaleString();
 
+            // Atualizar limites por domínio
+            if (metrics.domain_stats && metrics.domain_stats.length > 0) {
+                document.getElementById('domain-stats').innerHTML = metrics.domain_stats.map(stat =>
+                    '<tr><td>' + stat.domain + '</td>' +
+                    '<td class="success">' + stat.dispatched.toLocaleString() + '</td>' +
+                    '<td class="warning">' + stat.deferred.toLocaleString() + '</td>' +
+                    '<td class="info">' + stat.in_flight.toLocaleString() + '</td></tr>'
+                ).join('');
+            }
+
+            // Atualizar divisão de tráfego por provider
+            if (metrics.provider_split && metrics.provider_split.length > 0) {
+                const totalRouted = metrics.provider_split.reduce((sum, stat) => sum + stat.routed, 0);
+                document.getElementById('provider-split').innerHTML = metrics.provider_split.map(stat =>
+                    '<tr><td>' + stat.provider + '</td>' +
+                    '<td>' + (stat.weight > 0 ? stat.weight : '-') + '</td>' +
+                    '<td class="info">' + stat.routed.toLocaleString() + '</td>' +
+                    '<td class="info">' + (totalRouted > 0 ? (stat.routed / totalRouted * 100).toFixed(1) : '0.0') + '%</td>' +
+                    '<td class="success">' + stat.sent.toLocaleString() + '</td>' +
+                    '<td class="error">' + stat.failed.toLocaleString() + '</td></tr>'
+                ).join('');
+            }
+
+            // Atualizar circuit breaker por provider
+            if (metrics.provider_circuits && metrics.provider_circuits.length > 0) {
+                const circuitStates = {
+                    'closed': '<span class="success">● Fechado</span>',
+                    'half-open': '<span class="warning">● Em teste</span>',
+                    'open': '<span class="error">● Aberto</span>'
+                };
+                document.getElementById('provider-circuits').innerHTML = metrics.provider_circuits.map(circuit =>
+                    '<tr><td>' + circuit.provider + '</td>' +
+                    '<td>' + (circuitStates[circuit.state] || circuit.state) + '</td>' +
+                    '<td>' + circuit.consecutive_failures.toLocaleString() + '</td>' +
+                    '<td>' + (circuit.retry_at ? new Date(circuit.retry_at).toLocaleTimeString('pt-BR') : '-') + '</td></tr>'
+                ).join('');
+            }
+
             // Atualizar timestamp
             const timestamp = new Date(metrics.timestamp);
             document.getElementById('last-update').textContent =
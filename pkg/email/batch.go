@@ -0,0 +1,122 @@
+package email
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// BatchProvider é implementado pelos providers que aceitam várias mensagens
+// (destinatários diferentes, mesmo conteúdo) em uma única chamada à API
+type BatchProvider interface {
+	Provider
+
+	// BatchKey agrupa mensagens que podem ir na mesma chamada. Mensagens com a
+	// mesma chave diferem apenas no que o provider envia por destinatário.
+	// Vazio = mensagem não pode ser enviada em lote.
+	BatchKey(email EmailData) string
+
+	// MaxBatchSize número máximo de mensagens por chamada
+	MaxBatchSize() int
+
+	// SendBatch envia mensagens com a mesma BatchKey e retorna um resultado por
+	// mensagem, na mesma ordem. O erro indica falha da chamada inteira
+	// (nenhuma mensagem foi aceita).
+	SendBatch(ctx context.Context, emails []EmailData) ([]SendResult, error)
+}
+
+// batchKeyHash gera uma chave curta a partir dos campos que precisam ser
+// iguais entre as mensagens do lote (o corpo pode ser grande)
+func batchKeyHash(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// batchContent retorna o conteúdo compartilhado pelas mensagens do lote: o
+// template, quando o corpo foi gerado a partir de um (as macros são enviadas
+// por destinatário), ou o próprio corpo
+func batchContent(email EmailData) string {
+	if email.BodyTemplate != "" {
+		return "template\x00" + email.BodyTemplate
+	}
+	return "corpo\x00" + email.Body
+}
+
+// BatchKey retorna a chave de lote do email no provider para onde ele seria
+// roteado, e o tamanho máximo do lote. Vazio = enviar individualmente (provider
+// sem suporte a lote, circuito aberto ou mensagem não agrupável).
+func (s *Sender) BatchKey(email EmailData) (string, int) {
+	if email.Provider != "" && s.Lookup(email.Provider) == nil {
+		return "", 0
+	}
+
+	provider := s.Route(email)
+	batcher, ok := provider.(BatchProvider)
+	if !ok || s.circuits[provider.GetName()].retryAfter() > 0 {
+		return "", 0
+	}
+	if provider.ValidateEmail(email.To) != nil {
+		return "", 0
+	}
+
+	key := batcher.BatchKey(email)
+	if key == "" {
+		return "", 0
+	}
+	return provider.GetName() + ":" + key, batcher.MaxBatchSize()
+}
+
+// SendBatch envia em uma única chamada mensagens com a mesma BatchKey, pelo
+// provider para onde elas são roteadas (sem failover). Se a chamada falhar, o
+// erro é retornado e as mensagens devem ser reenviadas individualmente com Send.
+func (s *Sender) SendBatch(ctx context.Context, emails []EmailData) ([]SendResult, error) {
+	if len(emails) == 0 {
+		return nil, nil
+	}
+
+	provider := s.Route(emails[0])
+	name := provider.GetName()
+	batcher, ok := provider.(BatchProvider)
+	if !ok {
+		return nil, fmt.Errorf("provider %s não suporta envio em lote", name)
+	}
+
+	circuit := s.circuits[name]
+	if !circuit.allow() {
+		return nil, ErrNoProviderAvailable
+	}
+
+	s.logger.Info("Enviando lote de emails",
+		zap.String("provider", name),
+		zap.Int("total", len(emails)))
+
+	results, err := batcher.SendBatch(ctx, emails)
+	if err == nil && len(results) != len(emails) {
+		err = fmt.Errorf("provider %s retornou %d resultados para %d mensagens", name, len(results), len(emails))
+	}
+	if err != nil {
+		if isRetryableError(err) {
+			if circuit.recordFailure() {
+				s.logger.Error("Circuit breaker do provider aberto",
+					zap.String("provider", name))
+			}
+		} else {
+			circuit.recordSuccess()
+		}
+		s.logger.Warn("Falha no envio em lote",
+			zap.String("provider", name),
+			zap.Int("total", len(emails)),
+			zap.Error(err))
+		return nil, err
+	}
+
+	circuit.recordSuccess()
+	for i := range results {
+		results[i].Provider = name
+	}
+	return results, nil
+}
@@ -0,0 +1,154 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// batchTestEmails dois emails gerados a partir do mesmo template, com macros diferentes
+func batchTestEmails() []EmailData {
+	template := "<p>Olá {{nome}}, seu código é {{codigo}}</p>"
+	return []EmailData{
+		{
+			ID: 1, From: "noreply@exemplo.com.br", To: "ana@destino.com", Subject: "Bem-vindo",
+			Body: "<p>Olá Ana, seu código é 10</p>", ContentType: "text/html",
+			BodyTemplate: template, Variables: map[string]string{"nome": "Ana", "codigo": "10"},
+		},
+		{
+			ID: 2, From: "noreply@exemplo.com.br", To: "bruno@destino.com", Subject: "Bem-vindo",
+			Body: "<p>Olá Bruno, seu código é 20</p>", ContentType: "text/html",
+			BodyTemplate: template, Variables: map[string]string{"nome": "Bruno", "codigo": "20"},
+		},
+	}
+}
+
+func TestBatchKeyTemplate(t *testing.T) {
+	providers := []BatchProvider{
+		NewSendGridProvider("chave", zap.NewNop()),
+		NewPontaltechProvider("usuario", "senha", 1, "http://localhost", "", zap.NewNop()),
+	}
+
+	for _, provider := range providers {
+		emails := batchTestEmails()
+		if provider.BatchKey(emails[0]) != provider.BatchKey(emails[1]) {
+			t.Errorf("%s: emails do mesmo template deveriam ter a mesma chave", provider.GetName())
+		}
+
+		// Sem template, corpos diferentes não podem ir no mesmo lote
+		emails[0].BodyTemplate, emails[1].BodyTemplate = "", ""
+		if provider.BatchKey(emails[0]) == provider.BatchKey(emails[1]) {
+			t.Errorf("%s: corpos diferentes sem template não deveriam ter a mesma chave", provider.GetName())
+		}
+	}
+}
+
+// sendGridTransport redireciona as requisições do SendGrid para o servidor de teste
+type sendGridTransport struct {
+	target *url.URL
+}
+
+func (st sendGridTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r.URL.Scheme = st.target.Scheme
+	r.URL.Host = st.target.Host
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestSendGridSendBatchSubstitutions(t *testing.T) {
+	var received SendGridRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("corpo inválido: %v", err)
+		}
+		w.Header().Set("X-Message-Id", "lote-1")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	target, _ := url.Parse(server.URL)
+	provider := NewSendGridProvider("chave", zap.NewNop())
+	provider.httpClient.Transport = sendGridTransport{target: target}
+
+	results, err := provider.SendBatch(context.Background(), batchTestEmails())
+	if err != nil {
+		t.Fatalf("SendBatch: %v", err)
+	}
+	if len(results) != 2 || !results[0].Success || results[1].ProviderID != "lote-1" {
+		t.Errorf("resultados inesperados: %+v", results)
+	}
+
+	if len(received.Content) != 1 || received.Content[0].Value != batchTestEmails()[0].BodyTemplate {
+		t.Errorf("conteúdo = %+v, esperado o template", received.Content)
+	}
+	if len(received.Personalizations) != 2 {
+		t.Fatalf("%d personalizations, esperado 2", len(received.Personalizations))
+	}
+	want := []map[string]string{
+		{"{{nome}}": "Ana", "{{codigo}}": "10"},
+		{"{{nome}}": "Bruno", "{{codigo}}": "20"},
+	}
+	for i, personalization := range received.Personalizations {
+		for key, value := range want[i] {
+			if personalization.Substitutions[key] != value {
+				t.Errorf("personalization %d: %s = %q, esperado %q", i, key, personalization.Substitutions[key], value)
+			}
+		}
+	}
+}
+
+// pontaltechStandIn simula a API de envio da Pontaltech com a resposta informada
+func pontaltechStandIn(t *testing.T, response string, received *PontaltechEmailRequest) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(received); err != nil {
+			t.Errorf("corpo inválido: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(response))
+	}))
+}
+
+func TestPontaltechSendBatchVariables(t *testing.T) {
+	var received PontaltechEmailRequest
+	server := pontaltechStandIn(t, `{"messages":[{"email":"bruno@destino.com","id":202},{"email":"ana@destino.com","id":101}],"campaignId":9}`, &received)
+	defer server.Close()
+
+	provider := NewPontaltechProvider("usuario", "senha", 1, server.URL, "", zap.NewNop())
+	results, err := provider.SendBatch(context.Background(), batchTestEmails())
+	if err != nil {
+		t.Fatalf("SendBatch: %v", err)
+	}
+	if results[0].ProviderID != "101" || results[1].ProviderID != "202" {
+		t.Errorf("ProviderIDs = %q, %q; esperado 101, 202", results[0].ProviderID, results[1].ProviderID)
+	}
+
+	if received.MailBody != batchTestEmails()[0].BodyTemplate || !received.ReplaceVariable {
+		t.Errorf("mailBody = %q, replaceVariable = %v; esperado o template com substituição", received.MailBody, received.ReplaceVariable)
+	}
+	if len(received.To) != 2 || received.To[0].MessageVariable["nome"] != "Ana" || received.To[1].MessageVariable["codigo"] != "20" {
+		t.Errorf("destinatários = %+v, esperado variáveis por destinatário", received.To)
+	}
+}
+
+func TestPontaltechSendBatchUnparsableResponse(t *testing.T) {
+	var received PontaltechEmailRequest
+	server := pontaltechStandIn(t, "OK", &received)
+	defer server.Close()
+
+	provider := NewPontaltechProvider("usuario", "senha", 1, server.URL, "", zap.NewNop())
+	results, err := provider.SendBatch(context.Background(), batchTestEmails())
+	if err != nil {
+		t.Fatalf("SendBatch: %v", err)
+	}
+	if !results[0].Success || !results[1].Success {
+		t.Fatalf("resultados = %+v, esperado sucesso", results)
+	}
+	if results[0].ProviderID == results[1].ProviderID {
+		t.Errorf("ProviderID %q repetido no lote: um callback seria aplicado a todos os emails", results[0].ProviderID)
+	}
+}
@@ -0,0 +1,138 @@
+package email
+
+import (
+	"sync"
+	"time"
+)
+
+// Estados do circuit breaker de provider
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half-open"
+)
+
+// ProviderCircuitState estado do circuit breaker de um provider (dashboard e /health)
+type ProviderCircuitState struct {
+	Provider            string     `json:"provider"`
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	RetryAt             *time.Time `json:"retry_at,omitempty"` // Quando o circuito aberto será testado novamente
+}
+
+// providerCircuit circuit breaker de um provider: abre após `threshold`
+// falhas consecutivas e, após `timeout`, libera um único envio de teste
+// (half-open) que fecha ou reabre o circuito
+type providerCircuit struct {
+	mu              sync.Mutex
+	state           string
+	failureCount    int
+	lastFailureTime time.Time
+	probing         bool      // Envio de teste em andamento (half-open)
+	probeStarted    time.Time // Início do envio de teste; após `timeout` sem resultado outro é liberado
+	threshold       int
+	timeout         time.Duration
+}
+
+// newProviderCircuit cria um circuit breaker fechado. threshold <= 0 desabilita.
+func newProviderCircuit(threshold int, timeout time.Duration) *providerCircuit {
+	return &providerCircuit{
+		state:     CircuitClosed,
+		threshold: threshold,
+		timeout:   timeout,
+	}
+}
+
+// allow indica se o provider pode ser usado agora. No estado half-open apenas
+// um envio de teste é liberado por vez; os demais aguardam o resultado dele.
+func (c *providerCircuit) allow() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch c.state {
+	case CircuitOpen:
+		if time.Since(c.lastFailureTime) < c.timeout {
+			return false
+		}
+		c.state = CircuitHalfOpen
+	case CircuitHalfOpen:
+		// Envio de teste sem resultado após `timeout` (abandonado): liberar outro
+		if c.probing && time.Since(c.probeStarted) < c.timeout {
+			return false
+		}
+	default:
+		return true
+	}
+
+	c.probing = true
+	c.probeStarted = time.Now()
+	return true
+}
+
+// retryAfter retorna quanto tempo falta para o provider poder ser usado
+// (0 se já pode ser usado), sem alterar o estado do circuito
+func (c *providerCircuit) retryAfter() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch c.state {
+	case CircuitOpen:
+		if remaining := c.timeout - time.Since(c.lastFailureTime); remaining > 0 {
+			return remaining
+		}
+	case CircuitHalfOpen:
+		if remaining := c.timeout - time.Since(c.probeStarted); c.probing && remaining > 0 {
+			return remaining
+		}
+	}
+
+	return 0
+}
+
+func (c *providerCircuit) recordSuccess() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.failureCount = 0
+	c.probing = false
+	c.state = CircuitClosed
+}
+
+// recordFailure registra uma falha e retorna true se o circuito abriu agora
+func (c *providerCircuit) recordFailure() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.failureCount++
+	c.lastFailureTime = time.Now()
+	c.probing = false
+
+	if c.threshold <= 0 {
+		return false
+	}
+
+	wasOpen := c.state == CircuitOpen
+	if c.state == CircuitHalfOpen || c.failureCount >= c.threshold {
+		c.state = CircuitOpen
+	}
+
+	return !wasOpen && c.state == CircuitOpen
+}
+
+// snapshot retorna o estado atual do circuito
+func (c *providerCircuit) snapshot(provider string) ProviderCircuitState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state := ProviderCircuitState{
+		Provider:            provider,
+		State:               c.state,
+		ConsecutiveFailures: c.failureCount,
+	}
+	if c.state == CircuitOpen {
+		retryAt := c.lastFailureTime.Add(c.timeout)
+		state.RetryAt = &retryAt
+	}
+
+	return state
+}
@@ -0,0 +1,158 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// openTestCircuit retorna um circuito aberto cujo tempo de espera já terminou
+func openTestCircuit(timeout time.Duration) *providerCircuit {
+	circuit := newProviderCircuit(2, timeout)
+	circuit.recordFailure()
+	circuit.recordFailure()
+	circuit.lastFailureTime = time.Now().Add(-timeout)
+	return circuit
+}
+
+func TestProviderCircuitSingleProbe(t *testing.T) {
+	circuit := openTestCircuit(time.Minute)
+
+	// Vários workers ao mesmo tempo: apenas um envio de teste
+	var allowed atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if circuit.allow() {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed.Load() != 1 {
+		t.Fatalf("%d envios liberados no half-open, esperado 1", allowed.Load())
+	}
+	if state := circuit.snapshot("SMTP").State; state != CircuitHalfOpen {
+		t.Errorf("estado = %s, esperado %s", state, CircuitHalfOpen)
+	}
+	if wait := circuit.retryAfter(); wait <= 0 || wait > time.Minute {
+		t.Errorf("retryAfter = %v durante o envio de teste, esperado espera de até 1m", wait)
+	}
+
+	// Teste bem-sucedido fecha o circuito para todos
+	circuit.recordSuccess()
+	for i := 0; i < 3; i++ {
+		if !circuit.allow() {
+			t.Fatalf("envio %d bloqueado com o circuito fechado", i+1)
+		}
+	}
+}
+
+func TestProviderCircuitProbeFailureReopens(t *testing.T) {
+	circuit := openTestCircuit(time.Minute)
+
+	if !circuit.allow() {
+		t.Fatal("envio de teste deveria ser liberado após o tempo de espera")
+	}
+	if !circuit.recordFailure() {
+		t.Error("falha do envio de teste deveria reabrir o circuito")
+	}
+	if circuit.allow() {
+		t.Error("circuito reaberto não deveria liberar envios antes do tempo de espera")
+	}
+	if state := circuit.snapshot("SMTP"); state.State != CircuitOpen || state.RetryAt == nil {
+		t.Errorf("estado = %+v, esperado aberto com RetryAt", state)
+	}
+}
+
+func TestProviderCircuitAbandonedProbe(t *testing.T) {
+	circuit := openTestCircuit(50 * time.Millisecond)
+
+	if !circuit.allow() {
+		t.Fatal("envio de teste deveria ser liberado após o tempo de espera")
+	}
+	if circuit.allow() {
+		t.Fatal("segundo envio não deveria ser liberado com o teste em andamento")
+	}
+
+	// Teste sem resultado (ex: worker interrompido): outro é liberado após o timeout
+	time.Sleep(60 * time.Millisecond)
+	if circuit.retryAfter() != 0 {
+		t.Errorf("retryAfter = %v, esperado 0 após o teste abandonado", circuit.retryAfter())
+	}
+	if !circuit.allow() {
+		t.Error("novo envio de teste deveria ser liberado")
+	}
+	if circuit.allow() {
+		t.Error("apenas um novo envio de teste deveria ser liberado")
+	}
+}
+
+// probeStandIn provider que falha enquanto failing=true e segura os envios
+// até release ser fechado
+type probeStandIn struct {
+	failing atomic.Bool
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (p *probeStandIn) Send(ctx context.Context, email EmailData) (SendResult, error) {
+	p.calls.Add(1)
+	if p.failing.Load() {
+		err := NewSendError(ErrorTransient, "Teste", "", "indisponível", nil)
+		return SendResult{Error: err}, err
+	}
+	<-p.release
+	return SendResult{Success: true, ProviderID: "ok"}, nil
+}
+
+func (p *probeStandIn) GetName() string                  { return "Teste" }
+func (p *probeStandIn) ValidateEmail(email string) error { return ValidateEmail(email) }
+
+func TestSenderHalfOpenSingleProbe(t *testing.T) {
+	provider := &probeStandIn{release: make(chan struct{})}
+	provider.failing.Store(true)
+	sender := NewSender([]Provider{provider}, nil, 1, 200*time.Millisecond, zap.NewNop())
+	message := EmailData{From: "noreply@exemplo.com.br", To: "cliente@destino.com", Subject: "Teste", Body: "Corpo"}
+
+	if result := sender.Send(context.Background(), message); result.Success {
+		t.Fatal("primeiro envio deveria falhar e abrir o circuito")
+	}
+	provider.failing.Store(false)
+	time.Sleep(250 * time.Millisecond)
+
+	// Após o tempo de espera, só o envio de teste chega ao provider; os
+	// demais recebem ErrNoProviderAvailable enquanto ele não termina
+	results := make(chan SendResult, 10)
+	for i := 0; i < 10; i++ {
+		go func() { results <- sender.Send(context.Background(), message) }()
+	}
+	blocked := 0
+	for i := 0; i < 9; i++ {
+		if result := <-results; errors.Is(result.Error, ErrNoProviderAvailable) {
+			blocked++
+		}
+	}
+	if blocked != 9 {
+		t.Errorf("%d envios bloqueados durante o teste, esperado 9", blocked)
+	}
+	close(provider.release)
+	if result := <-results; !result.Success {
+		t.Errorf("envio de teste = %+v, esperado sucesso", result)
+	}
+
+	if calls := provider.calls.Load(); calls != 2 {
+		t.Errorf("provider chamado %d vezes, esperado 2 (falha + envio de teste)", calls)
+	}
+	if state := sender.circuits["Teste"].snapshot("Teste").State; state != CircuitClosed {
+		t.Errorf("estado = %s, esperado fechado após o teste", state)
+	}
+}
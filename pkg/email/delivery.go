@@ -0,0 +1,53 @@
+package email
+
+import (
+	"strings"
+	"time"
+)
+
+// DeliveryEventType tipo de evento de entrega reportado pelo provider depois
+// que a mensagem foi aceita (callback/webhook)
+type DeliveryEventType string
+
+const (
+	DeliverySent         DeliveryEventType = "sent"         // Repassada pelo provider ao servidor do destinatário
+	DeliveryDelivered    DeliveryEventType = "delivered"    // Entregue ao servidor do destinatário
+	DeliveryDeferred     DeliveryEventType = "deferred"     // Entrega adiada / soft bounce (provider retenta)
+	DeliveryBounced      DeliveryEventType = "bounced"      // Hard bounce: destinatário rejeitado definitivamente
+	DeliveryDropped      DeliveryEventType = "dropped"      // Descartada pelo provider sem tentativa de entrega
+	DeliveryOpened       DeliveryEventType = "opened"       // Aberta pelo destinatário
+	DeliveryClicked      DeliveryEventType = "clicked"      // Link clicado pelo destinatário
+	DeliverySpamReport   DeliveryEventType = "spamreport"   // Marcada como spam pelo destinatário
+	DeliveryUnsubscribed DeliveryEventType = "unsubscribed" // Destinatário cancelou a inscrição
+)
+
+// DeliveryEvent evento de entrega recebido de um provider. A mensagem é
+// localizada em MENSAGEMEMAIL pelo ID devolvido pelo provider (MessageID) ou
+// pelo ID retornado no envio (ID_PROVIDER).
+type DeliveryEvent struct {
+	Provider   string // Nome do provider (ex: "pontaltech")
+	ProviderID string // ID da mensagem no provider (ID_PROVIDER)
+	MessageID  int64  // ID em MENSAGEMEMAIL, quando o provider o devolve (ex: custom_args); 0 = usar ProviderID
+	Recipient  string // Destinatário informado no evento (opcional)
+	Type       DeliveryEventType
+	Status     string    // Status original informado pelo provider
+	Detail     string    // Motivo/descrição informados pelo provider
+	Timestamp  time.Time // Data/hora do evento no provider (zero = recebimento)
+}
+
+// HardBounce indica que o destinatário foi rejeitado definitivamente
+func (e DeliveryEvent) HardBounce() bool {
+	return e.Type == DeliveryBounced
+}
+
+// normalizeDeliveryStatus remove caixa, espaços e separadores do status do
+// provider ("Hard-Bounce", "hard_bounce" -> "hardbounce")
+func normalizeDeliveryStatus(status string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '_', '-', '.':
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(status)))
+}
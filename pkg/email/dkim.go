@@ -0,0 +1,235 @@
+package email
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// dkimSignedHeaders cabeçalhos incluídos na assinatura DKIM (quando presentes)
+var dkimSignedHeaders = []string{
+	"From", "To", "Reply-To", "Subject", "Date", "Message-ID", "MIME-Version", "Content-Type",
+}
+
+// DKIMSigner assina mensagens com DKIM (RFC 6376) usando canonicalização
+// relaxed/relaxed e chave RSA (rsa-sha256) ou Ed25519 (ed25519-sha256, RFC 8463)
+type DKIMSigner struct {
+	domain    string
+	selector  string
+	key       crypto.Signer
+	algorithm string
+}
+
+// dkimKey chave privada DKIM já validada e o algoritmo correspondente
+type dkimKey struct {
+	signer    crypto.Signer
+	algorithm string
+}
+
+// NewDKIMSigner cria um assinador DKIM a partir de uma chave privada PEM
+// (PKCS#1 ou PKCS#8)
+func NewDKIMSigner(domain, selector string, pemKey []byte) (*DKIMSigner, error) {
+	key, err := parseDKIMKey(pemKey)
+	if err != nil {
+		return nil, err
+	}
+	return newDKIMSigner(domain, selector, key)
+}
+
+// LoadDKIMSigner cria um assinador DKIM lendo a chave privada de um arquivo PEM
+func LoadDKIMSigner(domain, selector, keyFile string) (*DKIMSigner, error) {
+	key, err := loadDKIMKey(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	return newDKIMSigner(domain, selector, key)
+}
+
+// newDKIMSigner cria o assinador para uma chave já carregada
+func newDKIMSigner(domain, selector string, key *dkimKey) (*DKIMSigner, error) {
+	if domain == "" || selector == "" {
+		return nil, fmt.Errorf("domínio e seletor DKIM são obrigatórios")
+	}
+	return &DKIMSigner{
+		domain:    domain,
+		selector:  selector,
+		key:       key.signer,
+		algorithm: key.algorithm,
+	}, nil
+}
+
+// loadDKIMKey lê e valida a chave privada DKIM de um arquivo PEM
+func loadDKIMKey(keyFile string) (*dkimKey, error) {
+	pemKey, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler arquivo da chave DKIM: %w", err)
+	}
+	return parseDKIMKey(pemKey)
+}
+
+// parseDKIMKey interpreta a chave privada PEM (PKCS#1 ou PKCS#8, RSA ou Ed25519)
+func parseDKIMKey(pemKey []byte) (*dkimKey, error) {
+	block, _ := pem.Decode(pemKey)
+	if block == nil {
+		return nil, fmt.Errorf("chave DKIM não está no formato PEM")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("tipo de chave DKIM não suportado: %s", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler chave DKIM: %w", err)
+	}
+
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		return &dkimKey{signer: key, algorithm: "rsa-sha256"}, nil
+	case ed25519.PrivateKey:
+		return &dkimKey{signer: key, algorithm: "ed25519-sha256"}, nil
+	default:
+		return nil, fmt.Errorf("algoritmo de chave DKIM não suportado (use RSA ou Ed25519)")
+	}
+}
+
+// Sign retorna a mensagem com o cabeçalho DKIM-Signature no início
+func (d *DKIMSigner) Sign(message []byte) ([]byte, error) {
+	headerEnd := bytes.Index(message, []byte("\r\n\r\n"))
+	if headerEnd < 0 {
+		return nil, errors.New("mensagem sem separação entre cabeçalhos e corpo")
+	}
+	fields := parseHeaderFields(message[:headerEnd+2])
+	body := message[headerEnd+4:]
+
+	// Hash do corpo canonicalizado
+	bodyHash := sha256.Sum256(canonicalizeBodyRelaxed(body))
+
+	// Cabeçalhos assinados: a última ocorrência de cada um
+	var signedNames []string
+	var signedData bytes.Buffer
+	for _, name := range dkimSignedHeaders {
+		for i := len(fields) - 1; i >= 0; i-- {
+			if strings.EqualFold(fields[i].name, name) {
+				signedNames = append(signedNames, strings.ToLower(name))
+				signedData.WriteString(canonicalizeHeaderRelaxed(fields[i].name, fields[i].value))
+				signedData.WriteString("\r\n")
+				break
+			}
+		}
+	}
+
+	// Cabeçalho DKIM-Signature com b= vazio entra no hash sem CRLF final
+	value := fmt.Sprintf("v=1; a=%s; c=relaxed/relaxed; d=%s; s=%s; t=%d;\r\n\th=%s;\r\n\tbh=%s;\r\n\tb=",
+		d.algorithm, d.domain, d.selector, time.Now().Unix(),
+		strings.Join(signedNames, ":"),
+		base64.StdEncoding.EncodeToString(bodyHash[:]))
+	signedData.WriteString(canonicalizeHeaderRelaxed("DKIM-Signature", value))
+
+	digest := sha256.Sum256(signedData.Bytes())
+
+	var signature []byte
+	var err error
+	if d.algorithm == "ed25519-sha256" {
+		signature, err = d.key.Sign(rand.Reader, digest[:], crypto.Hash(0))
+	} else {
+		signature, err = d.key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao assinar mensagem com DKIM: %w", err)
+	}
+
+	var signed bytes.Buffer
+	signed.Grow(len(message) + 512)
+	signed.WriteString("DKIM-Signature: ")
+	signed.WriteString(value)
+	signed.WriteString(base64.StdEncoding.EncodeToString(signature))
+	signed.WriteString("\r\n")
+	signed.Write(message)
+
+	return signed.Bytes(), nil
+}
+
+// headerField cabeçalho da mensagem (valor ainda dobrado, sem o CRLF final)
+type headerField struct {
+	name  string
+	value string
+}
+
+// parseHeaderFields separa os cabeçalhos, mantendo as linhas de continuação
+func parseHeaderFields(header []byte) []headerField {
+	var fields []headerField
+	for _, line := range strings.Split(strings.TrimSuffix(string(header), "\r\n"), "\r\n") {
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(fields) > 0 {
+			fields[len(fields)-1].value += "\r\n" + line
+			continue
+		}
+		if name, value, ok := strings.Cut(line, ":"); ok {
+			fields = append(fields, headerField{name: name, value: value})
+		}
+	}
+	return fields
+}
+
+// canonicalizeHeaderRelaxed aplica a canonicalização "relaxed" de cabeçalho
+// (RFC 6376 3.4.2), sem o CRLF final
+func canonicalizeHeaderRelaxed(name, value string) string {
+	value = strings.ReplaceAll(value, "\r\n", "")
+	return strings.ToLower(strings.TrimSpace(name)) + ":" + strings.TrimSpace(compressWhitespace(value))
+}
+
+// canonicalizeBodyRelaxed aplica a canonicalização "relaxed" de corpo (RFC 6376 3.4.4)
+func canonicalizeBodyRelaxed(body []byte) []byte {
+	lines := strings.Split(string(body), "\r\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(compressWhitespace(line), " ")
+	}
+
+	// Linhas vazias no final do corpo são ignoradas
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	return []byte(strings.Join(lines, "\r\n") + "\r\n")
+}
+
+// compressWhitespace reduz sequências de espaços e tabs a um único espaço
+func compressWhitespace(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	space := false
+	for i := 0; i < len(s); i++ {
+		if s[i] == ' ' || s[i] == '\t' {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteByte(s[i])
+	}
+	if space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
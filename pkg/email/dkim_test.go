@@ -0,0 +1,233 @@
+package email
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// dkimTestEmail mensagem com espaços repetidos, espaços no fim das linhas e
+// linhas vazias no final para exercitar a canonicalização relaxed
+func dkimTestEmail() EmailData {
+	return EmailData{
+		From:        "noreply@exemplo.com.br",
+		FromName:    "Loja   Exemplo",
+		To:          "cliente@destino.com",
+		Subject:     "Pedido    confirmado",
+		Body:        "Olá,  \r\nseu pedido\t\tfoi confirmado.   \r\n\r\n\r\n",
+		ContentType: "text/plain",
+	}
+}
+
+func TestDKIMSignRSA(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("erro ao gerar chave RSA: %v", err)
+	}
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	signed := signDKIMTestMessage(t, pemKey)
+	verifyDKIMTestMessage(t, signed, "rsa-sha256", func(digest, signature []byte) bool {
+		return rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest, signature) == nil
+	})
+}
+
+func TestDKIMSignEd25519(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("erro ao gerar chave Ed25519: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatalf("erro ao serializar chave Ed25519: %v", err)
+	}
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+
+	signed := signDKIMTestMessage(t, pemKey)
+	verifyDKIMTestMessage(t, signed, "ed25519-sha256", func(digest, signature []byte) bool {
+		// RFC 8463: Ed25519 assina o hash SHA-256 dos cabeçalhos
+		return ed25519.Verify(public, digest, signature)
+	})
+}
+
+func TestDKIMSignTamperedBody(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("erro ao gerar chave RSA: %v", err)
+	}
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	signed := signDKIMTestMessage(t, pemKey)
+	tampered := bytes.Replace(signed, []byte("confirmado"), []byte("cancelado"), -1)
+
+	tags, _, body := parseDKIMTestMessage(t, tampered)
+	if dkimTestBodyHash(body) == tags["bh"] {
+		t.Error("bh= deveria divergir do corpo alterado")
+	}
+}
+
+func TestNewSMTPProviderLoadsDKIMKey(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("erro ao gerar chave Ed25519: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatalf("erro ao serializar chave Ed25519: %v", err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "dkim.pem")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatalf("erro ao gravar chave: %v", err)
+	}
+
+	provider, err := NewSMTPProvider(SMTPConfig{Host: "localhost", DKIMSelector: "mail", DKIMKeyFile: keyFile}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewSMTPProvider: %v", err)
+	}
+	signer, err := provider.dkimSigner(dkimTestEmail())
+	if err != nil || signer == nil {
+		t.Fatalf("dkimSigner = %v, %v; esperado assinador", signer, err)
+	}
+	if signer.domain != "exemplo.com.br" || signer.algorithm != "ed25519-sha256" {
+		t.Errorf("assinador inesperado: domínio %q, algoritmo %q", signer.domain, signer.algorithm)
+	}
+
+	if _, err := NewSMTPProvider(SMTPConfig{Host: "localhost", DKIMSelector: "mail", DKIMKeyFile: keyFile + ".inexistente"}, zap.NewNop()); err == nil {
+		t.Error("NewSMTPProvider com arquivo de chave inexistente deveria falhar")
+	}
+	if _, err := NewSMTPProvider(SMTPConfig{Host: "localhost", DKIMKeyFile: keyFile}, zap.NewNop()); err == nil {
+		t.Error("NewSMTPProvider sem seletor DKIM deveria falhar")
+	}
+}
+
+// signDKIMTestMessage monta a mensagem MIME de teste e a assina com a chave
+func signDKIMTestMessage(t *testing.T, pemKey []byte) []byte {
+	t.Helper()
+
+	signer, err := NewDKIMSigner("exemplo.com.br", "mail", pemKey)
+	if err != nil {
+		t.Fatalf("NewDKIMSigner: %v", err)
+	}
+	msg, err := buildMIMEMessage(dkimTestEmail())
+	if err != nil {
+		t.Fatalf("buildMIMEMessage: %v", err)
+	}
+	signed, err := signer.Sign(msg.Data)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	if !bytes.HasPrefix(signed, []byte("DKIM-Signature: ")) || !bytes.HasSuffix(signed, msg.Data) {
+		t.Fatal("DKIM-Signature deveria ser acrescentado antes da mensagem original")
+	}
+	return signed
+}
+
+// verifyDKIMTestMessage confere bh= e b= de forma independente do assinador
+func verifyDKIMTestMessage(t *testing.T, signed []byte, algorithm string, verify func(digest, signature []byte) bool) {
+	t.Helper()
+
+	tags, headers, body := parseDKIMTestMessage(t, signed)
+	if tags["v"] != "1" || tags["a"] != algorithm || tags["c"] != "relaxed/relaxed" ||
+		tags["d"] != "exemplo.com.br" || tags["s"] != "mail" {
+		t.Fatalf("tags DKIM inesperadas: %v", tags)
+	}
+
+	if got := dkimTestBodyHash(body); got != tags["bh"] {
+		t.Errorf("bh= = %s, corpo canonicalizado = %s", tags["bh"], got)
+	}
+
+	// Cabeçalhos listados em h=, de baixo para cima, seguidos do próprio
+	// DKIM-Signature com b= vazio e sem CRLF final
+	var data strings.Builder
+	used := make(map[int]bool)
+	for _, name := range strings.Split(tags["h"], ":") {
+		for i := len(headers) - 1; i >= 0; i-- {
+			if !used[i] && strings.EqualFold(headers[i][0], name) {
+				used[i] = true
+				data.WriteString(dkimTestRelaxedHeader(headers[i][0], headers[i][1]) + "\r\n")
+				break
+			}
+		}
+	}
+	dkimHeader := regexp.MustCompile(`(^|;)(\s*b\s*=)[^;]*`).ReplaceAllString(headers[0][1], "$1$2")
+	data.WriteString(dkimTestRelaxedHeader(headers[0][0], dkimHeader))
+
+	signature, err := base64.StdEncoding.DecodeString(tags["b"])
+	if err != nil {
+		t.Fatalf("b= não é base64: %v", err)
+	}
+	digest := sha256.Sum256([]byte(data.String()))
+	if !verify(digest[:], signature) {
+		t.Error("b= não confere com a chave pública")
+	}
+}
+
+// parseDKIMTestMessage separa as tags do DKIM-Signature, os cabeçalhos
+// (nome, valor dobrado) e o corpo da mensagem assinada
+func parseDKIMTestMessage(t *testing.T, signed []byte) (map[string]string, [][2]string, []byte) {
+	t.Helper()
+
+	header, body, ok := bytes.Cut(signed, []byte("\r\n\r\n"))
+	if !ok {
+		t.Fatal("mensagem sem separação entre cabeçalhos e corpo")
+	}
+
+	var headers [][2]string
+	for _, line := range strings.Split(string(header), "\r\n") {
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(headers) > 0 {
+			headers[len(headers)-1][1] += "\r\n" + line
+			continue
+		}
+		name, value, _ := strings.Cut(line, ":")
+		headers = append(headers, [2]string{name, value})
+	}
+	if !strings.EqualFold(headers[0][0], "DKIM-Signature") {
+		t.Fatalf("primeiro cabeçalho = %q, esperado DKIM-Signature", headers[0][0])
+	}
+
+	tags := make(map[string]string)
+	unfolded := regexp.MustCompile(`\s+`).ReplaceAllString(headers[0][1], "")
+	for _, tag := range strings.Split(unfolded, ";") {
+		if name, value, ok := strings.Cut(tag, "="); ok {
+			tags[name] = value
+		}
+	}
+	return tags, headers, body
+}
+
+// dkimTestRelaxedHeader canonicalização relaxed de cabeçalho (RFC 6376 3.4.2)
+func dkimTestRelaxedHeader(name, value string) string {
+	value = strings.ReplaceAll(value, "\r\n", "")
+	value = regexp.MustCompile(`[ \t]+`).ReplaceAllString(value, " ")
+	return strings.ToLower(strings.TrimSpace(name)) + ":" + strings.TrimSpace(value)
+}
+
+// dkimTestBodyHash hash base64 do corpo com canonicalização relaxed (RFC 6376 3.4.4)
+func dkimTestBodyHash(body []byte) string {
+	lines := strings.Split(string(body), "\r\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(regexp.MustCompile(`[ \t]+`).ReplaceAllString(line, " "), " ")
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	canonical := ""
+	if len(lines) > 0 {
+		canonical = strings.Join(lines, "\r\n") + "\r\n"
+	}
+	sum := sha256.Sum256([]byte(canonical))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
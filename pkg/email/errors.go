@@ -0,0 +1,119 @@
+package email
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrorKind classificação de uma falha de envio
+type ErrorKind int
+
+const (
+	ErrorTransient        ErrorKind = iota // Falha temporária (rede, timeout, 5xx): retentar
+	ErrorInvalidRecipient                  // Destinatário inválido: não retentar
+	ErrorPermanent                         // Rejeição permanente da mensagem: não retentar
+	ErrorRateLimited                       // Limite de envio do provider atingido: retentar mais tarde
+	ErrorAuth                              // Falha de autenticação/permissão no provider: retentar após correção
+)
+
+// String retorna a descrição da classificação
+func (k ErrorKind) String() string {
+	switch k {
+	case ErrorInvalidRecipient:
+		return "destinatário inválido"
+	case ErrorPermanent:
+		return "rejeição permanente"
+	case ErrorRateLimited:
+		return "limite de envio atingido"
+	case ErrorAuth:
+		return "falha de autenticação"
+	default:
+		return "erro temporário"
+	}
+}
+
+// SendError erro de envio classificado por um provider. Implementa
+// retry.RetryableError para que o processamento decida entre retentar
+// (status 3), falha permanente (status 4) ou e-mail inválido (status 125).
+type SendError struct {
+	Kind     ErrorKind
+	Provider string // Nome do provider que retornou o erro
+	Code     string // Código retornado pelo provider (status HTTP, código da API, etc)
+	Message  string
+	Err      error // Erro original (opcional)
+}
+
+// NewSendError cria um erro de envio classificado
+func NewSendError(kind ErrorKind, provider, code, message string, err error) *SendError {
+	return &SendError{
+		Kind:     kind,
+		Provider: provider,
+		Code:     code,
+		Message:  message,
+		Err:      err,
+	}
+}
+
+// Error retorna a mensagem no formato "Provider [classificação] código: mensagem: erro"
+func (e *SendError) Error() string {
+	msg := fmt.Sprintf("%s [%s]", e.Provider, e.Kind)
+	if e.Code != "" {
+		msg += " " + e.Code
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap retorna o erro original
+func (e *SendError) Unwrap() error {
+	return e.Err
+}
+
+// IsRetryable indica se o envio pode ser tentado novamente (ou por outro provider)
+func (e *SendError) IsRetryable() bool {
+	switch e.Kind {
+	case ErrorInvalidRecipient, ErrorPermanent:
+		return false
+	default:
+		return true
+	}
+}
+
+// ErrorKindOf retorna a classificação do erro. Erros não classificados são
+// considerados temporários.
+func ErrorKindOf(err error) ErrorKind {
+	var sendErr *SendError
+	if errors.As(err, &sendErr) {
+		return sendErr.Kind
+	}
+	return ErrorTransient
+}
+
+// ClassifyHTTPStatus classifica um status HTTP de erro retornado por uma API de envio
+func ClassifyHTTPStatus(status int) ErrorKind {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ErrorAuth
+	case status == http.StatusTooManyRequests:
+		return ErrorRateLimited
+	case status == http.StatusRequestTimeout:
+		return ErrorTransient
+	case status >= 500:
+		return ErrorTransient
+	case status >= 400:
+		return ErrorPermanent
+	default:
+		return ErrorTransient
+	}
+}
+
+// httpStatusError cria um erro classificado a partir do status HTTP de resposta
+func httpStatusError(provider string, status int, message string) *SendError {
+	return NewSendError(ClassifyHTTPStatus(status), provider, fmt.Sprintf("HTTP %d", status), message, nil)
+}
@@ -0,0 +1,570 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/config"
+	"go.uber.org/zap"
+)
+
+const (
+	graphDefaultAuthorityURL = "https://login.microsoftonline.com"
+	graphDefaultAPIURL       = "https://graph.microsoft.com/v1.0"
+	graphScope               = "https://graph.microsoft.com/.default"
+
+	// graphInlineAttachmentLimit anexos maiores são enviados por sessão de upload
+	graphInlineAttachmentLimit = 3 * 1024 * 1024
+	// graphSendMailAttachmentLimit total de anexos no sendMail (requisição de até 4 MB em base64)
+	graphSendMailAttachmentLimit = 2560 * 1024
+	// graphUploadChunkSize tamanho dos blocos da sessão de upload (múltiplo de 320 KiB)
+	graphUploadChunkSize = 10 * 320 * 1024
+	// graphTokenRefreshMargin antecedência para renovar o token antes de expirar
+	graphTokenRefreshMargin = 2 * time.Minute
+)
+
+// GraphConfig configurações do provider Microsoft Graph
+type GraphConfig struct {
+	TenantID        string
+	ClientID        string
+	ClientSecret    string
+	UserID          string // Caixa de envio (id ou UPN). Vazio = endereço do remetente
+	SaveToSentItems bool
+	AuthorityURL    string // URL customizada do login (opcional)
+	APIURL          string // URL customizada da API Graph (opcional)
+}
+
+// GraphProvider implementa Provider para Microsoft Graph (sendMail) com
+// autenticação OAuth2 client credentials
+type GraphProvider struct {
+	config     GraphConfig
+	tokenURL   string
+	apiURL     string
+	logger     *zap.Logger
+	httpClient *http.Client
+
+	tokenMu     sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+// GraphMessage representa uma mensagem da API Graph
+type GraphMessage struct {
+	Subject      string            `json:"subject"`
+	Body         GraphItemBody     `json:"body"`
+	ToRecipients []GraphRecipient  `json:"toRecipients"`
+	ReplyTo      []GraphRecipient  `json:"replyTo,omitempty"`
+	Attachments  []GraphAttachment `json:"attachments,omitempty"`
+}
+
+type GraphItemBody struct {
+	ContentType string `json:"contentType"` // HTML ou Text
+	Content     string `json:"content"`
+}
+
+type GraphRecipient struct {
+	EmailAddress GraphEmailAddress `json:"emailAddress"`
+}
+
+type GraphEmailAddress struct {
+	Address string `json:"address"`
+	Name    string `json:"name,omitempty"`
+}
+
+// GraphAttachment anexo de arquivo enviado junto com a mensagem
+type GraphAttachment struct {
+	ODataType    string `json:"@odata.type"`
+	Name         string `json:"name"`
+	ContentType  string `json:"contentType,omitempty"`
+	ContentBytes string `json:"contentBytes"`
+}
+
+// GraphSendMailRequest corpo de /users/{id}/sendMail
+type GraphSendMailRequest struct {
+	Message         GraphMessage `json:"message"`
+	SaveToSentItems bool         `json:"saveToSentItems"`
+}
+
+// GraphErrorResponse representa uma resposta de erro da API Graph
+type GraphErrorResponse struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// graphTokenResponse resposta do endpoint de token OAuth2
+type graphTokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// graphDraft rascunho criado para envio com anexos grandes
+type graphDraft struct {
+	ID                string `json:"id"`
+	InternetMessageID string `json:"internetMessageId"`
+}
+
+func init() {
+	RegisterProvider(ProviderSpec{
+		Name:        "graph",
+		Code:        65536,
+		DisplayName: "🏢 Microsoft 365",
+		Attachments: AttachmentsInline,
+		Config: []ConfigField{
+			{Key: "graph_tenant_id", Description: "Tenant do Entra ID", Required: true,
+				Value: func(cfg *config.EmailConfig) string { return cfg.GraphTenantID }},
+			{Key: "graph_client_id", Description: "Client ID do aplicativo", Required: true,
+				Value: func(cfg *config.EmailConfig) string { return cfg.GraphClientID }},
+			{Key: "graph_client_secret", Description: "Segredo do aplicativo", Required: true,
+				Value: func(cfg *config.EmailConfig) string { return cfg.GraphClientSecret }},
+			{Key: "graph_user_id", Description: "Caixa de envio (vazio = remetente da mensagem)"},
+			{Key: "graph_save_to_sent_items", Description: "Guardar cópia em Itens Enviados"},
+			{Key: "graph_authority_url", Description: "URL customizada do login (opcional)"},
+			{Key: "graph_api_url", Description: "URL customizada da API (opcional)"},
+		},
+		Factory: func(cfg *config.EmailConfig, logger *zap.Logger) (Provider, error) {
+			return NewGraphProvider(GraphConfig{
+				TenantID:        cfg.GraphTenantID,
+				ClientID:        cfg.GraphClientID,
+				ClientSecret:    cfg.GraphClientSecret,
+				UserID:          cfg.GraphUserID,
+				SaveToSentItems: cfg.GraphSaveToSentItems,
+				AuthorityURL:    cfg.GraphAuthorityURL,
+				APIURL:          cfg.GraphAPIURL,
+			}, logger), nil
+		},
+	})
+}
+
+// NewGraphProvider cria um novo provider Microsoft Graph
+func NewGraphProvider(config GraphConfig, logger *zap.Logger) *GraphProvider {
+	authorityURL := config.AuthorityURL
+	if authorityURL == "" {
+		authorityURL = graphDefaultAuthorityURL
+	}
+	apiURL := config.APIURL
+	if apiURL == "" {
+		apiURL = graphDefaultAPIURL
+	}
+
+	return &GraphProvider{
+		config:   config,
+		tokenURL: fmt.Sprintf("%s/%s/oauth2/v2.0/token", strings.TrimSuffix(authorityURL, "/"), url.PathEscape(config.TenantID)),
+		apiURL:   strings.TrimSuffix(apiURL, "/"),
+		logger:   logger,
+		httpClient: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+	}
+}
+
+// Send envia um email via Microsoft Graph
+func (g *GraphProvider) Send(ctx context.Context, email EmailData) (SendResult, error) {
+	// Caixa de envio: identidade do remetente, configuração ou o próprio remetente
+	mailbox := g.config.UserID
+	if identityMailbox := email.Setting(g.GetName(), "user_id"); identityMailbox != "" {
+		mailbox = identityMailbox
+	}
+	if mailbox == "" {
+		mailbox = email.From
+	}
+
+	g.logger.Info("📧 Enviando email via Microsoft Graph",
+		zap.String("to", email.To),
+		zap.String("mailbox", mailbox),
+		zap.String("subject", email.Subject))
+
+	message := GraphMessage{
+		Subject: email.Subject,
+		Body: GraphItemBody{
+			ContentType: "Text",
+			Content:     email.Body,
+		},
+		ToRecipients: []GraphRecipient{
+			{EmailAddress: GraphEmailAddress{Address: email.To}},
+		},
+	}
+	if strings.EqualFold(email.ContentType, "text/html") {
+		message.Body.ContentType = "HTML"
+	}
+	if email.ReplyTo != "" {
+		message.ReplyTo = []GraphRecipient{{EmailAddress: GraphEmailAddress{Address: email.ReplyTo}}}
+	}
+
+	// Ler anexos
+	var files []graphFile
+	totalSize := 0
+	hasLarge := false
+	for _, attachment := range email.AllAttachments() {
+		if attachment.Data == nil {
+			g.logger.Warn("Anexo sem conteúdo ignorado no envio Graph",
+				zap.String("filename", attachment.Filename),
+				zap.String("url", attachment.URL))
+			continue
+		}
+		data, err := attachment.Bytes()
+		if err != nil {
+			sendErr := NewSendError(ErrorPermanent, g.GetName(), "", "erro ao ler anexo", err)
+			return SendResult{
+				Success: false,
+				Error:   sendErr,
+			}, sendErr
+		}
+
+		files = append(files, graphFile{name: attachment.Filename, contentType: attachment.ContentType, data: data})
+		totalSize += len(data)
+		hasLarge = hasLarge || len(data) > graphInlineAttachmentLimit
+	}
+
+	// Anexos cabem na requisição do sendMail: envio em uma única chamada.
+	// Caso contrário, rascunho com anexos grandes por sessão de upload.
+	var providerID string
+	var err error
+	uploadSessions := 0
+	if !hasLarge && totalSize <= graphSendMailAttachmentLimit {
+		for _, file := range files {
+			message.Attachments = append(message.Attachments, file.graphAttachment())
+		}
+		providerID, err = g.sendMail(ctx, mailbox, message, email.ID)
+	} else {
+		var inline, large []graphFile
+		for _, file := range files {
+			if len(file.data) > graphInlineAttachmentLimit {
+				large = append(large, file)
+			} else {
+				inline = append(inline, file)
+			}
+		}
+		uploadSessions = len(large)
+		providerID, err = g.sendDraft(ctx, mailbox, message, inline, large)
+	}
+
+	if err != nil {
+		g.logger.Error("Erro ao enviar email via Microsoft Graph",
+			zap.Error(err),
+			zap.String("to", email.To))
+		var sendErr *SendError
+		if !errors.As(err, &sendErr) {
+			sendErr = NewSendError(ErrorTransient, g.GetName(), "", "erro Graph", err)
+		}
+		return SendResult{
+			Success: false,
+			Error:   sendErr,
+		}, sendErr
+	}
+
+	g.logger.Info("✅ Email aceito pelo Microsoft Graph",
+		zap.String("message_id", providerID),
+		zap.String("to", email.To),
+		zap.Int("upload_sessions", uploadSessions))
+
+	return SendResult{
+		Success:    true,
+		ProviderID: providerID,
+		Error:      nil,
+	}, nil
+}
+
+// sendMail envia a mensagem com uma única chamada a /sendMail. A API não
+// retorna ID da mensagem, então um ID é gerado.
+func (g *GraphProvider) sendMail(ctx context.Context, mailbox string, message GraphMessage, id int64) (string, error) {
+	req := GraphSendMailRequest{
+		Message:         message,
+		SaveToSentItems: g.config.SaveToSentItems,
+	}
+
+	if err := g.call(ctx, "POST", g.userURL(mailbox, "/sendMail"), req, nil); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("graph-%d-%d", id, time.Now().Unix()), nil
+}
+
+// sendDraft cria um rascunho, anexa os arquivos (sessão de upload para os
+// grandes) e envia o rascunho. Retorna o Internet Message-ID.
+func (g *GraphProvider) sendDraft(ctx context.Context, mailbox string, message GraphMessage, inline, large []graphFile) (string, error) {
+	var draft graphDraft
+	if err := g.call(ctx, "POST", g.userURL(mailbox, "/messages"), message, &draft); err != nil {
+		return "", err
+	}
+	messagePath := "/messages/" + url.PathEscape(draft.ID)
+
+	// Em caso de falha, remover o rascunho para não acumular na caixa
+	sent := false
+	defer func() {
+		if !sent {
+			cleanupCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := g.call(cleanupCtx, "DELETE", g.userURL(mailbox, messagePath), nil, nil); err != nil {
+				g.logger.Warn("Erro ao remover rascunho do Graph", zap.Error(err), zap.String("draft_id", draft.ID))
+			}
+		}
+	}()
+
+	for _, file := range inline {
+		if err := g.call(ctx, "POST", g.userURL(mailbox, messagePath+"/attachments"), file.graphAttachment(), nil); err != nil {
+			return "", err
+		}
+	}
+
+	for _, file := range large {
+		if err := g.uploadAttachment(ctx, mailbox, messagePath, file); err != nil {
+			return "", err
+		}
+	}
+
+	if err := g.call(ctx, "POST", g.userURL(mailbox, messagePath+"/send"), nil, nil); err != nil {
+		return "", err
+	}
+	sent = true
+
+	if draft.InternetMessageID != "" {
+		return strings.Trim(draft.InternetMessageID, "<>"), nil
+	}
+	return draft.ID, nil
+}
+
+// uploadAttachment envia um anexo grande por sessão de upload, em blocos
+func (g *GraphProvider) uploadAttachment(ctx context.Context, mailbox, messagePath string, file graphFile) error {
+	sessionReq := map[string]interface{}{
+		"AttachmentItem": map[string]interface{}{
+			"attachmentType": "file",
+			"name":           file.name,
+			"size":           len(file.data),
+			"contentType":    file.contentTypeOrDefault(),
+		},
+	}
+
+	var session struct {
+		UploadURL string `json:"uploadUrl"`
+	}
+	if err := g.call(ctx, "POST", g.userURL(mailbox, messagePath+"/attachments/createUploadSession"), sessionReq, &session); err != nil {
+		return err
+	}
+
+	g.logger.Debug("Sessão de upload criada no Graph",
+		zap.String("filename", file.name),
+		zap.Int("size_bytes", len(file.data)))
+
+	total := len(file.data)
+	for start := 0; start < total; start += graphUploadChunkSize {
+		end := start + graphUploadChunkSize
+		if end > total {
+			end = total
+		}
+
+		// A URL da sessão já é autenticada: não enviar o token
+		req, err := http.NewRequestWithContext(ctx, "PUT", session.UploadURL, bytes.NewReader(file.data[start:end]))
+		if err != nil {
+			return NewSendError(ErrorTransient, g.GetName(), "", "erro ao criar requisição de upload", err)
+		}
+		req.ContentLength = int64(end - start)
+		req.Header.Set("Content-Type", "application/octet-stream")
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end-1, total))
+
+		resp, err := g.httpClient.Do(req)
+		if err != nil {
+			return NewSendError(ErrorTransient, g.GetName(), "", "erro no upload do anexo", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+			return g.responseError(resp.StatusCode, body)
+		}
+	}
+
+	return nil
+}
+
+// call executa uma chamada autenticada à API Graph. Com 401 o token é
+// renovado e a chamada repetida uma vez.
+func (g *GraphProvider) call(ctx context.Context, method, endpoint string, payload, out interface{}) error {
+	var data []byte
+	if payload != nil {
+		var err error
+		if data, err = json.Marshal(payload); err != nil {
+			return NewSendError(ErrorPermanent, g.GetName(), "", "erro ao serializar requisição", err)
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		token, err := g.accessToken(ctx, attempt > 0)
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(data))
+		if err != nil {
+			return NewSendError(ErrorTransient, g.GetName(), "", "erro ao criar requisição HTTP", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		if payload != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := g.httpClient.Do(req)
+		if err != nil {
+			return NewSendError(ErrorTransient, g.GetName(), "", "erro ao enviar requisição", err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return NewSendError(ErrorTransient, g.GetName(), "", "erro ao ler resposta", err)
+		}
+
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			g.logger.Debug("Token Graph recusado, renovando")
+			continue
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return g.responseError(resp.StatusCode, body)
+		}
+
+		if out != nil && len(body) > 0 {
+			if err := json.Unmarshal(body, out); err != nil {
+				return NewSendError(ErrorTransient, g.GetName(), "", "resposta inválida da API Graph", err)
+			}
+		}
+		return nil
+	}
+}
+
+// accessToken retorna o token em cache ou obtém um novo (client credentials)
+func (g *GraphProvider) accessToken(ctx context.Context, forceRefresh bool) (string, error) {
+	g.tokenMu.Lock()
+	defer g.tokenMu.Unlock()
+
+	if !forceRefresh && g.token != "" && time.Now().Add(graphTokenRefreshMargin).Before(g.tokenExpiry) {
+		return g.token, nil
+	}
+
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {g.config.ClientID},
+		"client_secret": {g.config.ClientSecret},
+		"scope":         {graphScope},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", g.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", NewSendError(ErrorTransient, g.GetName(), "", "erro ao criar requisição de token", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return "", NewSendError(ErrorTransient, g.GetName(), "", "erro ao obter token OAuth2", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", NewSendError(ErrorTransient, g.GetName(), "", "erro ao ler token OAuth2", err)
+	}
+
+	var tokenResp graphTokenResponse
+	_ = json.Unmarshal(body, &tokenResp)
+
+	if resp.StatusCode != http.StatusOK || tokenResp.AccessToken == "" {
+		message := tokenResp.ErrorDescription
+		if message == "" {
+			message = string(body)
+		}
+		sendErr := httpStatusError(g.GetName(), resp.StatusCode, "erro ao obter token OAuth2: "+message)
+		// Credenciais recusadas (invalid_client, unauthorized_client, ...)
+		if resp.StatusCode < 500 {
+			sendErr.Kind = ErrorAuth
+		}
+		return "", sendErr
+	}
+
+	g.token = tokenResp.AccessToken
+	g.tokenExpiry = time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+
+	g.logger.Debug("Token OAuth2 do Graph obtido",
+		zap.Time("expira_em", g.tokenExpiry))
+
+	return g.token, nil
+}
+
+// responseError converte a resposta de erro da API Graph em SendError
+func (g *GraphProvider) responseError(status int, body []byte) *SendError {
+	var errorResp GraphErrorResponse
+	_ = json.Unmarshal(body, &errorResp)
+
+	message := errorResp.Error.Message
+	if message == "" {
+		message = string(body)
+	}
+
+	sendErr := httpStatusError(g.GetName(), status, message)
+	if errorResp.Error.Code != "" {
+		sendErr.Code = fmt.Sprintf("HTTP %d %s", status, errorResp.Error.Code)
+	}
+
+	switch errorResp.Error.Code {
+	case "ErrorInvalidRecipients":
+		sendErr.Kind = ErrorInvalidRecipient
+	case "ApplicationThrottled", "MailboxConcurrency", "ErrorExceededMessageLimit":
+		sendErr.Kind = ErrorRateLimited
+	case "ErrorAccessDenied", "MailboxNotEnabledForRESTAPI", "ErrorInvalidUser", "ResourceNotFound":
+		// Caixa de envio inexistente ou sem permissão: problema de configuração
+		sendErr.Kind = ErrorAuth
+	case "ErrorMessageSizeExceeded":
+		sendErr.Kind = ErrorPermanent
+	}
+
+	return sendErr
+}
+
+// userURL monta a URL de um recurso da caixa de envio
+func (g *GraphProvider) userURL(mailbox, path string) string {
+	return g.apiURL + "/users/" + url.PathEscape(mailbox) + path
+}
+
+// graphFile anexo já lido
+type graphFile struct {
+	name        string
+	contentType string
+	data        []byte
+}
+
+func (f graphFile) contentTypeOrDefault() string {
+	if f.contentType == "" {
+		return "application/octet-stream"
+	}
+	return f.contentType
+}
+
+func (f graphFile) graphAttachment() GraphAttachment {
+	return GraphAttachment{
+		ODataType:    "#microsoft.graph.fileAttachment",
+		Name:         f.name,
+		ContentType:  f.contentTypeOrDefault(),
+		ContentBytes: base64.StdEncoding.EncodeToString(f.data),
+	}
+}
+
+// GetName retorna o nome do provider
+func (g *GraphProvider) GetName() string {
+	return "Graph"
+}
+
+// ValidateEmail valida o formato do email
+func (g *GraphProvider) ValidateEmail(email string) error {
+	return ValidateEmail(email)
+}
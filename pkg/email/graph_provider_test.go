@@ -0,0 +1,288 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// graphStandIn simula o endpoint de token do Entra ID e a API Graph
+type graphStandIn struct {
+	server *httptest.Server
+
+	expiresIn    int    // expires_in dos tokens emitidos
+	rejectToken  string // Token recusado pela API com 401
+	rejectAll    bool   // Todos os tokens recusados pela API com 401
+	failUpload   bool   // PUT da sessão de upload retorna 500
+	mu           sync.Mutex
+	tokens       int      // Tokens emitidos
+	calls        []string // "MÉTODO caminho" das chamadas à API
+	ranges       []string // Content-Range dos blocos enviados
+	uploaded     int      // Bytes recebidos na sessão de upload
+	uploadTokens int      // Blocos enviados com Authorization
+}
+
+func newGraphStandIn(t *testing.T) *graphStandIn {
+	t.Helper()
+	g := &graphStandIn{expiresIn: 3600}
+	g.server = httptest.NewServer(http.HandlerFunc(g.handle))
+	t.Cleanup(g.server.Close)
+	return g
+}
+
+func (g *graphStandIn) provider() *GraphProvider {
+	return NewGraphProvider(GraphConfig{
+		TenantID:     "tenant",
+		ClientID:     "client",
+		ClientSecret: "secret",
+		UserID:       "envios@exemplo.com.br",
+		AuthorityURL: g.server.URL,
+		APIURL:       g.server.URL + "/v1.0",
+	}, zap.NewNop())
+}
+
+func (g *graphStandIn) handle(w http.ResponseWriter, r *http.Request) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if r.URL.Path == "/tenant/oauth2/v2.0/token" {
+		r.ParseForm()
+		if r.Form.Get("grant_type") != "client_credentials" || r.Form.Get("client_secret") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid_client","error_description":"credenciais inválidas"}`))
+			return
+		}
+		g.tokens++
+		fmt.Fprintf(w, `{"access_token":"token-%d","expires_in":%d}`, g.tokens, g.expiresIn)
+		return
+	}
+
+	if strings.HasPrefix(r.URL.Path, "/upload/") {
+		if r.Header.Get("Authorization") != "" {
+			g.uploadTokens++
+		}
+		if g.failUpload {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error":{"code":"generalException","message":"falha no upload"}}`))
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		g.uploaded += len(data)
+		g.ranges = append(g.ranges, r.Header.Get("Content-Range"))
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	call := r.Method + " " + strings.TrimPrefix(r.URL.Path, "/v1.0/users/envios@exemplo.com.br")
+	g.calls = append(g.calls, call)
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" || token == g.rejectToken || g.rejectAll {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":{"code":"InvalidAuthenticationToken","message":"Access token has expired"}}`))
+		return
+	}
+
+	switch call {
+	case "POST /sendMail", "POST /messages/draft-1/send":
+		w.WriteHeader(http.StatusAccepted)
+	case "POST /messages":
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"draft-1","internetMessageId":"<draft-1@exemplo.com.br>"}`))
+	case "POST /messages/draft-1/attachments/createUploadSession":
+		fmt.Fprintf(w, `{"uploadUrl":"%s/upload/draft-1"}`, g.server.URL)
+	case "POST /messages/draft-1/attachments":
+		w.WriteHeader(http.StatusCreated)
+	case "DELETE /messages/draft-1":
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// snapshot retorna os tokens emitidos e as chamadas à API
+func (g *graphStandIn) snapshot() (int, []string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.tokens, append([]string(nil), g.calls...)
+}
+
+func graphTestEmail() EmailData {
+	return EmailData{
+		ID:          7,
+		From:        "envios@exemplo.com.br",
+		To:          "cliente@destino.com",
+		Subject:     "Relatório",
+		Body:        "<p>Segue o relatório</p>",
+		ContentType: "text/html",
+	}
+}
+
+func TestGraphProviderTokenCache(t *testing.T) {
+	standIn := newGraphStandIn(t)
+	provider := standIn.provider()
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := provider.Send(ctx, graphTestEmail()); err != nil {
+			t.Fatalf("Send %d: %v", i+1, err)
+		}
+	}
+	if tokens, _ := standIn.snapshot(); tokens != 1 {
+		t.Errorf("tokens emitidos = %d, esperado 1 (cache)", tokens)
+	}
+
+	// Token que expira dentro da margem de renovação não é reaproveitado
+	standIn.mu.Lock()
+	standIn.expiresIn = int(graphTokenRefreshMargin.Seconds()) - 30
+	standIn.mu.Unlock()
+	provider = standIn.provider()
+
+	for i := 0; i < 2; i++ {
+		if _, err := provider.Send(ctx, graphTestEmail()); err != nil {
+			t.Fatalf("Send com token curto %d: %v", i+1, err)
+		}
+	}
+	if tokens, _ := standIn.snapshot(); tokens != 3 {
+		t.Errorf("tokens emitidos = %d, esperado 3 (renovação dentro da margem)", tokens)
+	}
+}
+
+func TestGraphProviderRetryOnUnauthorized(t *testing.T) {
+	standIn := newGraphStandIn(t)
+	standIn.rejectToken = "token-1"
+	provider := standIn.provider()
+
+	if _, err := provider.Send(context.Background(), graphTestEmail()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	tokens, calls := standIn.snapshot()
+	if tokens != 2 {
+		t.Errorf("tokens emitidos = %d, esperado 2 (renovação após 401)", tokens)
+	}
+	if len(calls) != 2 || calls[0] != "POST /sendMail" || calls[1] != "POST /sendMail" {
+		t.Errorf("chamadas = %v, esperado sendMail repetido uma vez", calls)
+	}
+
+	// 401 também com o token novo: apenas uma repetição, erro de autenticação
+	standIn.mu.Lock()
+	standIn.rejectAll = true
+	standIn.calls = nil
+	standIn.mu.Unlock()
+
+	_, err := provider.Send(context.Background(), graphTestEmail())
+	if ErrorKindOf(err) != ErrorAuth {
+		t.Errorf("erro = %v, esperado falha de autenticação", err)
+	}
+	tokens, calls = standIn.snapshot()
+	if len(calls) != 2 || tokens != 3 {
+		t.Errorf("chamadas = %d, tokens = %d; esperado 2 chamadas e 3 tokens (uma única renovação)", len(calls), tokens)
+	}
+}
+
+func TestGraphProviderUploadSession(t *testing.T) {
+	standIn := newGraphStandIn(t)
+	provider := standIn.provider()
+
+	size := 2*graphUploadChunkSize + 1000
+	message := graphTestEmail()
+	message.Attachment = &Attachment{Filename: "grande.pdf", ContentType: "application/pdf",
+		Data: bytes.NewReader(bytes.Repeat([]byte("x"), size))}
+	message.Attachments = []*Attachment{{Filename: "pequeno.txt", Data: strings.NewReader("conteúdo")}}
+
+	result, err := provider.Send(context.Background(), message)
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if result.ProviderID != "draft-1@exemplo.com.br" {
+		t.Errorf("ProviderID = %q, esperado o Internet Message-ID do rascunho", result.ProviderID)
+	}
+
+	_, calls := standIn.snapshot()
+	want := []string{
+		"POST /messages",
+		"POST /messages/draft-1/attachments",
+		"POST /messages/draft-1/attachments/createUploadSession",
+		"POST /messages/draft-1/send",
+	}
+	if strings.Join(calls, ",") != strings.Join(want, ",") {
+		t.Errorf("chamadas = %v, esperado %v", calls, want)
+	}
+
+	standIn.mu.Lock()
+	defer standIn.mu.Unlock()
+	wantRanges := []string{
+		fmt.Sprintf("bytes 0-%d/%d", graphUploadChunkSize-1, size),
+		fmt.Sprintf("bytes %d-%d/%d", graphUploadChunkSize, 2*graphUploadChunkSize-1, size),
+		fmt.Sprintf("bytes %d-%d/%d", 2*graphUploadChunkSize, size-1, size),
+	}
+	if strings.Join(standIn.ranges, ",") != strings.Join(wantRanges, ",") {
+		t.Errorf("Content-Range = %v, esperado %v", standIn.ranges, wantRanges)
+	}
+	if standIn.uploaded != size {
+		t.Errorf("bytes enviados = %d, esperado %d", standIn.uploaded, size)
+	}
+	if standIn.uploadTokens != 0 {
+		t.Errorf("%d blocos enviados com Authorization; a URL da sessão já é autenticada", standIn.uploadTokens)
+	}
+}
+
+func TestGraphProviderDeletesDraftOnUploadFailure(t *testing.T) {
+	standIn := newGraphStandIn(t)
+	standIn.failUpload = true
+	provider := standIn.provider()
+
+	message := graphTestEmail()
+	message.Attachment = &Attachment{Filename: "grande.pdf",
+		Data: bytes.NewReader(bytes.Repeat([]byte("x"), graphInlineAttachmentLimit+1))}
+
+	_, err := provider.Send(context.Background(), message)
+	if err == nil {
+		t.Fatal("Send deveria falhar quando o upload falha")
+	}
+	if ErrorKindOf(err) != ErrorTransient {
+		t.Errorf("classificação = %v, esperado temporário", ErrorKindOf(err))
+	}
+
+	_, calls := standIn.snapshot()
+	want := []string{
+		"POST /messages",
+		"POST /messages/draft-1/attachments/createUploadSession",
+		"DELETE /messages/draft-1",
+	}
+	if strings.Join(calls, ",") != strings.Join(want, ",") {
+		t.Errorf("chamadas = %v, esperado %v (rascunho removido, sem envio)", calls, want)
+	}
+}
+
+func TestGraphProviderResponseError(t *testing.T) {
+	provider := NewGraphProvider(GraphConfig{TenantID: "tenant"}, zap.NewNop())
+
+	tests := []struct {
+		status int
+		code   string
+		want   ErrorKind
+	}{
+		{http.StatusBadRequest, "ErrorInvalidRecipients", ErrorInvalidRecipient},
+		{http.StatusTooManyRequests, "ApplicationThrottled", ErrorRateLimited},
+		{http.StatusForbidden, "ErrorAccessDenied", ErrorAuth},
+		{http.StatusRequestEntityTooLarge, "ErrorMessageSizeExceeded", ErrorPermanent},
+		{http.StatusServiceUnavailable, "", ErrorTransient},
+	}
+
+	for _, tt := range tests {
+		body, _ := json.Marshal(map[string]interface{}{"error": map[string]string{"code": tt.code, "message": "erro"}})
+		if got := provider.responseError(tt.status, body).Kind; got != tt.want {
+			t.Errorf("%d %s: classificação %v, esperado %v", tt.status, tt.code, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,332 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/config"
+	"go.uber.org/zap"
+)
+
+// HTTPConfig configurações do provider HTTP/JSON genérico
+type HTTPConfig struct {
+	URL           string
+	Method        string // Padrão: POST
+	ContentType   string // Padrão: application/json
+	AuthHeader    string // Cabeçalho de autenticação, ex: Authorization
+	AuthValue     string // Valor do cabeçalho, ex: "Bearer xxx"
+	Headers       map[string]string
+	BodyTemplate  string // Template Go aplicado sobre EmailData
+	SuccessStatus []int  // Status HTTP de sucesso (padrão: 200, 201, 202)
+	SuccessPath   string // Campo JSON que indica sucesso (opcional), ex: "success"
+	SuccessValue  string // Valor esperado em SuccessPath, ex: "true"
+	IDPath        string // Campo JSON com o ID da mensagem, ex: "data.id" ou "messages.0.id"
+	ErrorPath     string // Campo JSON com a mensagem de erro (opcional), ex: "error.message"
+	Timeout       time.Duration
+}
+
+// HTTPProvider implementa Provider para gateways REST simples, com endpoint,
+// autenticação, corpo da requisição e leitura da resposta configuráveis
+type HTTPProvider struct {
+	config     HTTPConfig
+	body       *template.Template
+	success    map[int]bool
+	logger     *zap.Logger
+	httpClient *http.Client
+}
+
+// httpTemplateFuncs funções disponíveis no template do corpo
+var httpTemplateFuncs = template.FuncMap{
+	// json codifica o valor como JSON (strings já saem entre aspas e escapadas)
+	"json": func(value interface{}) (string, error) {
+		data, err := json.Marshal(value)
+		return string(data), err
+	},
+	// base64 codifica um texto em base64
+	"base64": func(value string) string {
+		return base64.StdEncoding.EncodeToString([]byte(value))
+	},
+	// attachmentBase64 retorna o conteúdo do anexo em base64
+	"attachmentBase64": func(attachment *Attachment) (string, error) {
+		if attachment == nil {
+			return "", nil
+		}
+		data, err := attachment.Bytes()
+		if err != nil {
+			return "", err
+		}
+		return base64.StdEncoding.EncodeToString(data), nil
+	},
+}
+
+func init() {
+	RegisterProvider(ProviderSpec{
+		Name:        "http",
+		Code:        131072,
+		DisplayName: "🌐 HTTP",
+		Attachments: AttachmentsInline,
+		Config: []ConfigField{
+			{Key: "http_provider.url", Description: "Endpoint do gateway", Required: true,
+				Value: func(cfg *config.EmailConfig) string { return cfg.HTTP.URL }},
+			{Key: "http_provider.body_template", Description: "Template do corpo (ou body_template_file)", Required: true,
+				Value: func(cfg *config.EmailConfig) string { return cfg.HTTP.BodyTemplate }},
+			{Key: "http_provider.method", Description: "Método HTTP (padrão POST)"},
+			{Key: "http_provider.content_type", Description: "Content-Type (padrão application/json)"},
+			{Key: "http_provider.auth_header", Description: "Cabeçalho de autenticação"},
+			{Key: "http_provider.auth_value", Description: "Valor do cabeçalho (pode ser definido por identidade em http.auth_value)"},
+			{Key: "http_provider.success_status", Description: "Status HTTP de sucesso"},
+			{Key: "http_provider.success_path", Description: "Campo da resposta que confirma o aceite"},
+			{Key: "http_provider.id_path", Description: "Campo da resposta com o ID da mensagem"},
+			{Key: "http_provider.error_path", Description: "Campo da resposta com a mensagem de erro"},
+		},
+		Factory: func(cfg *config.EmailConfig, logger *zap.Logger) (Provider, error) {
+			return NewHTTPProvider(HTTPConfig{
+				URL:           cfg.HTTP.URL,
+				Method:        cfg.HTTP.Method,
+				ContentType:   cfg.HTTP.ContentType,
+				AuthHeader:    cfg.HTTP.AuthHeader,
+				AuthValue:     cfg.HTTP.AuthValue,
+				Headers:       cfg.HTTP.Headers,
+				BodyTemplate:  cfg.HTTP.BodyTemplate,
+				SuccessStatus: cfg.HTTP.SuccessStatus,
+				SuccessPath:   cfg.HTTP.SuccessPath,
+				SuccessValue:  cfg.HTTP.SuccessValue,
+				IDPath:        cfg.HTTP.IDPath,
+				ErrorPath:     cfg.HTTP.ErrorPath,
+				Timeout:       cfg.HTTP.Timeout,
+			}, logger)
+		},
+	})
+}
+
+// NewHTTPProvider cria um novo provider HTTP genérico. O template do corpo é
+// validado na criação.
+func NewHTTPProvider(config HTTPConfig, logger *zap.Logger) (*HTTPProvider, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("URL do provider HTTP não pode ser vazia")
+	}
+	if config.Method == "" {
+		config.Method = http.MethodPost
+	}
+	if config.ContentType == "" {
+		config.ContentType = "application/json"
+	}
+	if len(config.SuccessStatus) == 0 {
+		config.SuccessStatus = []int{http.StatusOK, http.StatusCreated, http.StatusAccepted}
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+
+	body, err := template.New("body").Funcs(httpTemplateFuncs).Option("missingkey=error").Parse(config.BodyTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("erro no template do corpo do provider HTTP: %w", err)
+	}
+
+	success := make(map[int]bool, len(config.SuccessStatus))
+	for _, status := range config.SuccessStatus {
+		success[status] = true
+	}
+
+	return &HTTPProvider{
+		config:  config,
+		body:    body,
+		success: success,
+		logger:  logger,
+		httpClient: &http.Client{
+			Timeout: config.Timeout,
+		},
+	}, nil
+}
+
+// Send envia um email pelo gateway HTTP configurado
+func (h *HTTPProvider) Send(ctx context.Context, email EmailData) (SendResult, error) {
+	h.logger.Info("📧 Enviando email via provider HTTP",
+		zap.String("url", h.config.URL),
+		zap.String("to", email.To),
+		zap.String("subject", email.Subject))
+
+	var payload bytes.Buffer
+	if err := h.body.Execute(&payload, email); err != nil {
+		h.logger.Error("Erro ao aplicar template do provider HTTP", zap.Error(err))
+		sendErr := NewSendError(ErrorPermanent, h.GetName(), "", "erro no template do corpo", err)
+		return SendResult{
+			Success: false,
+			Error:   sendErr,
+		}, sendErr
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, h.config.Method, h.config.URL, &payload)
+	if err != nil {
+		h.logger.Error("Erro ao criar requisição HTTP", zap.Error(err))
+		sendErr := NewSendError(ErrorTransient, h.GetName(), "", "erro ao criar requisição HTTP", err)
+		return SendResult{
+			Success: false,
+			Error:   sendErr,
+		}, sendErr
+	}
+	httpReq.Header.Set("Content-Type", h.config.ContentType)
+	for name, value := range h.config.Headers {
+		httpReq.Header.Set(name, value)
+	}
+	if h.config.AuthHeader != "" {
+		// Credencial da identidade do remetente tem precedência
+		authValue := h.config.AuthValue
+		if identityValue := email.Setting(h.GetName(), "auth_value"); identityValue != "" {
+			authValue = identityValue
+		}
+		httpReq.Header.Set(h.config.AuthHeader, authValue)
+	}
+
+	resp, err := h.httpClient.Do(httpReq)
+	if err != nil {
+		h.logger.Error("Erro ao enviar requisição para provider HTTP", zap.Error(err))
+		sendErr := NewSendError(ErrorTransient, h.GetName(), "", "erro ao enviar requisição", err)
+		return SendResult{
+			Success: false,
+			Error:   sendErr,
+		}, sendErr
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		h.logger.Error("Erro ao ler resposta do provider HTTP",
+			zap.Error(err),
+			zap.Int("status_code", resp.StatusCode))
+		sendErr := NewSendError(ErrorTransient, h.GetName(), "", "erro ao ler resposta", err)
+		return SendResult{
+			Success: false,
+			Error:   sendErr,
+		}, sendErr
+	}
+
+	h.logger.Debug("Resposta do provider HTTP",
+		zap.Int("status_code", resp.StatusCode),
+		zap.String("body", string(body)))
+
+	// Resposta JSON (opcional: alguns gateways respondem sem corpo)
+	var parsed interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&parsed); err != nil {
+		parsed = nil
+	}
+
+	if !h.success[resp.StatusCode] {
+		sendErr := httpStatusError(h.GetName(), resp.StatusCode, h.errorMessage(parsed, body))
+		h.logger.Error("Erro no provider HTTP",
+			zap.Int("status_code", resp.StatusCode),
+			zap.String("error", sendErr.Error()))
+		return SendResult{
+			Success: false,
+			Error:   sendErr,
+		}, sendErr
+	}
+
+	// Gateway que responde 200 com indicador de falha no corpo
+	if h.config.SuccessPath != "" {
+		value, _ := jsonPathValue(parsed, h.config.SuccessPath)
+		if !strings.EqualFold(value, h.config.SuccessValue) {
+			sendErr := NewSendError(ErrorPermanent, h.GetName(), fmt.Sprintf("HTTP %d", resp.StatusCode),
+				h.errorMessage(parsed, body), nil)
+			h.logger.Error("Provider HTTP recusou a mensagem",
+				zap.String("success_path", h.config.SuccessPath),
+				zap.String("valor", value),
+				zap.String("error", sendErr.Error()))
+			return SendResult{
+				Success: false,
+				Error:   sendErr,
+			}, sendErr
+		}
+	}
+
+	messageID := ""
+	if h.config.IDPath != "" {
+		messageID, _ = jsonPathValue(parsed, h.config.IDPath)
+	}
+	if messageID == "" {
+		messageID = fmt.Sprintf("http-%d-%d", email.ID, time.Now().Unix())
+	}
+
+	h.logger.Info("✅ Email aceito pelo provider HTTP",
+		zap.String("message_id", messageID),
+		zap.String("to", email.To))
+
+	return SendResult{
+		Success:    true,
+		ProviderID: messageID,
+		Error:      nil,
+	}, nil
+}
+
+// errorMessage extrai a mensagem de erro da resposta (ErrorPath) ou usa o corpo
+func (h *HTTPProvider) errorMessage(parsed interface{}, body []byte) string {
+	if h.config.ErrorPath != "" {
+		if message, ok := jsonPathValue(parsed, h.config.ErrorPath); ok && message != "" {
+			return message
+		}
+	}
+	message := strings.TrimSpace(string(body))
+	if len(message) > 500 {
+		message = message[:500]
+	}
+	return message
+}
+
+// jsonPathValue lê um valor de um JSON decodificado usando um caminho
+// separado por pontos, com índices numéricos para arrays (ex: "data.items.0.id")
+func jsonPathValue(value interface{}, path string) (string, bool) {
+	for _, key := range strings.Split(path, ".") {
+		switch node := value.(type) {
+		case map[string]interface{}:
+			next, ok := node[key]
+			if !ok {
+				return "", false
+			}
+			value = next
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(node) {
+				return "", false
+			}
+			value = node[index]
+		default:
+			return "", false
+		}
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
+	case json.Number, bool:
+		return fmt.Sprint(v), true
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", false
+		}
+		return string(data), true
+	}
+}
+
+// GetName retorna o nome do provider
+func (h *HTTPProvider) GetName() string {
+	return "HTTP"
+}
+
+// ValidateEmail valida o formato do email
+func (h *HTTPProvider) ValidateEmail(email string) error {
+	return ValidateEmail(email)
+}
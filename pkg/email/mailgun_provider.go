@@ -0,0 +1,306 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/config"
+	"go.uber.org/zap"
+)
+
+// Endpoints da API Mailgun por região
+const (
+	mailgunAPIURLUS = "https://api.mailgun.net"
+	mailgunAPIURLEU = "https://api.eu.mailgun.net"
+)
+
+// MailgunConfig configurações do provider Mailgun
+type MailgunConfig struct {
+	APIKey string
+	Domain string   // Domínio de envio cadastrado no Mailgun
+	Region string   // us (padrão) ou eu
+	Tags   []string // Tags aplicadas a todas as mensagens (o:tag)
+	APIURL string   // URL customizada da API (opcional, sobrescreve a região)
+}
+
+// MailgunProvider implementa Provider para a API de mensagens do Mailgun (v3)
+type MailgunProvider struct {
+	config     MailgunConfig
+	baseURL    string
+	logger     *zap.Logger
+	httpClient *http.Client
+}
+
+// MailgunResponse representa a resposta da API Mailgun
+type MailgunResponse struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+}
+
+func init() {
+	RegisterProvider(ProviderSpec{
+		Name:        "mailgun",
+		Code:        32768,
+		DisplayName: "✉️ Mailgun",
+		Attachments: AttachmentsInline,
+		Config: []ConfigField{
+			{Key: "mailgun_api_key", Description: "API key (pode ser definida por identidade em mailgun.api_key)"},
+			{Key: "mailgun_domain", Description: "Domínio de envio (pode ser definido por identidade em mailgun.domain)"},
+			{Key: "mailgun_region", Description: "us ou eu"},
+			{Key: "mailgun_tags", Description: "Tags aplicadas a todas as mensagens"},
+			{Key: "mailgun_api_url", Description: "URL customizada da API (opcional)"},
+		},
+		Factory: func(cfg *config.EmailConfig, logger *zap.Logger) (Provider, error) {
+			return NewMailgunProvider(MailgunConfig{
+				APIKey: cfg.MailgunAPIKey,
+				Domain: cfg.MailgunDomain,
+				Region: cfg.MailgunRegion,
+				Tags:   cfg.MailgunTags,
+				APIURL: cfg.MailgunAPIURL,
+			}, logger), nil
+		},
+	})
+}
+
+// NewMailgunProvider cria um novo provider Mailgun
+func NewMailgunProvider(config MailgunConfig, logger *zap.Logger) *MailgunProvider {
+	baseURL := config.APIURL
+	if baseURL == "" {
+		baseURL = mailgunAPIURLUS
+		if strings.EqualFold(config.Region, "eu") {
+			baseURL = mailgunAPIURLEU
+		}
+	}
+
+	return &MailgunProvider{
+		config:  config,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		logger:  logger,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Send envia um email via Mailgun
+func (mg *MailgunProvider) Send(ctx context.Context, email EmailData) (SendResult, error) {
+	// API key e domínio da identidade do remetente têm precedência
+	apiKey := mg.config.APIKey
+	if identityKey := email.Setting(mg.GetName(), "api_key"); identityKey != "" {
+		apiKey = identityKey
+	}
+	domain := mg.config.Domain
+	if identityDomain := email.Setting(mg.GetName(), "domain"); identityDomain != "" {
+		domain = identityDomain
+	}
+
+	mg.logger.Info("📧 Enviando email via Mailgun",
+		zap.String("to", email.To),
+		zap.String("from", email.From),
+		zap.String("domain", domain),
+		zap.String("subject", email.Subject))
+
+	body, contentType, err := mg.buildForm(email)
+	if err != nil {
+		mg.logger.Error("Erro ao montar requisição Mailgun", zap.Error(err))
+		sendErr := NewSendError(ErrorPermanent, mg.GetName(), "", "erro ao montar requisição", err)
+		return SendResult{
+			Success: false,
+			Error:   sendErr,
+		}, sendErr
+	}
+
+	url := fmt.Sprintf("%s/v3/%s/messages", mg.baseURL, domain)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, body)
+	if err != nil {
+		mg.logger.Error("Erro ao criar requisição HTTP", zap.Error(err))
+		sendErr := NewSendError(ErrorTransient, mg.GetName(), "", "erro ao criar requisição HTTP", err)
+		return SendResult{
+			Success: false,
+			Error:   sendErr,
+		}, sendErr
+	}
+	httpReq.Header.Set("Content-Type", contentType)
+	httpReq.SetBasicAuth("api", apiKey)
+
+	resp, err := mg.httpClient.Do(httpReq)
+	if err != nil {
+		mg.logger.Error("Erro ao enviar requisição para Mailgun", zap.Error(err))
+		sendErr := NewSendError(ErrorTransient, mg.GetName(), "", "erro ao enviar requisição", err)
+		return SendResult{
+			Success: false,
+			Error:   sendErr,
+		}, sendErr
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		mg.logger.Error("Erro ao ler resposta do Mailgun",
+			zap.Error(err),
+			zap.Int("status_code", resp.StatusCode))
+		sendErr := NewSendError(ErrorTransient, mg.GetName(), "", "erro ao ler resposta", err)
+		return SendResult{
+			Success: false,
+			Error:   sendErr,
+		}, sendErr
+	}
+
+	mg.logger.Debug("Resposta da API Mailgun",
+		zap.Int("status_code", resp.StatusCode),
+		zap.String("body", string(respBody)))
+
+	var mgResp MailgunResponse
+	_ = json.Unmarshal(respBody, &mgResp)
+
+	if resp.StatusCode != http.StatusOK {
+		errorMsg := mgResp.Message
+		if errorMsg == "" {
+			errorMsg = string(respBody)
+		}
+
+		sendErr := httpStatusError(mg.GetName(), resp.StatusCode, errorMsg)
+		switch {
+		case resp.StatusCode == http.StatusBadRequest && isMailgunRecipientError(errorMsg):
+			sendErr.Kind = ErrorInvalidRecipient
+		case resp.StatusCode == http.StatusPaymentRequired || resp.StatusCode == http.StatusNotFound:
+			// Conta sem crédito ou domínio inexistente: problema da conta, usar o próximo provider
+			sendErr.Kind = ErrorAuth
+		}
+
+		mg.logger.Error("Erro na API Mailgun",
+			zap.Int("status_code", resp.StatusCode),
+			zap.String("error_message", errorMsg))
+
+		return SendResult{
+			Success: false,
+			Error:   sendErr,
+		}, sendErr
+	}
+
+	// Mailgun retorna o Message-ID entre < >
+	messageID := strings.Trim(mgResp.ID, "<>")
+	if messageID == "" {
+		messageID = fmt.Sprintf("mailgun-%d-%d", email.ID, time.Now().Unix())
+	}
+
+	mg.logger.Info("✅ Email aceito pelo Mailgun",
+		zap.String("message_id", messageID),
+		zap.String("to", email.To))
+
+	return SendResult{
+		Success:    true,
+		ProviderID: messageID,
+		Error:      nil,
+	}, nil
+}
+
+// buildForm monta o corpo multipart/form-data da API de mensagens
+func (mg *MailgunProvider) buildForm(email EmailData) (*bytes.Buffer, string, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+
+	from := email.From
+	if email.FromName != "" {
+		from = (&mail.Address{Name: email.FromName, Address: email.From}).String()
+	}
+
+	fields := [][2]string{
+		{"from", from},
+		{"to", email.To},
+		{"subject", email.Subject},
+		// Variável customizada: retorna nos webhooks e eventos do Mailgun
+		{"v:mensagem_id", fmt.Sprintf("%d", email.ID)},
+	}
+	if strings.EqualFold(email.ContentType, "text/html") {
+		fields = append(fields, [2]string{"html", email.Body})
+		if email.TextBody != "" {
+			fields = append(fields, [2]string{"text", email.TextBody})
+		}
+	} else {
+		fields = append(fields, [2]string{"text", email.Body})
+	}
+	if email.ReplyTo != "" {
+		fields = append(fields, [2]string{"h:Reply-To", email.ReplyTo})
+	}
+	for _, tag := range mg.config.Tags {
+		fields = append(fields, [2]string{"o:tag", tag})
+	}
+	if tag := email.Setting(mg.GetName(), "tag"); tag != "" {
+		fields = append(fields, [2]string{"o:tag", tag})
+	}
+
+	for _, field := range fields {
+		if err := form.WriteField(field[0], field[1]); err != nil {
+			return nil, "", fmt.Errorf("erro ao escrever campo %s: %w", field[0], err)
+		}
+	}
+
+	for _, attachment := range email.AllAttachments() {
+		if attachment.Data == nil {
+			mg.logger.Warn("Anexo sem conteúdo ignorado no envio Mailgun",
+				zap.String("filename", attachment.Filename),
+				zap.String("url", attachment.URL))
+			continue
+		}
+
+		data, err := attachment.Bytes()
+		if err != nil {
+			return nil, "", fmt.Errorf("erro ao ler anexo %s: %w", attachment.Filename, err)
+		}
+
+		contentType := attachment.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+
+		headers := textproto.MIMEHeader{}
+		headers.Set("Content-Disposition", fmt.Sprintf(`form-data; name="attachment"; filename="%s"`,
+			strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(attachment.Filename)))
+		headers.Set("Content-Type", contentType)
+
+		part, err := form.CreatePart(headers)
+		if err != nil {
+			return nil, "", fmt.Errorf("erro ao criar parte do anexo %s: %w", attachment.Filename, err)
+		}
+		if _, err := part.Write(data); err != nil {
+			return nil, "", fmt.Errorf("erro ao escrever anexo %s: %w", attachment.Filename, err)
+		}
+
+		mg.logger.Debug("Anexo adicionado ao email",
+			zap.String("filename", attachment.Filename),
+			zap.Int("size_bytes", len(data)))
+	}
+
+	if err := form.Close(); err != nil {
+		return nil, "", fmt.Errorf("erro ao finalizar formulário: %w", err)
+	}
+
+	return &body, form.FormDataContentType(), nil
+}
+
+// isMailgunRecipientError verifica se o erro 400 aponta para o destinatário
+// (ex: "'to' parameter is not a valid address", com ou sem aspas)
+func isMailgunRecipientError(message string) bool {
+	message = strings.ToLower(strings.ReplaceAll(message, "'", ""))
+	return strings.Contains(message, "to parameter") || strings.Contains(message, "invalid recipient")
+}
+
+// GetName retorna o nome do provider
+func (mg *MailgunProvider) GetName() string {
+	return "Mailgun"
+}
+
+// ValidateEmail valida o formato do email
+func (mg *MailgunProvider) ValidateEmail(email string) error {
+	return ValidateEmail(email)
+}
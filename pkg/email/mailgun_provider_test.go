@@ -0,0 +1,186 @@
+package email
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// mailgunStandIn simula a API de mensagens do Mailgun: grava a última
+// requisição e responde com status e corpo configurados
+type mailgunStandIn struct {
+	server *httptest.Server
+
+	mu          sync.Mutex
+	status      int    // Status da resposta (0 = 200)
+	response    string // Corpo da resposta
+	path        string
+	apiKey      string
+	fields      map[string][]string
+	attachments map[string]string // nome do arquivo -> conteúdo
+	types       map[string]string // nome do arquivo -> Content-Type
+}
+
+func newMailgunStandIn(t *testing.T) *mailgunStandIn {
+	t.Helper()
+	m := &mailgunStandIn{response: `{"id":"<20250101120000.1@mg.exemplo.com.br>","message":"Queued. Thank you."}`}
+	m.server = httptest.NewServer(http.HandlerFunc(m.handle))
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+func (m *mailgunStandIn) provider(config MailgunConfig) *MailgunProvider {
+	config.APIURL = m.server.URL
+	return NewMailgunProvider(config, zap.NewNop())
+}
+
+func (m *mailgunStandIn) handle(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.path = r.URL.Path
+	if user, password, ok := r.BasicAuth(); ok && user == "api" {
+		m.apiKey = password
+	}
+
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		http.Error(w, `{"message":"formulário inválido"}`, http.StatusBadRequest)
+		return
+	}
+	m.fields = r.MultipartForm.Value
+	m.attachments = map[string]string{}
+	m.types = map[string]string{}
+	for _, header := range r.MultipartForm.File["attachment"] {
+		file, _ := header.Open()
+		data, _ := io.ReadAll(file)
+		file.Close()
+		m.attachments[header.Filename] = string(data)
+		m.types[header.Filename] = header.Header.Get("Content-Type")
+	}
+
+	if m.status != 0 {
+		w.WriteHeader(m.status)
+	}
+	w.Write([]byte(m.response))
+}
+
+func TestMailgunProviderForm(t *testing.T) {
+	standIn := newMailgunStandIn(t)
+	provider := standIn.provider(MailgunConfig{APIKey: "chave", Domain: "mg.exemplo.com.br", Tags: []string{"icrm"}})
+
+	message := EmailData{
+		ID:          42,
+		From:        "noreply@exemplo.com.br",
+		FromName:    "Atendimento",
+		ReplyTo:     "contato@exemplo.com.br",
+		To:          "cliente@destino.com",
+		Subject:     "Seu boleto",
+		Body:        "<p>Olá</p>",
+		TextBody:    "Olá",
+		ContentType: "text/html",
+		Settings:    map[string]string{"mailgun.tag": "financeiro", "mailgun.domain": "mg.financeiro.com.br"},
+		Attachment:  &Attachment{Filename: "boleto.pdf", ContentType: "application/pdf", Data: strings.NewReader("%PDF-1.4")},
+		Attachments: []*Attachment{
+			{Filename: "nota.xml", Data: strings.NewReader("<nota/>")},
+			{Filename: "remoto.pdf", URL: "https://arquivos.exemplo.com/remoto.pdf"}, // sem conteúdo: ignorado
+		},
+	}
+
+	result, err := provider.Send(context.Background(), message)
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if result.ProviderID != "20250101120000.1@mg.exemplo.com.br" {
+		t.Errorf("ProviderID = %q, esperado o id sem < >", result.ProviderID)
+	}
+
+	standIn.mu.Lock()
+	defer standIn.mu.Unlock()
+
+	// Domínio da identidade do remetente tem precedência sobre o global
+	if standIn.path != "/v3/mg.financeiro.com.br/messages" || standIn.apiKey != "chave" {
+		t.Errorf("requisição = %s (chave %q)", standIn.path, standIn.apiKey)
+	}
+
+	fields := []struct {
+		name string
+		want []string
+	}{
+		{"from", []string{`"Atendimento" <noreply@exemplo.com.br>`}},
+		{"to", []string{"cliente@destino.com"}},
+		{"subject", []string{"Seu boleto"}},
+		{"v:mensagem_id", []string{"42"}},
+		{"html", []string{"<p>Olá</p>"}},
+		{"text", []string{"Olá"}},
+		{"h:Reply-To", []string{"contato@exemplo.com.br"}},
+		{"o:tag", []string{"icrm", "financeiro"}},
+	}
+	for _, field := range fields {
+		if got := standIn.fields[field.name]; strings.Join(got, "|") != strings.Join(field.want, "|") {
+			t.Errorf("campo %s = %q, esperado %q", field.name, got, field.want)
+		}
+	}
+
+	if len(standIn.attachments) != 2 ||
+		standIn.attachments["boleto.pdf"] != "%PDF-1.4" || standIn.types["boleto.pdf"] != "application/pdf" ||
+		standIn.attachments["nota.xml"] != "<nota/>" || standIn.types["nota.xml"] != "application/octet-stream" {
+		t.Errorf("anexos = %q (tipos %q)", standIn.attachments, standIn.types)
+	}
+}
+
+func TestMailgunProviderRegion(t *testing.T) {
+	tests := []struct {
+		config MailgunConfig
+		want   string
+	}{
+		{MailgunConfig{}, mailgunAPIURLUS},
+		{MailgunConfig{Region: "us"}, mailgunAPIURLUS},
+		{MailgunConfig{Region: "EU"}, mailgunAPIURLEU},
+		{MailgunConfig{Region: "eu", APIURL: "https://mailgun.proxy.local/"}, "https://mailgun.proxy.local"},
+	}
+
+	for _, tt := range tests {
+		if got := NewMailgunProvider(tt.config, zap.NewNop()).baseURL; got != tt.want {
+			t.Errorf("região %q, URL %q: baseURL = %q, esperado %q", tt.config.Region, tt.config.APIURL, got, tt.want)
+		}
+	}
+}
+
+func TestMailgunProviderErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		response string
+		wantKind ErrorKind
+	}{
+		{"API key inválida", http.StatusUnauthorized, `Forbidden`, ErrorAuth},
+		{"limite de envio", http.StatusTooManyRequests, `{"message":"Too many requests"}`, ErrorRateLimited},
+		{"destinatário inválido", http.StatusBadRequest, `{"message":"'to' parameter is not a valid address. please check documentation"}`, ErrorInvalidRecipient},
+		{"destinatário inválido sem aspas", http.StatusBadRequest, `{"message":"to parameter is not a valid address. please check documentation"}`, ErrorInvalidRecipient},
+		{"requisição inválida", http.StatusBadRequest, `{"message":"from parameter is missing"}`, ErrorPermanent},
+		{"domínio inexistente", http.StatusNotFound, `{"message":"Domain not found: mg.exemplo.com.br"}`, ErrorAuth},
+		{"conta sem crédito", http.StatusPaymentRequired, `{"message":"Payment required"}`, ErrorAuth},
+		{"indisponível", http.StatusServiceUnavailable, `{"message":"Service unavailable"}`, ErrorTransient},
+	}
+
+	standIn := newMailgunStandIn(t)
+	provider := standIn.provider(MailgunConfig{APIKey: "chave", Domain: "mg.exemplo.com.br"})
+	message := EmailData{ID: 7, From: "noreply@exemplo.com.br", To: "cliente@destino.com", Subject: "Teste", Body: "Corpo"}
+
+	for _, tt := range tests {
+		standIn.mu.Lock()
+		standIn.status, standIn.response = tt.status, tt.response
+		standIn.mu.Unlock()
+
+		result, err := provider.Send(context.Background(), message)
+		if result.Success || ErrorKindOf(err) != tt.wantKind {
+			t.Errorf("%s: classificação %v (%v), esperado %v", tt.name, ErrorKindOf(err), err, tt.wantKind)
+		}
+	}
+}
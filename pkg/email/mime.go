@@ -0,0 +1,322 @@
+package email
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// mimeLineLength tamanho máximo das linhas base64 (RFC 2045)
+const mimeLineLength = 76
+
+// mimeMessage mensagem MIME pronta para envio
+type mimeMessage struct {
+	Data      []byte
+	MessageID string // Message-ID sem os sinais < >
+}
+
+// buildMIMEMessage monta a mensagem no formato RFC 5322/MIME:
+//   - cabeçalhos não ASCII codificados conforme RFC 2047
+//   - corpo HTML enviado como multipart/alternative com versão em texto puro
+//   - corpos em quoted-printable e anexos em base64 com linhas de 76 caracteres
+//   - multipart/mixed quando houver anexos (boundary aleatório)
+func buildMIMEMessage(email EmailData) (*mimeMessage, error) {
+	messageID, err := generateMessageID(email.From)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	// Cabeçalhos
+	from := mail.Address{Name: email.FromName, Address: email.From}
+	writeHeader(&buf, "From", from.String())
+	writeHeader(&buf, "To", email.To)
+	if email.ReplyTo != "" {
+		writeHeader(&buf, "Reply-To", email.ReplyTo)
+	}
+	writeHeader(&buf, "Subject", mime.QEncoding.Encode("UTF-8", email.Subject))
+	writeHeader(&buf, "Date", time.Now().Format(time.RFC1123Z))
+	writeHeader(&buf, "Message-ID", "<"+messageID+">")
+	writeHeader(&buf, "MIME-Version", "1.0")
+
+	// Anexos que podem ser embutidos na mensagem (anexos via URL são ignorados)
+	var attachments []*Attachment
+	for _, attachment := range email.AllAttachments() {
+		if attachment.Data != nil {
+			attachments = append(attachments, attachment)
+		}
+	}
+
+	if len(attachments) == 0 {
+		if err := writeBody(&buf, email); err != nil {
+			return nil, err
+		}
+		return &mimeMessage{Data: buf.Bytes(), MessageID: messageID}, nil
+	}
+
+	mixed := multipart.NewWriter(&buf)
+	writeHeader(&buf, "Content-Type", mime.FormatMediaType("multipart/mixed", map[string]string{"boundary": mixed.Boundary()}))
+	buf.WriteString("\r\n")
+
+	// Corpo
+	var body bytes.Buffer
+	if err := writeBody(&body, email); err != nil {
+		return nil, err
+	}
+	headers, content := splitPart(body.Bytes())
+	part, err := mixed.CreatePart(headers)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar parte do corpo: %w", err)
+	}
+	if _, err := part.Write(content); err != nil {
+		return nil, fmt.Errorf("erro ao escrever corpo: %w", err)
+	}
+
+	// Anexos
+	for _, attachment := range attachments {
+		if err := writeAttachment(mixed, attachment); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := mixed.Close(); err != nil {
+		return nil, fmt.Errorf("erro ao finalizar mensagem: %w", err)
+	}
+
+	return &mimeMessage{Data: buf.Bytes(), MessageID: messageID}, nil
+}
+
+// writeBody escreve o corpo (texto simples ou multipart/alternative) com seus cabeçalhos
+func writeBody(buf *bytes.Buffer, email EmailData) error {
+	contentType := email.ContentType
+	if contentType == "" {
+		contentType = "text/plain"
+	}
+
+	if !strings.EqualFold(contentType, "text/html") {
+		return writeTextPart(buf, contentType, email.Body)
+	}
+
+	textBody := email.TextBody
+	if textBody == "" {
+		textBody = htmlToText(email.Body)
+	}
+
+	alternative := multipart.NewWriter(buf)
+	writeHeader(buf, "Content-Type", mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": alternative.Boundary()}))
+	buf.WriteString("\r\n")
+
+	for _, alt := range []struct{ contentType, body string }{
+		{"text/plain", textBody},
+		{"text/html", email.Body},
+	} {
+		var part bytes.Buffer
+		if err := writeTextPart(&part, alt.contentType, alt.body); err != nil {
+			return err
+		}
+		headers, content := splitPart(part.Bytes())
+		w, err := alternative.CreatePart(headers)
+		if err != nil {
+			return fmt.Errorf("erro ao criar parte %s: %w", alt.contentType, err)
+		}
+		if _, err := w.Write(content); err != nil {
+			return fmt.Errorf("erro ao escrever parte %s: %w", alt.contentType, err)
+		}
+	}
+
+	return alternative.Close()
+}
+
+// writeTextPart escreve uma parte de texto em UTF-8 codificada em quoted-printable
+func writeTextPart(buf *bytes.Buffer, contentType, body string) error {
+	writeHeader(buf, "Content-Type", mime.FormatMediaType(contentType, map[string]string{"charset": "UTF-8"}))
+	writeHeader(buf, "Content-Transfer-Encoding", "quoted-printable")
+	buf.WriteString("\r\n")
+
+	qp := quotedprintable.NewWriter(buf)
+	if _, err := qp.Write([]byte(normalizeNewlines(body))); err != nil {
+		return fmt.Errorf("erro ao codificar corpo: %w", err)
+	}
+	if err := qp.Close(); err != nil {
+		return fmt.Errorf("erro ao codificar corpo: %w", err)
+	}
+	buf.WriteString("\r\n")
+
+	return nil
+}
+
+// writeAttachment escreve um anexo em base64 com linhas de 76 caracteres
+func writeAttachment(w *multipart.Writer, attachment *Attachment) error {
+	data, err := attachment.Bytes()
+	if err != nil {
+		return fmt.Errorf("erro ao ler anexo %s: %w", attachment.Filename, err)
+	}
+
+	contentType := attachment.ContentType
+	if contentType == "" || strings.EqualFold(contentType, "url") {
+		contentType = mime.TypeByExtension(fileExtension(attachment.Filename))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	headers := textproto.MIMEHeader{}
+	headers.Set("Content-Type", formatMediaTypeWithName(contentType, "name", attachment.Filename))
+	headers.Set("Content-Transfer-Encoding", "base64")
+	headers.Set("Content-Disposition", formatMediaTypeWithName("attachment", "filename", attachment.Filename))
+
+	part, err := w.CreatePart(headers)
+	if err != nil {
+		return fmt.Errorf("erro ao criar parte do anexo %s: %w", attachment.Filename, err)
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 0 {
+		n := mimeLineLength
+		if len(encoded) < n {
+			n = len(encoded)
+		}
+		if _, err := part.Write([]byte(encoded[:n] + "\r\n")); err != nil {
+			return fmt.Errorf("erro ao escrever anexo %s: %w", attachment.Filename, err)
+		}
+		encoded = encoded[n:]
+	}
+
+	return nil
+}
+
+// formatMediaTypeWithName formata um cabeçalho com o nome do arquivo,
+// usando a codificação da RFC 2231 quando o nome não é ASCII
+func formatMediaTypeWithName(mediaType, param, filename string) string {
+	if filename == "" {
+		return mediaType
+	}
+	if formatted := mime.FormatMediaType(mediaType, map[string]string{param: filename}); formatted != "" {
+		return formatted
+	}
+	return mediaType
+}
+
+// splitPart separa os cabeçalhos do conteúdo de uma parte já escrita
+func splitPart(part []byte) (textproto.MIMEHeader, []byte) {
+	headers := textproto.MIMEHeader{}
+
+	idx := bytes.Index(part, []byte("\r\n\r\n"))
+	if idx < 0 {
+		return headers, part
+	}
+
+	name := ""
+	for _, line := range strings.Split(string(part[:idx]), "\r\n") {
+		// Linha de continuação de um cabeçalho dobrado (writeHeader)
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && name != "" {
+			values := headers[name]
+			values[len(values)-1] += " " + strings.TrimSpace(line)
+			continue
+		}
+		if key, value, ok := strings.Cut(line, ":"); ok {
+			name = textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(key))
+			headers.Add(name, strings.TrimSpace(value))
+		}
+	}
+
+	return headers, part[idx+4:]
+}
+
+// headerLineLength tamanho recomendado das linhas de cabeçalho (RFC 5322 2.1.1)
+const headerLineLength = 78
+
+// writeHeader escreve um cabeçalho "Nome: valor", dobrando o valor nos espaços
+// (CRLF + espaço) quando a linha passa de 78 caracteres. Assunto e nomes
+// codificados (RFC 2047) são divididos em encoded-words de até 75 caracteres
+// separados por espaço, então cada um fica em sua própria linha e nenhuma
+// chega ao limite de 998 caracteres.
+func writeHeader(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name)
+	buf.WriteString(":")
+
+	lineLength := len(name) + 1
+	for i, word := range strings.Split(value, " ") {
+		if i > 0 && word != "" && lineLength+1+len(word) > headerLineLength {
+			buf.WriteString("\r\n")
+			lineLength = 0
+		}
+		buf.WriteString(" ")
+		buf.WriteString(word)
+		lineLength += 1 + len(word)
+	}
+	buf.WriteString("\r\n")
+}
+
+// generateMessageID gera um Message-ID único no domínio do remetente
+func generateMessageID(from string) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("erro ao gerar Message-ID: %w", err)
+	}
+
+	domain := ""
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = strings.TrimSuffix(from[at+1:], ">")
+	}
+	if domain == "" {
+		domain, _ = os.Hostname()
+	}
+	if domain == "" {
+		domain = "localhost"
+	}
+
+	return fmt.Sprintf("%d.%s@%s", time.Now().UnixNano(), hex.EncodeToString(random), domain), nil
+}
+
+var (
+	htmlBreakRegex   = regexp.MustCompile(`(?i)<\s*(br|/p|/div|/tr|/h[1-6]|/li)\s*/?>`)
+	htmlHiddenRegex  = regexp.MustCompile(`(?is)<(style|script|head)[^>]*>.*?</(style|script|head)>`)
+	htmlTagRegex     = regexp.MustCompile(`(?s)<[^>]*>`)
+	blankLinesRegex  = regexp.MustCompile(`\n{3,}`)
+	inlineSpaceRegex = regexp.MustCompile(`[ \t]+`)
+)
+
+// htmlToText gera uma versão em texto puro de um corpo HTML
+func htmlToText(body string) string {
+	text := htmlHiddenRegex.ReplaceAllString(body, "")
+	text = htmlBreakRegex.ReplaceAllString(text, "\n")
+	text = htmlTagRegex.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+	text = inlineSpaceRegex.ReplaceAllString(text, " ")
+
+	lines := strings.Split(normalizeNewlines(text), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	text = strings.Join(lines, "\n")
+
+	return strings.TrimSpace(blankLinesRegex.ReplaceAllString(text, "\n\n"))
+}
+
+// normalizeNewlines converte quebras de linha CRLF/CR em LF
+func normalizeNewlines(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.ReplaceAll(text, "\r", "\n")
+}
+
+// fileExtension retorna a extensão do arquivo (com ponto) ou ""
+func fileExtension(filename string) string {
+	if idx := strings.LastIndex(filename, "."); idx >= 0 {
+		return filename[idx:]
+	}
+	return ""
+}
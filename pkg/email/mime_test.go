@@ -0,0 +1,89 @@
+package email
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func TestBuildMIMEMessageFoldsLongSubject(t *testing.T) {
+	subject := strings.Repeat("Promoção de verão: descontos exclusivos na sua próxima compra ", 20)
+	message := smtpTestEmail()
+	message.FromName = "Atendimento Ação Cliente"
+	message.Subject = subject
+	message.ContentType = "text/html"
+	message.Body = "<p>Olá</p>"
+	message.Attachment = &Attachment{Filename: "relatório.pdf", ContentType: "application/pdf", Data: strings.NewReader("%PDF-1.4")}
+
+	msg, err := buildMIMEMessage(message)
+	if err != nil {
+		t.Fatalf("buildMIMEMessage: %v", err)
+	}
+
+	header, _, _ := bytes.Cut(msg.Data, []byte("\r\n\r\n"))
+	subjectLines := 0
+	inSubject := false
+	for _, line := range strings.Split(string(header), "\r\n") {
+		if len(line) > 998 {
+			t.Errorf("linha de cabeçalho com %d caracteres, limite 998", len(line))
+		}
+		switch {
+		case strings.HasPrefix(line, "Subject:"):
+			inSubject = true
+		case !strings.HasPrefix(line, " "):
+			inSubject = false
+		}
+		if inSubject {
+			subjectLines++
+			// Cada linha de continuação traz um encoded-word inteiro
+			if len(line) > headerLineLength+len("Subject:") {
+				t.Errorf("linha do assunto com %d caracteres: %q", len(line), line)
+			}
+		}
+	}
+	if subjectLines < 2 {
+		t.Errorf("assunto em %d linha, esperado dobrado em várias", subjectLines)
+	}
+
+	// O assunto dobrado volta ao texto original
+	parsed, err := mail.ReadMessage(bytes.NewReader(msg.Data))
+	if err != nil {
+		t.Fatalf("mensagem inválida: %v", err)
+	}
+	decoded, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil {
+		t.Fatalf("erro ao decodificar assunto: %v", err)
+	}
+	if decoded != subject {
+		t.Errorf("assunto decodificado = %q, esperado %q", decoded, subject)
+	}
+	from, err := parsed.Header.AddressList("From")
+	if err != nil || from[0].Name != message.FromName {
+		t.Errorf("From = %v (%v), esperado nome %q", from, err, message.FromName)
+	}
+
+	// Boundary dobrado continua válido: corpo e anexo separados
+	_, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("Content-Type inválido: %v", err)
+	}
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	var parts []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("erro ao ler parte %d: %v", len(parts)+1, err)
+		}
+		parts = append(parts, part.Header.Get("Content-Type"))
+	}
+	if len(parts) != 2 || !strings.HasPrefix(parts[0], "multipart/alternative; boundary=") {
+		t.Errorf("partes = %q, esperado corpo multipart/alternative e anexo", parts)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/config"
 	"go.uber.org/zap"
 )
 
@@ -14,6 +15,18 @@ type MockProvider struct {
 	delay  time.Duration
 }
 
+func init() {
+	RegisterProvider(ProviderSpec{
+		Name:        "mock",
+		Code:        0,
+		DisplayName: "🧪 Mock (Teste)",
+		Attachments: AttachmentsNone,
+		Factory: func(cfg *config.EmailConfig, logger *zap.Logger) (Provider, error) {
+			return NewMockProvider(logger), nil
+		},
+	})
+}
+
 // NewMockProvider cria um novo provider mock
 func NewMockProvider(logger *zap.Logger) *MockProvider {
 	return &MockProvider{
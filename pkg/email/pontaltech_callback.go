@@ -0,0 +1,150 @@
+package email
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pontaltechDeliveryStatus mapeia os status do callback Pontaltech (normalizados
+// com normalizeDeliveryStatus) para o tipo de evento
+var pontaltechDeliveryStatus = map[string]DeliveryEventType{
+	"delivered": DeliveryDelivered, "delivery": DeliveryDelivered, "entregue": DeliveryDelivered,
+	"opened": DeliveryOpened, "open": DeliveryOpened, "aberto": DeliveryOpened, "read": DeliveryOpened, "lido": DeliveryOpened,
+	"clicked": DeliveryClicked, "click": DeliveryClicked, "clicado": DeliveryClicked,
+	"bounce": DeliveryBounced, "bounced": DeliveryBounced, "hardbounce": DeliveryBounced,
+	"invalid": DeliveryBounced, "invalido": DeliveryBounced, "inexistente": DeliveryBounced,
+	"softbounce": DeliveryDeferred, "deferred": DeliveryDeferred, "adiado": DeliveryDeferred,
+	"dropped": DeliveryDropped, "rejected": DeliveryDropped, "rejeitado": DeliveryDropped,
+	"blocked": DeliveryDropped, "bloqueado": DeliveryDropped, "failed": DeliveryDropped,
+	"falha": DeliveryDropped, "error": DeliveryDropped, "erro": DeliveryDropped,
+	"spam": DeliverySpamReport, "spamreport": DeliverySpamReport, "complaint": DeliverySpamReport,
+	"unsubscribe": DeliveryUnsubscribed, "unsubscribed": DeliveryUnsubscribed, "descadastro": DeliveryUnsubscribed,
+}
+
+// Campos aceitos no callback (comparados em minúsculas, na ordem de preferência)
+var (
+	pontaltechCallbackIDFields        = []string{"id", "messageid", "message_id", "idmensagem"}
+	pontaltechCallbackStatusFields    = []string{"status", "event", "evento"}
+	pontaltechCallbackRecipientFields = []string{"email", "to", "destination", "destinatario"}
+	pontaltechCallbackDetailFields    = []string{"statusdescription", "description", "reason", "detail", "motivo", "message"}
+	pontaltechCallbackDateFields      = []string{"date", "timestamp", "eventdate", "datetime", "data"}
+	pontaltechCallbackListFields      = []string{"messages", "events", "data"}
+)
+
+// ParsePontaltechCallback interpreta o corpo do callback de status enviado para
+// a URLCallback. Aceita um evento, uma lista de eventos ou um objeto com a lista
+// em "messages"/"events"/"data". Eventos com status desconhecido são retornados
+// com Type vazio.
+func ParsePontaltechCallback(body []byte) ([]DeliveryEvent, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var payload interface{}
+	if err := decoder.Decode(&payload); err != nil {
+		return nil, fmt.Errorf("callback Pontaltech inválido: %w", err)
+	}
+
+	var events []DeliveryEvent
+	for _, item := range pontaltechCallbackItems(payload) {
+		fields := make(map[string]interface{}, len(item))
+		for key, value := range item {
+			fields[strings.ToLower(key)] = value
+		}
+
+		id := callbackField(fields, pontaltechCallbackIDFields)
+		if id == "" {
+			continue
+		}
+		status := callbackField(fields, pontaltechCallbackStatusFields)
+		events = append(events, DeliveryEvent{
+			Provider:   "pontaltech",
+			ProviderID: id,
+			Recipient:  callbackField(fields, pontaltechCallbackRecipientFields),
+			Type:       pontaltechDeliveryStatus[normalizeDeliveryStatus(status)],
+			Status:     status,
+			Detail:     callbackField(fields, pontaltechCallbackDetailFields),
+			Timestamp:  parseCallbackTime(callbackField(fields, pontaltechCallbackDateFields)),
+		})
+	}
+
+	if len(events) == 0 {
+		return nil, errors.New("callback Pontaltech sem eventos com ID da mensagem")
+	}
+	return events, nil
+}
+
+// pontaltechCallbackItems extrai os objetos de evento do payload
+func pontaltechCallbackItems(payload interface{}) []map[string]interface{} {
+	switch value := payload.(type) {
+	case []interface{}:
+		var items []map[string]interface{}
+		for _, element := range value {
+			if item, ok := element.(map[string]interface{}); ok {
+				items = append(items, item)
+			}
+		}
+		return items
+	case map[string]interface{}:
+		for key, nested := range value {
+			for _, listField := range pontaltechCallbackListFields {
+				if strings.EqualFold(key, listField) {
+					if list, ok := nested.([]interface{}); ok {
+						return pontaltechCallbackItems(list)
+					}
+				}
+			}
+		}
+		return []map[string]interface{}{value}
+	}
+	return nil
+}
+
+// callbackField retorna o primeiro campo preenchido entre os nomes informados
+func callbackField(fields map[string]interface{}, names []string) string {
+	for _, name := range names {
+		switch value := fields[name].(type) {
+		case string:
+			if value = strings.TrimSpace(value); value != "" {
+				return value
+			}
+		case json.Number:
+			return value.String()
+		case bool:
+			return strconv.FormatBool(value)
+		}
+	}
+	return ""
+}
+
+// Formatos de data aceitos nos callbacks (sem fuso = horário local)
+var callbackTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"02/01/2006 15:04:05",
+}
+
+// parseCallbackTime interpreta a data do evento (texto ou epoch em segundos ou
+// milissegundos). Formato desconhecido retorna zero (data de recebimento).
+func parseCallbackTime(value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	if epoch, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if epoch > 1e12 {
+			return time.UnixMilli(epoch)
+		}
+		return time.Unix(epoch, 0)
+	}
+	for _, layout := range callbackTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,106 @@
+package email
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePontaltechCallbackShapes(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		ids  []string
+	}{
+		{
+			name: "evento único",
+			body: `{"id": 101, "status": "DELIVERED", "email": "ana@destino.com"}`,
+			ids:  []string{"101"},
+		},
+		{
+			name: "lista de eventos",
+			body: `[{"id": "101", "status": "delivered"}, {"messageId": "102", "status": "opened"}]`,
+			ids:  []string{"101", "102"},
+		},
+		{
+			name: "lista dentro de objeto",
+			body: `{"campaignId": 9, "Messages": [{"ID": 101, "Status": "bounce"}, {"status": "sem id"}, {"id": 103, "status": "clicked"}]}`,
+			ids:  []string{"101", "103"},
+		},
+	}
+
+	for _, tt := range tests {
+		events, err := ParsePontaltechCallback([]byte(tt.body))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if len(events) != len(tt.ids) {
+			t.Errorf("%s: %d eventos, esperado %d", tt.name, len(events), len(tt.ids))
+			continue
+		}
+		for i, event := range events {
+			if event.ProviderID != tt.ids[i] || event.Provider != "pontaltech" {
+				t.Errorf("%s: evento %d = %s/%s, esperado pontaltech/%s", tt.name, i, event.Provider, event.ProviderID, tt.ids[i])
+			}
+		}
+	}
+
+	for _, body := range []string{`{"status": "delivered"}`, `[]`, `não é json`} {
+		if _, err := ParsePontaltechCallback([]byte(body)); err == nil {
+			t.Errorf("%s: esperado erro (sem eventos com ID)", body)
+		}
+	}
+}
+
+func TestParsePontaltechCallbackStatus(t *testing.T) {
+	tests := []struct {
+		status string
+		want   DeliveryEventType
+	}{
+		{"DELIVERED", DeliveryDelivered},
+		{"Entregue", DeliveryDelivered},
+		{"hard_bounce", DeliveryBounced},
+		{"Hard-Bounce", DeliveryBounced},
+		{"soft bounce", DeliveryDeferred},
+		{"spam.report", DeliverySpamReport},
+		{"Bloqueado", DeliveryDropped},
+		{"status novo", ""},
+	}
+
+	for _, tt := range tests {
+		events, err := ParsePontaltechCallback([]byte(`{"id": 1, "status": "` + tt.status + `"}`))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.status, err)
+		}
+		if events[0].Type != tt.want {
+			t.Errorf("status %q: tipo %q, esperado %q", tt.status, events[0].Type, tt.want)
+		}
+		if events[0].Status != tt.status {
+			t.Errorf("status %q: Status original = %q", tt.status, events[0].Status)
+		}
+	}
+}
+
+func TestParsePontaltechCallbackTimestamp(t *testing.T) {
+	tests := []struct {
+		date string
+		want time.Time
+	}{
+		{`1735732800`, time.Unix(1735732800, 0)},
+		{`1735732800123`, time.UnixMilli(1735732800123)},
+		{`"2025-01-01T12:00:00Z"`, time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)},
+		{`"2025-01-01 09:30:00"`, time.Date(2025, 1, 1, 9, 30, 0, 0, time.Local)},
+		{`"01/02/2025 09:30:00"`, time.Date(2025, 2, 1, 9, 30, 0, 0, time.Local)},
+		{`"ontem"`, time.Time{}},
+	}
+
+	for _, tt := range tests {
+		events, err := ParsePontaltechCallback([]byte(`{"id": 1, "status": "delivered", "date": ` + tt.date + `}`))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.date, err)
+		}
+		if !events[0].Timestamp.Equal(tt.want) {
+			t.Errorf("data %s: %v, esperado %v", tt.date, events[0].Timestamp, tt.want)
+		}
+	}
+}
@@ -8,13 +8,18 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/config"
 	"go.uber.org/zap"
 )
 
-const pontaltechEmailAPIURL = "https://pointer-email-api.pontaltech.com.br/send"
+const (
+	pontaltechEmailAPIURL     = "https://pointer-email-api.pontaltech.com.br/send"
+	pontaltechMaxBatchSize    = 100 // Destinatários por requisição no envio em lote
+)
 
 // PontaltechProvider implementa Provider para Pontaltech Email API
 type PontaltechProvider struct {
@@ -33,16 +38,15 @@ type PontaltechAttachment struct {
 	Data     string `json:"data"` // Base64 encoded
 }
 
-// PontaltechMessageVariable representa variáveis da mensagem
-type PontaltechMessageVariable struct {
-	Nome string `json:"nome"`
-}
+// PontaltechMessageVariable representa variáveis da mensagem (nome → valor),
+// substituídas no corpo ({{nome}}) quando replaceVariable está ativo
+type PontaltechMessageVariable map[string]string
 
 // PontaltechRecipient representa um destinatário
 type PontaltechRecipient struct {
-	Email           string                     `json:"email"`
-	MessageVariable *PontaltechMessageVariable `json:"messageVariable,omitempty"`
-	Attachments     []PontaltechAttachment     `json:"attachments,omitempty"`
+	Email           string                    `json:"email"`
+	MessageVariable PontaltechMessageVariable `json:"messageVariable,omitempty"`
+	Attachments     []PontaltechAttachment    `json:"attachments,omitempty"`
 }
 
 // PontaltechEmailRequest representa a requisição para a API Pontaltech
@@ -80,6 +84,35 @@ type PontaltechErrorResponse struct {
 	Message string `json:"message"`
 }
 
+func init() {
+	RegisterProvider(ProviderSpec{
+		Name:        "pontaltech",
+		Code:        8192,
+		DisplayName: "📡 Pontaltech",
+		Attachments: AttachmentsInline,
+		Config: []ConfigField{
+			{Key: "pontaltech_username", Description: "Usuário da API", Required: true,
+				Value: func(cfg *config.EmailConfig) string { return cfg.PontaltechUsername }},
+			{Key: "pontaltech_password", Description: "Senha da API", Required: true,
+				Value: func(cfg *config.EmailConfig) string { return cfg.PontaltechPassword }},
+			{Key: "pontaltech_account_id", Description: "Conta de envio (pode ser definida por identidade)"},
+			{Key: "pontaltech_api_url", Description: "URL customizada da API (opcional)"},
+			{Key: "pontaltech_callback_url", Description: "URL de callback de status (opcional)"},
+			{Key: "pontaltech_callback_token", Description: "Token do endpoint de callback no dashboard (opcional)"},
+		},
+		Factory: func(cfg *config.EmailConfig, logger *zap.Logger) (Provider, error) {
+			return NewPontaltechProvider(
+				cfg.PontaltechUsername,
+				cfg.PontaltechPassword,
+				cfg.PontaltechAccountID,
+				cfg.PontaltechAPIURL,
+				cfg.PontaltechCallbackURL,
+				logger,
+			), nil
+		},
+	})
+}
+
 // NewPontaltechProvider cria um novo provider Pontaltech
 func NewPontaltechProvider(username, password string, accountID int, apiURL, callbackURL string, logger *zap.Logger) *PontaltechProvider {
 	// Se URL customizada não foi fornecida, usa a padrão
@@ -118,80 +151,162 @@ func (p *PontaltechProvider) Send(ctx context.Context, email EmailData) (SendRes
 		zap.Bool("has_attachment", email.Attachment != nil))
 
 	// Preparar destinatário
+	recipient, hasAttachment, err := p.buildRecipient(email)
+	if err != nil {
+		return SendResult{
+			Success: false,
+			Error:   err,
+		}, err
+	}
+
+	req := p.buildRequest(email, []PontaltechRecipient{recipient}, hasAttachment)
+
+	pontaltechResp, err := p.post(ctx, req)
+	if err != nil {
+		return SendResult{
+			Success: false,
+			Error:   err,
+		}, err
+	}
+	if pontaltechResp == nil {
+		// Status HTTP de sucesso sem JSON reconhecível: aceitar mesmo assim
+		return SendResult{
+			Success:    true,
+			ProviderID: fmt.Sprintf("pontaltech-%d-%d", email.ID, time.Now().Unix()),
+			Error:      nil,
+		}, nil
+	}
+
+	// Verificar se há mensagens inválidas
+	if len(pontaltechResp.InvalidMessages) > 0 {
+		p.logger.Error("❌ Email rejeitado pela API Pontaltech - endereço inválido",
+			zap.Strings("invalid_messages", pontaltechResp.InvalidMessages),
+			zap.String("to", email.To))
+
+		sendErr := NewSendError(ErrorInvalidRecipient, p.GetName(), "", fmt.Sprintf("%v", pontaltechResp.InvalidMessages), nil)
+		return SendResult{
+			Success: false,
+			Error:   sendErr,
+		}, sendErr
+	}
+
+	// Verificar se há mensagens enviadas
+	if len(pontaltechResp.Messages) == 0 {
+		p.logger.Error("❌ API Pontaltech não retornou nenhuma mensagem enviada",
+			zap.String("to", email.To),
+			zap.Int64("campaign_id", pontaltechResp.CampaignID))
+
+		sendErr := NewSendError(ErrorTransient, p.GetName(), "", "API não retornou mensagens enviadas", nil)
+		return SendResult{
+			Success: false,
+			Error:   sendErr,
+		}, sendErr
+	}
+
+	// Extrair ID da primeira mensagem
+	messageID := pontaltechResp.Messages[0].ID
+	providerID := fmt.Sprintf("%d", messageID)
+
+	p.logger.Info("✅ Email enviado via Pontaltech com sucesso",
+		zap.String("message_id", providerID),
+		zap.Int64("campaign_id", pontaltechResp.CampaignID),
+		zap.String("to", email.To),
+		zap.Bool("has_attachment", hasAttachment))
+
+	return SendResult{
+		Success:    true,
+		ProviderID: providerID,
+		Error:      nil,
+	}, nil
+}
+
+// buildRecipient monta o destinatário com o anexo do email, se houver
+func (p *PontaltechProvider) buildRecipient(email EmailData) (PontaltechRecipient, bool, error) {
 	recipient := PontaltechRecipient{
 		Email: email.To,
 	}
 
-	// Adicionar anexo se houver
-	hasAttachment := false
-	if email.Attachment != nil && email.Attachment.Data != nil {
-		// Ler dados do anexo
-		attachmentData, err := io.ReadAll(email.Attachment.Data)
-		if err != nil {
-			p.logger.Error("Erro ao ler dados do anexo", zap.Error(err))
-			return SendResult{
-				Success: false,
-				Error:   fmt.Errorf("erro ao ler anexo: %w", err),
-			}, err
-		}
+	if email.Attachment == nil || email.Attachment.Data == nil {
+		return recipient, false, nil
+	}
 
-		if len(attachmentData) > 0 {
-			// Codificar anexo em base64
-			base64Data := base64.StdEncoding.EncodeToString(attachmentData)
+	// Ler dados do anexo (pode ser lido novamente no reenvio individual)
+	attachmentData, err := email.Attachment.Bytes()
+	if err != nil {
+		p.logger.Error("Erro ao ler dados do anexo", zap.Error(err))
+		return recipient, false, NewSendError(ErrorPermanent, p.GetName(), "", "erro ao ler anexo", err)
+	}
+	if len(attachmentData) == 0 {
+		return recipient, false, nil
+	}
 
-			recipient.Attachments = []PontaltechAttachment{
-				{
-					Filename: email.Attachment.Filename,
-					Data:     base64Data,
-				},
-			}
+	// Codificar anexo em base64
+	recipient.Attachments = []PontaltechAttachment{
+		{
+			Filename: email.Attachment.Filename,
+			Data:     base64.StdEncoding.EncodeToString(attachmentData),
+		},
+	}
 
-			// Adicionar variável de mensagem (exemplo do WinDev)
-			recipient.MessageVariable = &PontaltechMessageVariable{
-				Nome: "nometeste",
-			}
+	// Adicionar variável de mensagem (exemplo do WinDev)
+	recipient.MessageVariable = PontaltechMessageVariable{
+		"nome": "nometeste",
+	}
 
-			hasAttachment = true
+	p.logger.Debug("Anexo adicionado ao email",
+		zap.String("filename", email.Attachment.Filename),
+		zap.String("content_type", email.Attachment.ContentType),
+		zap.Int("size_bytes", len(attachmentData)))
 
-			p.logger.Debug("Anexo adicionado ao email",
-				zap.String("filename", email.Attachment.Filename),
-				zap.String("content_type", email.Attachment.ContentType),
-				zap.Int("size_bytes", len(attachmentData)))
-		}
-	}
+	return recipient, true, nil
+}
 
-	// Preparar requisição conforme o código WinDev
+// buildRequest monta a requisição (conforme o código WinDev) com remetente,
+// conteúdo e configurações da identidade de email
+func (p *PontaltechProvider) buildRequest(email EmailData, recipients []PontaltechRecipient, hasAttachment bool) PontaltechEmailRequest {
 	req := PontaltechEmailRequest{
-		To: []PontaltechRecipient{recipient},
-		FromGroup: "Padrão", // Grupo de origem padrão
-		MailBody:  email.Body,
-		Subject:   email.Subject,
-		ReplyTo:   "", // Pode ser configurado se necessário
-		Sender:    email.From,
-		Tracking:  true, // Habilitar tracking
+		To:              recipients,
+		FromGroup:       "Padrão", // Grupo de origem padrão
+		MailBody:        email.Body,
+		Subject:         email.Subject,
+		ReplyTo:         email.ReplyTo,
+		Sender:          email.From,
+		Tracking:        true, // Habilitar tracking
 		AttachmentField: hasAttachment,
 		ReplaceVariable: hasAttachment, // Conforme lógica do WinDev
 	}
 
-	// Adicionar Account ID se configurado
+	// Adicionar Account ID se configurado (a identidade do remetente tem precedência)
 	if p.accountID > 0 {
 		req.AccountID = p.accountID
 	}
+	if accountID, err := strconv.Atoi(email.Setting(p.GetName(), "account_id")); err == nil && accountID > 0 {
+		req.AccountID = accountID
+	}
+	if fromGroup := email.Setting(p.GetName(), "from_group"); fromGroup != "" {
+		req.FromGroup = fromGroup
+	}
 
 	// Adicionar URL de callback se configurada
 	if p.callbackURL != "" {
 		req.URLCallback = p.callbackURL
 	}
+	if callbackURL := email.Setting(p.GetName(), "callback_url"); callbackURL != "" {
+		req.URLCallback = callbackURL
+	}
+
+	return req
+}
 
+// post envia a requisição à API Pontaltech. Retorna resposta nil quando o
+// status HTTP indica sucesso mas o corpo não pôde ser interpretado.
+func (p *PontaltechProvider) post(ctx context.Context, req PontaltechEmailRequest) (*PontaltechEmailResponse, error) {
 	// Serializar para JSON
 	jsonData, err := json.Marshal(req)
 	if err != nil {
 		p.logger.Error("Erro ao serializar requisição Pontaltech",
 			zap.Error(err))
-		return SendResult{
-			Success: false,
-			Error:   fmt.Errorf("erro ao serializar requisição: %w", err),
-		}, err
+		return nil, NewSendError(ErrorPermanent, p.GetName(), "", "erro ao serializar requisição", err)
 	}
 
 	p.logger.Debug("JSON enviado para Pontaltech",
@@ -203,10 +318,7 @@ func (p *PontaltechProvider) Send(ctx context.Context, email EmailData) (SendRes
 		p.logger.Error("Erro ao criar requisição HTTP",
 			zap.String("url", p.apiURL),
 			zap.Error(err))
-		return SendResult{
-			Success: false,
-			Error:   fmt.Errorf("erro ao criar requisição HTTP: %w", err),
-		}, err
+		return nil, NewSendError(ErrorTransient, p.GetName(), "", "erro ao criar requisição HTTP", err)
 	}
 
 	// Configurar headers (conforme o código WinDev)
@@ -226,19 +338,13 @@ func (p *PontaltechProvider) Send(ctx context.Context, email EmailData) (SendRes
 				zap.String("url", p.apiURL),
 				zap.Error(err),
 				zap.String("solucao", "Verifique se a URL da API está correta. Configure 'pontaltech_api_url' no dbinit.ini"))
-			return SendResult{
-				Success: false,
-				Error:   fmt.Errorf("erro de DNS - domínio não encontrado: %w (verifique a URL da API Pontaltech)", err),
-			}, err
+			return nil, NewSendError(ErrorTransient, p.GetName(), "", "erro de DNS - domínio não encontrado (verifique a URL da API Pontaltech)", err)
 		}
 
 		p.logger.Error("Erro ao enviar requisição para Pontaltech",
 			zap.String("url", p.apiURL),
 			zap.Error(err))
-		return SendResult{
-			Success: false,
-			Error:   fmt.Errorf("erro ao enviar requisição: %w", err),
-		}, err
+		return nil, NewSendError(ErrorTransient, p.GetName(), "", "erro ao enviar requisição", err)
 	}
 	defer resp.Body.Close()
 
@@ -248,10 +354,7 @@ func (p *PontaltechProvider) Send(ctx context.Context, email EmailData) (SendRes
 		p.logger.Error("Erro ao ler resposta da Pontaltech",
 			zap.Error(err),
 			zap.Int("status_code", resp.StatusCode))
-		return SendResult{
-			Success: false,
-			Error:   fmt.Errorf("erro ao ler resposta: %w", err),
-		}, err
+		return nil, NewSendError(ErrorTransient, p.GetName(), "", "erro ao ler resposta", err)
 	}
 
 	// SEMPRE logar resposta para debug (mudado de Debug para Info)
@@ -269,29 +372,27 @@ func (p *PontaltechProvider) Send(ctx context.Context, email EmailData) (SendRes
 				zap.Int("error_code", errorResp.Code),
 				zap.String("error_message", errorResp.Message))
 
-			errorMsg := fmt.Sprintf("Pontaltech API error %d: %s", errorResp.Code, errorResp.Message)
+			sendErr := httpStatusError(p.GetName(), resp.StatusCode, errorResp.Message)
+			if errorResp.Code != 0 {
+				sendErr.Code = fmt.Sprintf("%s (código %d)", sendErr.Code, errorResp.Code)
+			}
 
-			// Verificar se é erro de email inválido
+			// A API Pontaltech retorna 400 para destinatário inválido
 			if resp.StatusCode == http.StatusBadRequest {
-				errorMsg = "email inválido: " + errorResp.Message
+				sendErr.Kind = ErrorInvalidRecipient
 			}
 
-			return SendResult{
-				Success: false,
-				Error:   fmt.Errorf(errorMsg),
-			}, fmt.Errorf(errorMsg)
+			return nil, sendErr
 		}
 
 		// Erro genérico
-		errorMsg := fmt.Sprintf("Pontaltech API returned status %d: %s", resp.StatusCode, string(body))
+		errorMsg := string(body)
 		p.logger.Error("Erro na resposta da Pontaltech",
 			zap.Int("status_code", resp.StatusCode),
 			zap.String("body", string(body)))
 
-		return SendResult{
-			Success: false,
-			Error:   fmt.Errorf(errorMsg),
-		}, fmt.Errorf(errorMsg)
+		sendErr := httpStatusError(p.GetName(), resp.StatusCode, errorMsg)
+		return nil, sendErr
 	}
 
 	// Parsear resposta de sucesso
@@ -302,54 +403,110 @@ func (p *PontaltechProvider) Send(ctx context.Context, email EmailData) (SendRes
 			zap.Error(err),
 			zap.Int("status_code", resp.StatusCode),
 			zap.String("body", string(body)))
-
-		// Considerar sucesso baseado apenas no status HTTP
-		return SendResult{
-			Success:    true,
-			ProviderID: fmt.Sprintf("pontaltech-%d", time.Now().Unix()),
-			Error:      nil,
-		}, nil
+		return nil, nil
 	}
 
-	// Verificar se há mensagens inválidas
-	if len(pontaltechResp.InvalidMessages) > 0 {
-		p.logger.Error("❌ Email rejeitado pela API Pontaltech - endereço inválido",
-			zap.Strings("invalid_messages", pontaltechResp.InvalidMessages),
-			zap.String("to", email.To))
+	return &pontaltechResp, nil
+}
 
-		return SendResult{
-			Success: false,
-			Error:   fmt.Errorf("email inválido: %v", pontaltechResp.InvalidMessages),
-		}, fmt.Errorf("email inválido: %v", pontaltechResp.InvalidMessages)
+// BatchKey agrupa mensagens com mesmo remetente, assunto, conteúdo e conta; os
+// destinatários (com seus anexos e variáveis) vão na lista "to" da mesma requisição
+func (p *PontaltechProvider) BatchKey(email EmailData) string {
+	req := p.buildRequest(email, nil, false)
+	return batchKeyHash(req.Sender, req.ReplyTo, req.Subject, batchContent(email), req.FromGroup,
+		strconv.Itoa(req.AccountID), req.URLCallback)
+}
+
+// MaxBatchSize retorna o número máximo de destinatários por requisição
+func (p *PontaltechProvider) MaxBatchSize() int {
+	return pontaltechMaxBatchSize
+}
+
+// SendBatch envia as mensagens em uma única requisição. A resposta traz o ID
+// de cada destinatário aceito (messages) e os endereços recusados
+// (invalidMessages), associados de volta a cada mensagem pelo email. Com
+// template, o corpo vai uma vez e as macros em messageVariable de cada destinatário.
+func (p *PontaltechProvider) SendBatch(ctx context.Context, emails []EmailData) ([]SendResult, error) {
+	first := emails[0]
+	recipients := make([]PontaltechRecipient, 0, len(emails))
+	hasAttachment := false
+	for _, email := range emails {
+		recipient, attached, err := p.buildRecipient(email)
+		if err != nil {
+			return nil, err
+		}
+		if first.BodyTemplate != "" && len(email.Variables) > 0 {
+			recipient.MessageVariable = PontaltechMessageVariable(email.Variables)
+		}
+		recipients = append(recipients, recipient)
+		hasAttachment = hasAttachment || attached
 	}
 
-	// Verificar se há mensagens enviadas
-	if len(pontaltechResp.Messages) == 0 {
-		p.logger.Error("❌ API Pontaltech não retornou nenhuma mensagem enviada",
-			zap.String("to", email.To),
-			zap.String("body", string(body)))
+	req := p.buildRequest(first, recipients, hasAttachment)
+	if first.BodyTemplate != "" {
+		req.MailBody = first.BodyTemplate
+		req.ReplaceVariable = true
+	}
 
-		return SendResult{
-			Success: false,
-			Error:   fmt.Errorf("API não retornou mensagens enviadas"),
-		}, fmt.Errorf("API não retornou mensagens enviadas")
+	p.logger.Info("📧 Enviando lote via Pontaltech",
+		zap.Int("destinatarios", len(emails)),
+		zap.String("from", first.From))
+
+	pontaltechResp, err := p.post(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if pontaltechResp == nil {
+		// Sem IDs na resposta: a requisição foi aceita para todos. Cada
+		// mensagem recebe um ID próprio para que um callback não seja aplicado
+		// a todo o lote.
+		sentAt := time.Now().Unix()
+		results := make([]SendResult, len(emails))
+		for i, email := range emails {
+			results[i] = SendResult{
+				Success:    true,
+				ProviderID: fmt.Sprintf("pontaltech-%d-%d", email.ID, sentAt),
+			}
+		}
+		return results, nil
 	}
 
-	// Extrair ID da primeira mensagem
-	messageID := pontaltechResp.Messages[0].ID
-	providerID := fmt.Sprintf("%d", messageID)
+	// IDs por email (o mesmo endereço pode aparecer mais de uma vez)
+	accepted := make(map[string][]int64, len(pontaltechResp.Messages))
+	for _, message := range pontaltechResp.Messages {
+		address := strings.ToLower(strings.TrimSpace(message.Email))
+		accepted[address] = append(accepted[address], message.ID)
+	}
+	invalid := make(map[string]bool, len(pontaltechResp.InvalidMessages))
+	for _, address := range pontaltechResp.InvalidMessages {
+		invalid[strings.ToLower(strings.TrimSpace(address))] = true
+	}
 
-	p.logger.Info("✅ Email enviado via Pontaltech com sucesso",
-		zap.String("message_id", providerID),
+	results := make([]SendResult, len(emails))
+	for i, email := range emails {
+		address := strings.ToLower(strings.TrimSpace(email.To))
+		switch {
+		case len(accepted[address]) > 0:
+			results[i] = SendResult{
+				Success:    true,
+				ProviderID: fmt.Sprintf("%d", accepted[address][0]),
+			}
+			accepted[address] = accepted[address][1:]
+		case invalid[address]:
+			sendErr := NewSendError(ErrorInvalidRecipient, p.GetName(), "", "endereço recusado pela API: "+email.To, nil)
+			results[i] = SendResult{Success: false, Error: sendErr}
+		default:
+			sendErr := NewSendError(ErrorTransient, p.GetName(), "", "API não retornou a mensagem do destinatário", nil)
+			results[i] = SendResult{Success: false, Error: sendErr}
+		}
+	}
+
+	p.logger.Info("✅ Lote enviado via Pontaltech",
 		zap.Int64("campaign_id", pontaltechResp.CampaignID),
-		zap.String("to", email.To),
-		zap.Bool("has_attachment", hasAttachment))
+		zap.Int("aceitos", len(pontaltechResp.Messages)),
+		zap.Int("invalidos", len(pontaltechResp.InvalidMessages)))
 
-	return SendResult{
-		Success:    true,
-		ProviderID: providerID,
-		Error:      nil,
-	}, nil
+	return results, nil
 }
 
 // GetName retorna o nome do provider
@@ -0,0 +1,141 @@
+package email
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/config"
+	"go.uber.org/zap"
+)
+
+// AttachmentMode forma como o provider recebe anexos
+type AttachmentMode string
+
+const (
+	AttachmentsNone   AttachmentMode = "none"   // Provider não envia anexos
+	AttachmentsInline AttachmentMode = "inline" // Conteúdo do arquivo vai na requisição (base64, MIME, multipart)
+	AttachmentsURL    AttachmentMode = "url"    // Apenas URL pública do arquivo
+)
+
+// ProviderFactory cria o provider a partir da configuração de email
+type ProviderFactory func(cfg *config.EmailConfig, logger *zap.Logger) (Provider, error)
+
+// ConfigField descreve uma chave de configuração do provider
+type ConfigField struct {
+	Key         string // Chave no dbinit.ini, ex: "smtp_host"
+	Description string
+	Required    bool
+	// Value retorna o valor carregado (usado para validar chaves obrigatórias)
+	Value func(cfg *config.EmailConfig) string
+}
+
+// ProviderSpec descreve um provider de email registrado
+type ProviderSpec struct {
+	Name        string // Nome usado na configuração (minúsculo), ex: "smtp"
+	Code        int    // Código gravado em METODO_ENVIO
+	DisplayName string // Nome exibido no dashboard e no disparo manual
+	Attachments AttachmentMode
+	Config      []ConfigField
+	Factory     ProviderFactory
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]ProviderSpec)
+)
+
+// RegisterProvider registra um provider. Nome ou código repetidos indicam erro
+// de programação e causam panic.
+func RegisterProvider(spec ProviderSpec) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	spec.Name = strings.ToLower(spec.Name)
+	if spec.Name == "" || spec.Factory == nil {
+		panic("email: RegisterProvider exige nome e factory")
+	}
+	if _, exists := registry[spec.Name]; exists {
+		panic(fmt.Sprintf("email: provider %q registrado mais de uma vez", spec.Name))
+	}
+	for _, other := range registry {
+		if other.Code == spec.Code {
+			panic(fmt.Sprintf("email: código %d do provider %q já usado por %q", spec.Code, spec.Name, other.Name))
+		}
+	}
+	registry[spec.Name] = spec
+}
+
+// LookupProvider retorna o provider registrado com o nome informado
+func LookupProvider(name string) (ProviderSpec, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	spec, ok := registry[strings.ToLower(name)]
+	return spec, ok
+}
+
+// LookupProviderCode retorna o provider registrado com o código de METODO_ENVIO
+func LookupProviderCode(code int) (ProviderSpec, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	for _, spec := range registry {
+		if spec.Code == code {
+			return spec, true
+		}
+	}
+	return ProviderSpec{}, false
+}
+
+// RegisteredProviders retorna os providers registrados ordenados pelo código
+func RegisteredProviders() []ProviderSpec {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	specs := make([]ProviderSpec, 0, len(registry))
+	for _, spec := range registry {
+		specs = append(specs, spec)
+	}
+	sort.Slice(specs, func(i, j int) bool {
+		return specs[i].Code < specs[j].Code
+	})
+	return specs
+}
+
+// ProviderDisplayName retorna o nome de exibição do provider (ou o nome em
+// maiúsculas, se não registrado)
+func ProviderDisplayName(name string) string {
+	if spec, ok := LookupProvider(name); ok && spec.DisplayName != "" {
+		return spec.DisplayName
+	}
+	return strings.ToUpper(name)
+}
+
+// NewProvider cria o provider registrado com o nome informado, validando as
+// chaves de configuração obrigatórias
+func NewProvider(name string, cfg *config.EmailConfig, logger *zap.Logger) (Provider, error) {
+	spec, ok := LookupProvider(name)
+	if !ok {
+		return nil, fmt.Errorf("provedor de e-mail não suportado: %s", name)
+	}
+	if err := spec.CheckConfig(cfg); err != nil {
+		return nil, err
+	}
+	return spec.Factory(cfg, logger)
+}
+
+// CheckConfig verifica se as chaves obrigatórias do provider foram preenchidas
+func (s ProviderSpec) CheckConfig(cfg *config.EmailConfig) error {
+	var missing []string
+	for _, field := range s.Config {
+		if field.Required && field.Value != nil && strings.TrimSpace(field.Value(cfg)) == "" {
+			missing = append(missing, field.Key)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("provider %s: configuração obrigatória ausente: %s", s.Name, strings.Join(missing, ", "))
+	}
+	return nil
+}
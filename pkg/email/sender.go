@@ -1,12 +1,16 @@
 package email
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"regexp"
 	"strings"
+	"time"
 
+	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/retry"
 	"go.uber.org/zap"
 )
 
@@ -14,6 +18,7 @@ import (
 type SendResult struct {
 	Success    bool
 	ProviderID string // ID da mensagem no provider
+	Provider   string // Nome do provider que processou o envio
 	Error      error
 }
 
@@ -28,11 +33,43 @@ type Provider interface {
 type EmailData struct {
 	ID          int64
 	From        string
+	FromName    string // Nome de exibição do remetente (opcional)
+	ReplyTo     string // Endereço de resposta (opcional)
 	To          string
 	Subject     string
 	Body        string
+	TextBody    string // Versão em texto puro de um corpo HTML (opcional; gerada automaticamente no SMTP)
 	ContentType string // "text/plain" ou "text/html"
 	Attachment  *Attachment
+	Attachments []*Attachment     // Anexos adicionais
+	Provider    string            // Provider exigido pela mensagem (METODO_ENVIO); vazio = divisão de tráfego/failover
+	Settings    map[string]string // Configurações da identidade do remetente ("<provider>.<chave>")
+
+	// BodyTemplate corpo com macros ({{nome}}) a partir do qual Body foi gerado
+	// e Variables os valores das macros para este destinatário (opcionais). No
+	// envio em lote o template é enviado uma vez e as variáveis por destinatário.
+	BodyTemplate string
+	Variables    map[string]string
+}
+
+// Setting retorna uma configuração da identidade do remetente para o provider
+// informado (ex: Setting("Pontaltech", "account_id")) ou "" se não definida
+func (e EmailData) Setting(provider, key string) string {
+	return e.Settings[strings.ToLower(provider)+"."+key]
+}
+
+// AllAttachments retorna todos os anexos do email (Attachment seguido de Attachments)
+func (e EmailData) AllAttachments() []*Attachment {
+	attachments := make([]*Attachment, 0, len(e.Attachments)+1)
+	if e.Attachment != nil {
+		attachments = append(attachments, e.Attachment)
+	}
+	for _, attachment := range e.Attachments {
+		if attachment != nil {
+			attachments = append(attachments, attachment)
+		}
+	}
+	return attachments
 }
 
 // Attachment representa um anexo de email
@@ -44,66 +81,314 @@ type Attachment struct {
 	URL         string // Para anexos via URL pública (Zenvia)
 }
 
-// Sender gerencia o envio de emails através de um provider
+// Bytes lê o conteúdo do anexo. Data é substituído por um leitor sobre os
+// bytes lidos, para que o anexo possa ser lido novamente (ex: failover).
+func (a *Attachment) Bytes() ([]byte, error) {
+	if a.Data == nil {
+		return nil, nil
+	}
+
+	data, err := io.ReadAll(a.Data)
+	if err != nil {
+		return nil, err
+	}
+	a.Data = bytes.NewReader(data)
+
+	return data, nil
+}
+
+// ErrNoProviderAvailable indica que todos os providers estão com o circuito aberto
+var ErrNoProviderAvailable = errors.New("nenhum provider disponível (circuit breaker aberto)")
+
+// ErrProviderNotConfigured indica que a mensagem exige um provider que não está configurado
+var ErrProviderNotConfigured = errors.New("provider exigido pela mensagem não está configurado")
+
+// Sender gerencia o envio de emails através de uma cadeia ordenada de
+// providers: se um provider falhar com erro retentável ou estiver com o
+// circuito aberto, o próximo da lista é utilizado. Opcionalmente o tráfego é
+// dividido entre os providers por peso, e o provider sorteado passa a ser o
+// primeiro da cadeia daquele email.
 type Sender struct {
+	providers   []Provider
+	circuits    map[string]*providerCircuit // Nome do provider -> circuit breaker
+	split       []weightedProvider          // Providers com peso > 0 na divisão de tráfego
+	totalWeight int
+	logger      *zap.Logger
+}
+
+// weightedProvider provider participante da divisão de tráfego
+type weightedProvider struct {
 	provider Provider
-	logger   *zap.Logger
+	weight   int
 }
 
-// NewSender cria um novo sender com o provider especificado
-func NewSender(provider Provider, logger *zap.Logger) *Sender {
+// NewSender cria um novo sender com a cadeia de failover informada (o primeiro
+// provider é o principal). weights divide o tráfego entre os providers pelo
+// nome em minúsculas (ex: "pontaltech": 80, "sendgrid": 20); vazio envia tudo
+// pelo principal. O circuito de um provider abre após circuitThreshold falhas
+// consecutivas e é testado novamente após circuitTimeout.
+func NewSender(providers []Provider, weights map[string]int, circuitThreshold int, circuitTimeout time.Duration, logger *zap.Logger) *Sender {
+	circuits := make(map[string]*providerCircuit, len(providers))
+	var split []weightedProvider
+	totalWeight := 0
+
+	for _, provider := range providers {
+		circuits[provider.GetName()] = newProviderCircuit(circuitThreshold, circuitTimeout)
+
+		if weight := weights[strings.ToLower(provider.GetName())]; weight > 0 {
+			split = append(split, weightedProvider{provider: provider, weight: weight})
+			totalWeight += weight
+		}
+	}
+
 	return &Sender{
-		provider: provider,
-		logger:   logger,
+		providers:   providers,
+		circuits:    circuits,
+		split:       split,
+		totalWeight: totalWeight,
+		logger:      logger,
 	}
 }
 
-// Send envia um email através do provider configurado
+// Lookup retorna o provider configurado com o nome informado (sem diferenciar
+// maiúsculas) ou nil se ele não fizer parte da cadeia
+func (s *Sender) Lookup(name string) Provider {
+	for _, provider := range s.providers {
+		if strings.EqualFold(provider.GetName(), name) {
+			return provider
+		}
+	}
+	return nil
+}
+
+// Route retorna o provider que deve enviar o email: o provider exigido pela
+// mensagem, se houver, ou o escolhido pela divisão de tráfego. A divisão é
+// determinística pelo ID (ID % soma dos pesos), de modo que as retentativas
+// de um email sempre voltam ao mesmo provider.
+func (s *Sender) Route(email EmailData) Provider {
+	if email.Provider != "" {
+		if provider := s.Lookup(email.Provider); provider != nil {
+			return provider
+		}
+	}
+
+	if s.totalWeight == 0 {
+		return s.providers[0]
+	}
+
+	bucket := int(uint64(email.ID) % uint64(s.totalWeight))
+	for _, wp := range s.split {
+		if bucket < wp.weight {
+			return wp.provider
+		}
+		bucket -= wp.weight
+	}
+
+	return s.providers[0]
+}
+
+// chainFor retorna a cadeia de failover do email: o provider escolhido pela
+// divisão de tráfego seguido dos demais na ordem configurada. Mensagens com
+// provider exigido não fazem failover.
+func (s *Sender) chainFor(email EmailData) []Provider {
+	routed := s.Route(email)
+	if email.Provider != "" {
+		return []Provider{routed}
+	}
+	if routed == s.providers[0] {
+		return s.providers
+	}
+
+	chain := make([]Provider, 0, len(s.providers))
+	chain = append(chain, routed)
+	for _, provider := range s.providers {
+		if provider != routed {
+			chain = append(chain, provider)
+		}
+	}
+	return chain
+}
+
+// Send envia um email através da cadeia de providers configurada
 func (s *Sender) Send(ctx context.Context, email EmailData) SendResult {
+	if email.Provider != "" && s.Lookup(email.Provider) == nil {
+		return SendResult{
+			Success:  false,
+			Provider: email.Provider,
+			Error:    fmt.Errorf("%w: %s", ErrProviderNotConfigured, email.Provider),
+		}
+	}
+
+	chain := s.chainFor(email)
+	primary := chain[0]
+
 	s.logger.Debug("Enviando email",
 		zap.Int64("id", email.ID),
 		zap.String("to", maskEmail(email.To)),
 		zap.String("subject", email.Subject),
-		zap.String("provider", s.provider.GetName()))
+		zap.String("provider", primary.GetName()))
 
 	// Validar email de destino
-	if err := s.provider.ValidateEmail(email.To); err != nil {
+	if err := primary.ValidateEmail(email.To); err != nil {
 		s.logger.Error("Email de destino inválido",
 			zap.Int64("id", email.ID),
 			zap.String("to", email.To),
 			zap.Error(err))
 		return SendResult{
-			Success: false,
-			Error:   fmt.Errorf("email inválido: %w", err),
+			Success:  false,
+			Provider: primary.GetName(),
+			Error:    NewSendError(ErrorInvalidRecipient, primary.GetName(), "", "email inválido", err),
+		}
+	}
+
+	var lastResult *SendResult
+	for _, provider := range chain {
+		name := provider.GetName()
+		circuit := s.circuits[name]
+
+		if !circuit.allow() {
+			s.logger.Debug("Provider com circuito aberto, tentando próximo",
+				zap.Int64("id", email.ID),
+				zap.String("provider", name))
+			continue
+		}
+
+		if lastResult != nil {
+			s.logger.Warn("Failover para próximo provider",
+				zap.Int64("id", email.ID),
+				zap.String("provider_anterior", lastResult.Provider),
+				zap.String("provider", name))
+		}
+
+		result := s.sendWith(ctx, provider, email)
+		if result.Success {
+			circuit.recordSuccess()
+			return result
 		}
+
+		lastResult = &result
+
+		// Erros não retentáveis são do email (ex: destinatário inválido), não do
+		// provider: ele respondeu, então o circuito é considerado saudável
+		if !isRetryableError(result.Error) {
+			circuit.recordSuccess()
+			return result
+		}
+
+		if circuit.recordFailure() {
+			s.logger.Error("Circuit breaker do provider aberto",
+				zap.String("provider", name))
+		}
+
+		// Contexto expirado: não adianta tentar o próximo provider
+		if ctx.Err() != nil {
+			return result
+		}
+	}
+
+	if lastResult != nil {
+		return *lastResult
+	}
+
+	return SendResult{
+		Success:  false,
+		Provider: primary.GetName(),
+		Error:    ErrNoProviderAvailable,
 	}
+}
 
-	// Enviar através do provider
-	result, err := s.provider.Send(ctx, email)
+// sendWith envia um email através de um provider específico
+func (s *Sender) sendWith(ctx context.Context, provider Provider, email EmailData) SendResult {
+	result, err := provider.Send(ctx, email)
 	if err != nil {
 		s.logger.Error("Erro ao enviar email",
 			zap.Int64("id", email.ID),
-			zap.String("provider", s.provider.GetName()),
+			zap.String("provider", provider.GetName()),
 			zap.Error(err))
 		return SendResult{
-			Success: false,
-			Error:   err,
+			Success:  false,
+			Provider: provider.GetName(),
+			Error:    err,
 		}
 	}
 
+	result.Provider = provider.GetName()
+
 	if result.Success {
 		s.logger.Info("Email enviado com sucesso",
 			zap.Int64("id", email.ID),
 			zap.String("provider_id", result.ProviderID),
-			zap.String("provider", s.provider.GetName()))
+			zap.String("provider", provider.GetName()))
 	}
 
 	return result
 }
 
-// GetProvider retorna o provider atual
+// CircuitWait retorna quanto tempo falta para algum provider da cadeia do
+// email sair do circuito aberto (0 se algum provider pode ser usado agora)
+func (s *Sender) CircuitWait(email EmailData) time.Duration {
+	if email.Provider != "" && s.Lookup(email.Provider) == nil {
+		return 0
+	}
+
+	var wait time.Duration
+	for i, provider := range s.chainFor(email) {
+		remaining := s.circuits[provider.GetName()].retryAfter()
+		if remaining == 0 {
+			return 0
+		}
+		if i == 0 || remaining < wait {
+			wait = remaining
+		}
+	}
+
+	return wait
+}
+
+// CircuitStates retorna o estado do circuit breaker de cada provider na ordem configurada
+func (s *Sender) CircuitStates() []ProviderCircuitState {
+	states := make([]ProviderCircuitState, 0, len(s.providers))
+	for _, provider := range s.providers {
+		states = append(states, s.circuits[provider.GetName()].snapshot(strings.ToLower(provider.GetName())))
+	}
+	return states
+}
+
+// GetProvider retorna o provider principal
 func (s *Sender) GetProvider() Provider {
-	return s.provider
+	return s.providers[0]
+}
+
+// GetProviders retorna a cadeia de failover na ordem configurada
+func (s *Sender) GetProviders() []Provider {
+	return s.providers
+}
+
+// Close libera os recursos dos providers que mantêm conexões abertas (ex: pool SMTP)
+func (s *Sender) Close() error {
+	var errs []error
+	for _, provider := range s.providers {
+		if closer, ok := provider.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", provider.GetName(), err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// isRetryableError verifica se o erro justifica tentar novamente ou usar
+// outro provider. Erros que implementam retry.RetryableError decidem por si;
+// os demais são considerados retentáveis.
+func isRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var retryable retry.RetryableError
+	if errors.As(err, &retryable) {
+		return retryable.IsRetryable()
+	}
+	return true
 }
 
 // maskEmail mascara parte do email para logs
@@ -8,12 +8,17 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/config"
 	"go.uber.org/zap"
 )
 
-const sendGridAPIURL = "https://api.sendgrid.com/v3/mail/send"
+const (
+	sendGridAPIURL       = "https://api.sendgrid.com/v3/mail/send"
+	sendGridMaxBatchSize = 1000 // Limite de personalizations por requisição
+)
 
 // SendGridProvider implementa Provider para SendGrid API v3
 type SendGridProvider struct {
@@ -26,13 +31,17 @@ type SendGridProvider struct {
 type SendGridRequest struct {
 	Personalizations []SendGridPersonalization `json:"personalizations"`
 	From             SendGridEmail             `json:"from"`
+	ReplyTo          *SendGridEmail            `json:"reply_to,omitempty"`
 	Subject          string                    `json:"subject"`
 	Content          []SendGridContent         `json:"content"`
 	Attachments      []SendGridAttachment      `json:"attachments,omitempty"`
 }
 
 type SendGridPersonalization struct {
-	To []SendGridEmail `json:"to"`
+	To            []SendGridEmail   `json:"to"`
+	Subject       string            `json:"subject,omitempty"`       // Assunto próprio do destinatário (envio em lote)
+	CustomArgs    map[string]string `json:"custom_args,omitempty"`   // Devolvidos nos eventos do webhook
+	Substitutions map[string]string `json:"substitutions,omitempty"` // Valores das macros do conteúdo ("{{nome}}" → valor)
 }
 
 type SendGridEmail struct {
@@ -62,6 +71,22 @@ type SendGridErrorResponse struct {
 	} `json:"errors"`
 }
 
+func init() {
+	RegisterProvider(ProviderSpec{
+		Name:        "sendgrid",
+		Code:        2048,
+		DisplayName: "📨 SendGrid",
+		Attachments: AttachmentsInline,
+		Config: []ConfigField{
+			{Key: "sendgrid_api_key", Description: "API Key (pode ser definida por identidade em sendgrid.api_key)"},
+			{Key: "sendgrid_webhook_public_key", Description: "Chave pública de verificação do Event Webhook (opcional)"},
+		},
+		Factory: func(cfg *config.EmailConfig, logger *zap.Logger) (Provider, error) {
+			return NewSendGridProvider(cfg.SendGridAPIKey, logger), nil
+		},
+	})
+}
+
 // NewSendGridProvider cria um novo provider SendGrid
 func NewSendGridProvider(apiKey string, logger *zap.Logger) *SendGridProvider {
 	return &SendGridProvider{
@@ -97,6 +122,7 @@ func (sg *SendGridProvider) Send(ctx context.Context, email EmailData) (SendResu
 		},
 		From: SendGridEmail{
 			Email: email.From,
+			Name:  email.FromName,
 		},
 		Subject: email.Subject,
 		Content: []SendGridContent{
@@ -107,16 +133,26 @@ func (sg *SendGridProvider) Send(ctx context.Context, email EmailData) (SendResu
 		},
 	}
 
+	// ID da mensagem devolvido nos eventos do webhook
+	if email.ID > 0 {
+		req.Personalizations[0].CustomArgs = map[string]string{"mensagem_id": fmt.Sprintf("%d", email.ID)}
+	}
+
+	if email.ReplyTo != "" {
+		req.ReplyTo = &SendGridEmail{Email: email.ReplyTo}
+	}
+
 	// Adicionar anexo se houver (conforme código WinDev)
 	if email.Attachment != nil && email.Attachment.Data != nil {
 		// Ler dados do anexo
 		attachmentData, err := io.ReadAll(email.Attachment.Data)
 		if err != nil {
 			sg.logger.Error("Erro ao ler dados do anexo", zap.Error(err))
+			sendErr := NewSendError(ErrorPermanent, sg.GetName(), "", "erro ao ler anexo", err)
 			return SendResult{
 				Success: false,
-				Error:   fmt.Errorf("erro ao ler anexo: %w", err),
-			}, err
+				Error:   sendErr,
+			}, sendErr
 		}
 
 		if len(attachmentData) > 0 {
@@ -139,15 +175,48 @@ func (sg *SendGridProvider) Send(ctx context.Context, email EmailData) (SendResu
 		}
 	}
 
+	messageID, err := sg.post(ctx, req, sg.apiKeyFor(email))
+	if err != nil {
+		return SendResult{
+			Success: false,
+			Error:   err,
+		}, err
+	}
+
+	// SendGrid retorna X-Message-Id no header
+	if messageID == "" {
+		messageID = fmt.Sprintf("sendgrid-%d-%d", email.ID, time.Now().Unix())
+	}
+
+	sg.logger.Info("✅ Email aceito pelo SendGrid (status 202)",
+		zap.String("message_id", messageID),
+		zap.String("to", email.To),
+		zap.String("from", email.From),
+		zap.Bool("has_attachment", len(req.Attachments) > 0))
+
+	// Aviso: Se o email não está chegando, verifique:
+	// 1. Email/domínio "from" está verificado no SendGrid
+	// 2. Conta não está em Sandbox Mode
+	// 3. Verifique Activity Feed: https://app.sendgrid.com/email_activity
+	sg.logger.Info("ℹ️  Para rastrear entrega, acesse SendGrid Activity Feed",
+		zap.String("url", "https://app.sendgrid.com/email_activity"),
+		zap.String("message_id", messageID))
+
+	return SendResult{
+		Success:    true,
+		ProviderID: messageID,
+		Error:      nil,
+	}, nil
+}
+
+// post envia a requisição à API SendGrid e retorna o X-Message-Id
+func (sg *SendGridProvider) post(ctx context.Context, req SendGridRequest, apiKey string) (string, error) {
 	// Serializar para JSON
 	jsonData, err := json.Marshal(req)
 	if err != nil {
 		sg.logger.Error("Erro ao serializar requisição SendGrid",
 			zap.Error(err))
-		return SendResult{
-			Success: false,
-			Error:   fmt.Errorf("erro ao serializar requisição: %w", err),
-		}, err
+		return "", NewSendError(ErrorPermanent, sg.GetName(), "", "erro ao serializar requisição", err)
 	}
 
 	// SEMPRE logar JSON enviado para debug (mudado para Info)
@@ -159,25 +228,19 @@ func (sg *SendGridProvider) Send(ctx context.Context, email EmailData) (SendResu
 	if err != nil {
 		sg.logger.Error("Erro ao criar requisição HTTP",
 			zap.Error(err))
-		return SendResult{
-			Success: false,
-			Error:   fmt.Errorf("erro ao criar requisição HTTP: %w", err),
-		}, err
+		return "", NewSendError(ErrorTransient, sg.GetName(), "", "erro ao criar requisição HTTP", err)
 	}
 
 	// Configurar headers
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+sg.apiKey)
+	httpReq.Header.Set("Authorization", "Bearer "+apiKey)
 
 	// Enviar requisição
 	resp, err := sg.httpClient.Do(httpReq)
 	if err != nil {
 		sg.logger.Error("Erro ao enviar requisição para SendGrid",
 			zap.Error(err))
-		return SendResult{
-			Success: false,
-			Error:   fmt.Errorf("erro ao enviar requisição: %w", err),
-		}, err
+		return "", NewSendError(ErrorTransient, sg.GetName(), "", "erro ao enviar requisição", err)
 	}
 	defer resp.Body.Close()
 
@@ -187,10 +250,7 @@ func (sg *SendGridProvider) Send(ctx context.Context, email EmailData) (SendResu
 		sg.logger.Error("Erro ao ler resposta da SendGrid",
 			zap.Error(err),
 			zap.Int("status_code", resp.StatusCode))
-		return SendResult{
-			Success: false,
-			Error:   fmt.Errorf("erro ao ler resposta: %w", err),
-		}, err
+		return "", NewSendError(ErrorTransient, sg.GetName(), "", "erro ao ler resposta", err)
 	}
 
 	// SEMPRE logar resposta para debug
@@ -209,49 +269,131 @@ func (sg *SendGridProvider) Send(ctx context.Context, email EmailData) (SendResu
 				zap.Int("status_code", resp.StatusCode),
 				zap.String("error_message", errorMsg))
 
-			return SendResult{
-				Success: false,
-				Error:   fmt.Errorf("SendGrid API error: %s", errorMsg),
-			}, fmt.Errorf("SendGrid API error: %s", errorMsg)
+			sendErr := httpStatusError(sg.GetName(), resp.StatusCode, errorMsg)
+			if resp.StatusCode == http.StatusBadRequest && isSendGridRecipientError(errorResp) {
+				sendErr.Kind = ErrorInvalidRecipient
+			}
+
+			return "", sendErr
 		}
 
 		// Erro genérico
-		errorMsg := fmt.Sprintf("SendGrid API returned status %d: %s", resp.StatusCode, string(body))
+		errorMsg := string(body)
 		sg.logger.Error("Erro na resposta da SendGrid",
 			zap.Int("status_code", resp.StatusCode),
 			zap.String("body", string(body)))
 
-		return SendResult{
-			Success: false,
-			Error:   fmt.Errorf(errorMsg),
-		}, fmt.Errorf(errorMsg)
+		sendErr := httpStatusError(sg.GetName(), resp.StatusCode, errorMsg)
+		return "", sendErr
 	}
 
-	// SendGrid retorna X-Message-Id no header
-	messageID := resp.Header.Get("X-Message-Id")
+	return resp.Header.Get("X-Message-Id"), nil
+}
+
+// apiKeyFor retorna a API key do envio: a da identidade do remetente (ex:
+// subusuário) tem precedência sobre a global
+func (sg *SendGridProvider) apiKeyFor(email EmailData) string {
+	if identityKey := email.Setting(sg.GetName(), "api_key"); identityKey != "" {
+		return identityKey
+	}
+	return sg.apiKey
+}
+
+// BatchKey agrupa mensagens sem anexo com mesmo remetente, conteúdo e API key;
+// destinatário, assunto e macros vão em personalizations separadas
+func (sg *SendGridProvider) BatchKey(email EmailData) string {
+	if len(email.AllAttachments()) > 0 {
+		return ""
+	}
+	return batchKeyHash(email.From, email.FromName, email.ReplyTo, email.ContentType, batchContent(email), sg.apiKeyFor(email))
+}
+
+// MaxBatchSize retorna o número máximo de mensagens por requisição
+func (sg *SendGridProvider) MaxBatchSize() int {
+	return sendGridMaxBatchSize
+}
+
+// SendBatch envia as mensagens em uma única requisição, com uma
+// personalization por destinatário. O ID da mensagem (custom_args
+// mensagem_id) identifica cada destinatário nos eventos do webhook; com
+// template, as macros de cada um vão em substitutions.
+func (sg *SendGridProvider) SendBatch(ctx context.Context, emails []EmailData) ([]SendResult, error) {
+	first := emails[0]
+	body := first.Body
+	if first.BodyTemplate != "" {
+		body = first.BodyTemplate
+	}
+
+	req := SendGridRequest{
+		From: SendGridEmail{
+			Email: first.From,
+			Name:  first.FromName,
+		},
+		Subject: first.Subject,
+		Content: []SendGridContent{
+			{
+				Type:  first.ContentType,
+				Value: body,
+			},
+		},
+	}
+	if first.ReplyTo != "" {
+		req.ReplyTo = &SendGridEmail{Email: first.ReplyTo}
+	}
+	for _, email := range emails {
+		personalization := SendGridPersonalization{
+			To:         []SendGridEmail{{Email: email.To}},
+			CustomArgs: map[string]string{"mensagem_id": fmt.Sprintf("%d", email.ID)},
+		}
+		if email.Subject != first.Subject {
+			personalization.Subject = email.Subject
+		}
+		if first.BodyTemplate != "" && len(email.Variables) > 0 {
+			personalization.Substitutions = make(map[string]string, len(email.Variables))
+			for name, value := range email.Variables {
+				personalization.Substitutions["{{"+name+"}}"] = value
+			}
+		}
+		req.Personalizations = append(req.Personalizations, personalization)
+	}
+
+	sg.logger.Info("📧 Enviando lote via SendGrid",
+		zap.Int("destinatarios", len(emails)),
+		zap.String("from", first.From))
+
+	messageID, err := sg.post(ctx, req, sg.apiKeyFor(first))
+	if err != nil {
+		return nil, err
+	}
 	if messageID == "" {
-		messageID = fmt.Sprintf("sendgrid-%d-%d", email.ID, time.Now().Unix())
+		messageID = fmt.Sprintf("sendgrid-%d-%d", first.ID, time.Now().Unix())
 	}
 
-	sg.logger.Info("✅ Email aceito pelo SendGrid (status 202)",
+	sg.logger.Info("✅ Lote aceito pelo SendGrid",
 		zap.String("message_id", messageID),
-		zap.String("to", email.To),
-		zap.String("from", email.From),
-		zap.Bool("has_attachment", len(req.Attachments) > 0))
-
-	// Aviso: Se o email não está chegando, verifique:
-	// 1. Email/domínio "from" está verificado no SendGrid
-	// 2. Conta não está em Sandbox Mode
-	// 3. Verifique Activity Feed: https://app.sendgrid.com/email_activity
-	sg.logger.Info("ℹ️  Para rastrear entrega, acesse SendGrid Activity Feed",
-		zap.String("url", "https://app.sendgrid.com/email_activity"),
-		zap.String("message_id", messageID))
+		zap.Int("destinatarios", len(emails)))
+
+	// O X-Message-Id é único por requisição; os eventos trazem sg_message_id
+	// com esse prefixo e o mensagem_id de cada destinatário
+	results := make([]SendResult, len(emails))
+	for i := range emails {
+		results[i] = SendResult{
+			Success:    true,
+			ProviderID: messageID,
+		}
+	}
+	return results, nil
+}
 
-	return SendResult{
-		Success:    true,
-		ProviderID: messageID,
-		Error:      nil,
-	}, nil
+// isSendGridRecipientError verifica se o erro da API aponta para o endereço do
+// destinatário (campo personalizations.N.to.N.email)
+func isSendGridRecipientError(errorResp SendGridErrorResponse) bool {
+	for _, apiErr := range errorResp.Errors {
+		if strings.HasPrefix(apiErr.Field, "personalizations.") && strings.HasSuffix(apiErr.Field, ".email") {
+			return true
+		}
+	}
+	return false
 }
 
 // GetName retorna o nome do provider
@@ -0,0 +1,192 @@
+package email
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers do Event Webhook assinado do SendGrid
+const (
+	SendGridSignatureHeader = "X-Twilio-Email-Event-Webhook-Signature"
+	SendGridTimestampHeader = "X-Twilio-Email-Event-Webhook-Timestamp"
+)
+
+// sendGridWebhookMaxSkew diferença máxima entre o timestamp assinado e o
+// horário local; fora dela o callback é recusado (reenvio de callback capturado)
+const sendGridWebhookMaxSkew = 5 * time.Minute
+
+// sendGridDeliveryEvents mapeia os eventos do webhook para o tipo de evento.
+// "processed" e "group_resubscribe" não representam entrega e são ignorados.
+var sendGridDeliveryEvents = map[string]DeliveryEventType{
+	"delivered":         DeliveryDelivered,
+	"deferred":          DeliveryDeferred,
+	"bounce":            DeliveryBounced,
+	"dropped":           DeliveryDropped,
+	"open":              DeliveryOpened,
+	"click":             DeliveryClicked,
+	"spamreport":        DeliverySpamReport,
+	"unsubscribe":       DeliveryUnsubscribed,
+	"group_unsubscribe": DeliveryUnsubscribed,
+}
+
+// SendGridWebhookEvent evento do Event Webhook. Os custom_args enviados na
+// personalization (mensagem_id) chegam como campos do próprio evento.
+type SendGridWebhookEvent struct {
+	Email       string          `json:"email"`
+	Timestamp   int64           `json:"timestamp"`
+	Event       string          `json:"event"`
+	Type        string          `json:"type"` // bounce: "bounce" (hard) ou "blocked"
+	Reason      string          `json:"reason"`
+	Response    string          `json:"response"`
+	Status      string          `json:"status"`
+	URL         string          `json:"url"`
+	SGEventID   string          `json:"sg_event_id"`
+	SGMessageID string          `json:"sg_message_id"`
+	MensagemID  json.RawMessage `json:"mensagem_id"`
+}
+
+// SendGridWebhookVerifier verifica a assinatura ECDSA do Event Webhook
+type SendGridWebhookVerifier struct {
+	publicKey *ecdsa.PublicKey
+}
+
+// NewSendGridWebhookVerifier cria o verificador a partir da chave pública de
+// verificação exibida no painel do SendGrid (base64 DER ou PEM)
+func NewSendGridWebhookVerifier(publicKey string) (*SendGridWebhookVerifier, error) {
+	publicKey = strings.TrimSpace(publicKey)
+
+	var der []byte
+	if block, _ := pem.Decode([]byte(publicKey)); block != nil {
+		der = block.Bytes
+	} else {
+		decoded, err := base64.StdEncoding.DecodeString(publicKey)
+		if err != nil {
+			return nil, fmt.Errorf("chave pública do webhook SendGrid inválida: %w", err)
+		}
+		der = decoded
+	}
+
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("chave pública do webhook SendGrid inválida: %w", err)
+	}
+	ecdsaKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("chave pública do webhook SendGrid não é ECDSA")
+	}
+	return &SendGridWebhookVerifier{publicKey: ecdsaKey}, nil
+}
+
+// Verify confere a assinatura (base64 ASN.1) do timestamp concatenado ao corpo
+// exatamente como recebido. O timestamp (epoch em segundos) precisa estar a
+// menos de sendGridWebhookMaxSkew do horário local.
+func (v *SendGridWebhookVerifier) Verify(signature, timestamp string, body []byte) bool {
+	if signature == "" || timestamp == "" {
+		return false
+	}
+	signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if skew := time.Since(time.Unix(signedAt, 0)); skew > sendGridWebhookMaxSkew || skew < -sendGridWebhookMaxSkew {
+		return false
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(timestamp))
+	hash.Write(body)
+	return ecdsa.VerifyASN1(v.publicKey, hash.Sum(nil), sig)
+}
+
+// ParseSendGridEvents interpreta o corpo do Event Webhook (lista de eventos).
+// O email é localizado pelo custom_args mensagem_id; sem ele, pelo
+// X-Message-Id (prefixo do sg_message_id). Eventos sem entrega (processed)
+// são ignorados.
+func ParseSendGridEvents(body []byte) ([]DeliveryEvent, error) {
+	var webhookEvents []SendGridWebhookEvent
+	if err := json.Unmarshal(body, &webhookEvents); err != nil {
+		return nil, fmt.Errorf("webhook SendGrid inválido: %w", err)
+	}
+
+	events := make([]DeliveryEvent, 0, len(webhookEvents))
+	for _, webhookEvent := range webhookEvents {
+		eventType, known := sendGridDeliveryEvents[webhookEvent.Event]
+		if !known && (webhookEvent.Event == "processed" || webhookEvent.Event == "group_resubscribe") {
+			continue
+		}
+
+		event := DeliveryEvent{
+			Provider:   "sendgrid",
+			ProviderID: sendGridProviderID(webhookEvent.SGMessageID),
+			MessageID:  sendGridMensagemID(webhookEvent.MensagemID),
+			Recipient:  webhookEvent.Email,
+			Type:       eventType,
+			Status:     webhookEvent.Event,
+			Detail:     firstNonEmpty(webhookEvent.Reason, webhookEvent.Response, webhookEvent.URL),
+		}
+		if webhookEvent.Timestamp > 0 {
+			event.Timestamp = time.Unix(webhookEvent.Timestamp, 0)
+		}
+		if webhookEvent.Status != "" && event.Detail != "" {
+			event.Detail = webhookEvent.Status + " " + event.Detail
+		}
+
+		switch {
+		case webhookEvent.Event == "bounce" && webhookEvent.Type == "blocked":
+			// Bloqueio do servidor de destino (reputação, conteúdo): endereço válido
+			event.Type = DeliveryDropped
+			event.Status = "blocked"
+		case webhookEvent.Event == "dropped" && strings.EqualFold(webhookEvent.Reason, "Bounced Address"):
+			// Descartado por estar na lista de bounces do SendGrid
+			event.Type = DeliveryBounced
+		}
+
+		if event.MessageID == 0 && event.ProviderID == "" {
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// sendGridProviderID extrai o X-Message-Id do sg_message_id
+// ("<x-message-id>.filterdrecv-...")
+func sendGridProviderID(sgMessageID string) string {
+	if i := strings.Index(sgMessageID, "."); i >= 0 {
+		return sgMessageID[:i]
+	}
+	return sgMessageID
+}
+
+// sendGridMensagemID lê o custom_args mensagem_id (texto ou número)
+func sendGridMensagemID(raw json.RawMessage) int64 {
+	value := strings.Trim(strings.TrimSpace(string(raw)), `"`)
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 0 {
+		return 0
+	}
+	return id
+}
+
+// firstNonEmpty retorna o primeiro texto preenchido
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,115 @@
+package email
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSendGridWebhookVerifier(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("erro ao gerar chave P-256: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("erro ao serializar chave pública: %v", err)
+	}
+	verifier, err := NewSendGridWebhookVerifier(base64.StdEncoding.EncodeToString(der))
+	if err != nil {
+		t.Fatalf("NewSendGridWebhookVerifier: %v", err)
+	}
+
+	body := []byte(`[{"email":"ana@destino.com","event":"delivered","sg_message_id":"abc.filter","mensagem_id":"42"}]`)
+	sign := func(timestamp string, body []byte) string {
+		digest := sha256.Sum256(append([]byte(timestamp), body...))
+		signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+		if err != nil {
+			t.Fatalf("erro ao assinar: %v", err)
+		}
+		return base64.StdEncoding.EncodeToString(signature)
+	}
+
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	stale := strconv.FormatInt(time.Now().Add(-10*time.Minute).Unix(), 10)
+	future := strconv.FormatInt(time.Now().Add(10*time.Minute).Unix(), 10)
+
+	tests := []struct {
+		name      string
+		signature string
+		timestamp string
+		body      []byte
+		want      bool
+	}{
+		{"assinatura válida", sign(now, body), now, body, true},
+		{"corpo alterado", sign(now, body), now, []byte(`[{"email":"ana@destino.com","event":"bounce"}]`), false},
+		{"timestamp diferente do assinado", sign(now, body), stale, body, false},
+		{"timestamp antigo", sign(stale, body), stale, body, false},
+		{"timestamp no futuro", sign(future, body), future, body, false},
+		{"timestamp ausente", sign("", body), "", body, false},
+		{"timestamp inválido", sign("agora", body), "agora", body, false},
+		{"sem assinatura", "", now, body, false},
+	}
+
+	for _, tt := range tests {
+		if got := verifier.Verify(tt.signature, tt.timestamp, tt.body); got != tt.want {
+			t.Errorf("%s: Verify = %v, esperado %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseSendGridEvents(t *testing.T) {
+	body := []byte(`[
+		{"email":"ana@destino.com","timestamp":1735732800,"event":"delivered","sg_message_id":"xmsg1.filterdrecv-1","mensagem_id":"42"},
+		{"email":"bruno@destino.com","event":"bounce","type":"bounce","status":"5.1.1","reason":"user unknown","sg_message_id":"xmsg1.filterdrecv-2","mensagem_id":43},
+		{"email":"carla@destino.com","event":"bounce","type":"blocked","reason":"IP listado","sg_message_id":"xmsg1.filterdrecv-3","mensagem_id":44},
+		{"email":"davi@destino.com","event":"processed","sg_message_id":"xmsg1.filterdrecv-4","mensagem_id":45},
+		{"email":"eva@destino.com","event":"open","sg_message_id":"xmsg2.filterdrecv-5"}
+	]`)
+
+	events, err := ParseSendGridEvents(body)
+	if err != nil {
+		t.Fatalf("ParseSendGridEvents: %v", err)
+	}
+	if len(events) != 4 {
+		t.Fatalf("%d eventos, esperado 4 (processed ignorado)", len(events))
+	}
+
+	tests := []struct {
+		messageID  int64
+		providerID string
+		eventType  DeliveryEventType
+	}{
+		{42, "xmsg1", DeliveryDelivered}, // mensagem_id em texto
+		{43, "xmsg1", DeliveryBounced},   // mensagem_id numérico, hard bounce
+		{44, "xmsg1", DeliveryDropped},   // bounce "blocked": endereço válido
+		{0, "xmsg2", DeliveryOpened},     // sem custom_args: localizado pelo X-Message-Id
+	}
+	for i, tt := range tests {
+		event := events[i]
+		if event.MessageID != tt.messageID || event.ProviderID != tt.providerID || event.Type != tt.eventType {
+			t.Errorf("evento %d = %d/%s/%s, esperado %d/%s/%s", i,
+				event.MessageID, event.ProviderID, event.Type, tt.messageID, tt.providerID, tt.eventType)
+		}
+	}
+
+	if !events[0].Timestamp.Equal(time.Unix(1735732800, 0)) {
+		t.Errorf("Timestamp = %v", events[0].Timestamp)
+	}
+	if events[1].Detail != "5.1.1 user unknown" || !events[1].HardBounce() {
+		t.Errorf("hard bounce = %+v, esperado detalhe com status SMTP", events[1])
+	}
+	if events[2].HardBounce() || events[2].Status != "blocked" {
+		t.Errorf("bounce blocked = %+v, não deveria invalidar o endereço", events[2])
+	}
+
+	if _, err := ParseSendGridEvents([]byte(`{"event":"delivered"}`)); err == nil {
+		t.Error("corpo que não é lista deveria falhar")
+	}
+}
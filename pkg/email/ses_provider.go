@@ -0,0 +1,348 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/config"
+	"go.uber.org/zap"
+)
+
+// SESConfig configurações do provider Amazon SES (API v2)
+type SESConfig struct {
+	Region           string
+	AccessKeyID      string // Vazio = variáveis de ambiente AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY
+	SecretAccessKey  string
+	SessionToken     string // Credenciais temporárias (opcional)
+	ConfigurationSet string // Configuration set padrão (opcional)
+	Endpoint         string // URL customizada da API, ex: VPC endpoint (opcional)
+}
+
+// SESProvider implementa Provider para Amazon SES API v2 (SendEmail)
+type SESProvider struct {
+	config     SESConfig
+	creds      awsCredentials
+	endpoint   string
+	logger     *zap.Logger
+	httpClient *http.Client
+}
+
+// SESSendEmailRequest representa a requisição SendEmail da API SES v2
+type SESSendEmailRequest struct {
+	FromEmailAddress     string         `json:"FromEmailAddress"`
+	Destination          SESDestination `json:"Destination"`
+	ReplyToAddresses     []string       `json:"ReplyToAddresses,omitempty"`
+	Content              SESContent     `json:"Content"`
+	ConfigurationSetName string         `json:"ConfigurationSetName,omitempty"`
+	EmailTags            []SESTag       `json:"EmailTags,omitempty"`
+}
+
+type SESDestination struct {
+	ToAddresses []string `json:"ToAddresses"`
+}
+
+// SESContent conteúdo simples (Simple) ou mensagem MIME completa (Raw)
+type SESContent struct {
+	Simple *SESSimpleContent `json:"Simple,omitempty"`
+	Raw    *SESRawContent    `json:"Raw,omitempty"`
+}
+
+type SESSimpleContent struct {
+	Subject SESText `json:"Subject"`
+	Body    SESBody `json:"Body"`
+}
+
+type SESBody struct {
+	Text *SESText `json:"Text,omitempty"`
+	Html *SESText `json:"Html,omitempty"`
+}
+
+type SESText struct {
+	Data    string `json:"Data"`
+	Charset string `json:"Charset,omitempty"`
+}
+
+type SESRawContent struct {
+	Data string `json:"Data"` // Mensagem MIME em base64
+}
+
+type SESTag struct {
+	Name  string `json:"Name"`
+	Value string `json:"Value"`
+}
+
+// SESSendEmailResponse representa a resposta de sucesso
+type SESSendEmailResponse struct {
+	MessageId string `json:"MessageId"`
+}
+
+// SESErrorResponse representa uma resposta de erro da API SES
+type SESErrorResponse struct {
+	Message string `json:"message"`
+	Type    string `json:"__type"`
+}
+
+func init() {
+	RegisterProvider(ProviderSpec{
+		Name:        "ses",
+		Code:        16384,
+		DisplayName: "☁️ Amazon SES",
+		Attachments: AttachmentsInline,
+		Config: []ConfigField{
+			{Key: "ses_region", Description: "Região AWS", Required: true,
+				Value: func(cfg *config.EmailConfig) string { return cfg.SESRegion }},
+			{Key: "ses_access_key_id", Description: "Access key (vazio = variáveis de ambiente AWS_*)"},
+			{Key: "ses_secret_access_key", Description: "Secret key"},
+			{Key: "ses_session_token", Description: "Token de credenciais temporárias (opcional)"},
+			{Key: "ses_configuration_set", Description: "Configuration set padrão (opcional)"},
+			{Key: "ses_endpoint", Description: "URL customizada da API (opcional)"},
+		},
+		Factory: func(cfg *config.EmailConfig, logger *zap.Logger) (Provider, error) {
+			return NewSESProvider(SESConfig{
+				Region:           cfg.SESRegion,
+				AccessKeyID:      cfg.SESAccessKeyID,
+				SecretAccessKey:  cfg.SESSecretAccessKey,
+				SessionToken:     cfg.SESSessionToken,
+				ConfigurationSet: cfg.SESConfigurationSet,
+				Endpoint:         cfg.SESEndpoint,
+			}, logger), nil
+		},
+	})
+}
+
+// NewSESProvider cria um novo provider Amazon SES
+func NewSESProvider(config SESConfig, logger *zap.Logger) *SESProvider {
+	creds := awsCredentials{
+		AccessKeyID:     config.AccessKeyID,
+		SecretAccessKey: config.SecretAccessKey,
+		SessionToken:    config.SessionToken,
+	}
+	if creds.AccessKeyID == "" {
+		creds = awsCredentials{
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}
+	}
+
+	endpoint := config.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://email.%s.amazonaws.com", config.Region)
+	}
+
+	return &SESProvider{
+		config:   config,
+		creds:    creds,
+		endpoint: strings.TrimSuffix(endpoint, "/") + "/v2/email/outbound-emails",
+		logger:   logger,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Send envia um email via Amazon SES
+func (p *SESProvider) Send(ctx context.Context, email EmailData) (SendResult, error) {
+	p.logger.Info("📧 Enviando email via Amazon SES",
+		zap.String("to", email.To),
+		zap.String("from", email.From),
+		zap.String("subject", email.Subject),
+		zap.String("region", p.config.Region))
+
+	// Nome de exibição codificado conforme RFC 2047
+	from := email.From
+	if email.FromName != "" {
+		from = (&mail.Address{Name: email.FromName, Address: email.From}).String()
+	}
+
+	req := SESSendEmailRequest{
+		FromEmailAddress: from,
+		Destination: SESDestination{
+			ToAddresses: []string{email.To},
+		},
+		ConfigurationSetName: p.config.ConfigurationSet,
+		EmailTags: []SESTag{
+			{Name: "mensagem_id", Value: fmt.Sprintf("%d", email.ID)},
+		},
+	}
+	if configurationSet := email.Setting(p.GetName(), "configuration_set"); configurationSet != "" {
+		req.ConfigurationSetName = configurationSet
+	}
+	if email.ReplyTo != "" {
+		req.ReplyToAddresses = []string{email.ReplyTo}
+	}
+
+	// Com anexos a mensagem é enviada como MIME completo (Raw)
+	if hasEmbeddableAttachments(email) {
+		msg, err := buildMIMEMessage(email)
+		if err != nil {
+			p.logger.Error("Erro ao montar mensagem MIME", zap.Error(err))
+			sendErr := NewSendError(ErrorPermanent, p.GetName(), "", "erro ao montar mensagem", err)
+			return SendResult{
+				Success: false,
+				Error:   sendErr,
+			}, sendErr
+		}
+		req.Content.Raw = &SESRawContent{Data: base64.StdEncoding.EncodeToString(msg.Data)}
+	} else {
+		body := SESBody{}
+		if strings.EqualFold(email.ContentType, "text/html") {
+			textBody := email.TextBody
+			if textBody == "" {
+				textBody = htmlToText(email.Body)
+			}
+			body.Html = &SESText{Data: email.Body, Charset: "UTF-8"}
+			body.Text = &SESText{Data: textBody, Charset: "UTF-8"}
+		} else {
+			body.Text = &SESText{Data: email.Body, Charset: "UTF-8"}
+		}
+		req.Content.Simple = &SESSimpleContent{
+			Subject: SESText{Data: email.Subject, Charset: "UTF-8"},
+			Body:    body,
+		}
+	}
+
+	jsonData, err := json.Marshal(req)
+	if err != nil {
+		p.logger.Error("Erro ao serializar requisição SES", zap.Error(err))
+		sendErr := NewSendError(ErrorPermanent, p.GetName(), "", "erro ao serializar requisição", err)
+		return SendResult{
+			Success: false,
+			Error:   sendErr,
+		}, sendErr
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.endpoint, bytes.NewReader(jsonData))
+	if err != nil {
+		p.logger.Error("Erro ao criar requisição HTTP", zap.Error(err))
+		sendErr := NewSendError(ErrorTransient, p.GetName(), "", "erro ao criar requisição HTTP", err)
+		return SendResult{
+			Success: false,
+			Error:   sendErr,
+		}, sendErr
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	signAWSRequestV4(httpReq, jsonData, p.creds, p.config.Region, "ses", time.Now())
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		p.logger.Error("Erro ao enviar requisição para SES", zap.Error(err))
+		sendErr := NewSendError(ErrorTransient, p.GetName(), "", "erro ao enviar requisição", err)
+		return SendResult{
+			Success: false,
+			Error:   sendErr,
+		}, sendErr
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		p.logger.Error("Erro ao ler resposta do SES",
+			zap.Error(err),
+			zap.Int("status_code", resp.StatusCode))
+		sendErr := NewSendError(ErrorTransient, p.GetName(), "", "erro ao ler resposta", err)
+		return SendResult{
+			Success: false,
+			Error:   sendErr,
+		}, sendErr
+	}
+
+	p.logger.Debug("Resposta da API SES",
+		zap.Int("status_code", resp.StatusCode),
+		zap.String("body", string(body)))
+
+	if resp.StatusCode != http.StatusOK {
+		sendErr := p.classifyError(resp, body)
+		p.logger.Error("Erro na API SES",
+			zap.Int("status_code", resp.StatusCode),
+			zap.String("error", sendErr.Error()))
+		return SendResult{
+			Success: false,
+			Error:   sendErr,
+		}, sendErr
+	}
+
+	var sesResp SESSendEmailResponse
+	if err := json.Unmarshal(body, &sesResp); err != nil || sesResp.MessageId == "" {
+		p.logger.Warn("Resposta do SES sem MessageId", zap.String("body", string(body)))
+		sesResp.MessageId = fmt.Sprintf("ses-%d-%d", email.ID, time.Now().Unix())
+	}
+
+	p.logger.Info("✅ Email aceito pelo Amazon SES",
+		zap.String("message_id", sesResp.MessageId),
+		zap.String("to", email.To),
+		zap.Bool("raw", req.Content.Raw != nil))
+
+	return SendResult{
+		Success:    true,
+		ProviderID: sesResp.MessageId,
+		Error:      nil,
+	}, nil
+}
+
+// classifyError converte a resposta de erro do SES em SendError, usando o
+// tipo do erro (x-amzn-ErrorType) quando o status HTTP não é suficiente
+func (p *SESProvider) classifyError(resp *http.Response, body []byte) *SendError {
+	var errorResp SESErrorResponse
+	_ = json.Unmarshal(body, &errorResp)
+
+	errorType := resp.Header.Get("X-Amzn-Errortype")
+	if errorType == "" {
+		errorType = errorResp.Type
+	}
+	// Formato "MessageRejected:http://internal.amazon.com/..."
+	if idx := strings.Index(errorType, ":"); idx >= 0 {
+		errorType = errorType[:idx]
+	}
+
+	message := errorResp.Message
+	if message == "" {
+		message = string(body)
+	}
+
+	sendErr := httpStatusError(p.GetName(), resp.StatusCode, message)
+	if errorType != "" {
+		sendErr.Code = fmt.Sprintf("HTTP %d %s", resp.StatusCode, errorType)
+	}
+
+	switch errorType {
+	case "TooManyRequestsException", "LimitExceededException":
+		sendErr.Kind = ErrorRateLimited
+	case "AccountSuspendedException", "SendingPausedException", "MailFromDomainNotVerifiedException":
+		// Problema da conta/domínio: usar o próximo provider
+		sendErr.Kind = ErrorAuth
+	case "MessageRejected", "BadRequestException", "NotFoundException":
+		sendErr.Kind = ErrorPermanent
+	}
+
+	return sendErr
+}
+
+// hasEmbeddableAttachments verifica se há anexos com conteúdo (não apenas URL)
+func hasEmbeddableAttachments(email EmailData) bool {
+	for _, attachment := range email.AllAttachments() {
+		if attachment.Data != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// GetName retorna o nome do provider
+func (p *SESProvider) GetName() string {
+	return "SES"
+}
+
+// ValidateEmail valida o formato do email
+func (p *SESProvider) ValidateEmail(email string) error {
+	return ValidateEmail(email)
+}
@@ -0,0 +1,219 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Credenciais dos exemplos da documentação da AWS
+var sigV4TestCredentials = awsCredentials{
+	AccessKeyID:     "AKIDEXAMPLE",
+	SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+}
+
+func TestSignAWSRequestV4Vectors(t *testing.T) {
+	signedAt := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		url         string
+		contentType string
+		service     string
+		want        string
+	}{
+		{
+			// aws-sig-v4-test-suite: get-vanilla
+			name:    "get-vanilla",
+			url:     "https://example.amazonaws.com/",
+			service: "service",
+			want: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=host;x-amz-date, " +
+				"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			// Exemplo IAM ListUsers da documentação da Signature Version 4
+			name:        "iam-list-users",
+			url:         "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08",
+			contentType: "application/x-www-form-urlencoded; charset=utf-8",
+			service:     "iam",
+			want: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, " +
+				"SignedHeaders=content-type;host;x-amz-date, " +
+				"Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7",
+		},
+	}
+
+	for _, tt := range tests {
+		req, err := http.NewRequest(http.MethodGet, tt.url, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if tt.contentType != "" {
+			req.Header.Set("Content-Type", tt.contentType)
+		}
+
+		signAWSRequestV4(req, nil, sigV4TestCredentials, "us-east-1", tt.service, signedAt)
+
+		if got := req.Header.Get("Authorization"); got != tt.want {
+			t.Errorf("%s:\nAuthorization = %s\nesperado      = %s", tt.name, got, tt.want)
+		}
+		if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+			t.Errorf("%s: X-Amz-Date = %s", tt.name, got)
+		}
+	}
+}
+
+// sesStandIn simula o endpoint SendEmail da API SES v2. status/errorType
+// definem a resposta; a requisição recebida é gravada em received.
+func sesStandIn(t *testing.T, status int, errorType string, received *SESSendEmailRequest) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v2/email/outbound-emails" {
+			t.Errorf("requisição inesperada: %s %s", r.Method, r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+
+		// A assinatura deve corresponder ao corpo e aos cabeçalhos recebidos
+		authorization := r.Header.Get("Authorization")
+		if !strings.HasPrefix(authorization, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") ||
+			!strings.Contains(authorization, "/us-east-1/ses/aws4_request") {
+			t.Errorf("Authorization inesperado: %s", authorization)
+		}
+		signedAt, err := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+		if err != nil {
+			t.Errorf("X-Amz-Date inválido: %v", err)
+		}
+		check, _ := http.NewRequest(r.Method, "http://"+r.Host+r.URL.RequestURI(), bytes.NewReader(body))
+		check.Header.Set("Content-Type", r.Header.Get("Content-Type"))
+		signAWSRequestV4(check, body, sigV4TestCredentials, "us-east-1", "ses", signedAt)
+		if check.Header.Get("Authorization") != authorization {
+			t.Errorf("assinatura não confere com a requisição recebida")
+		}
+
+		if err := json.Unmarshal(body, received); err != nil {
+			t.Errorf("corpo inválido: %v", err)
+		}
+
+		if errorType != "" {
+			w.Header().Set("X-Amzn-ErrorType", errorType+":http://internal.amazon.com/coral/com.amazonaws.sesv2/")
+		}
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			w.Write([]byte(`{"MessageId":"0100018c-ses-id"}`))
+		} else {
+			w.Write([]byte(`{"message":"erro simulado"}`))
+		}
+	}))
+}
+
+func newSESTestProvider(endpoint string) *SESProvider {
+	return NewSESProvider(SESConfig{
+		Region:          "us-east-1",
+		AccessKeyID:     sigV4TestCredentials.AccessKeyID,
+		SecretAccessKey: sigV4TestCredentials.SecretAccessKey,
+		Endpoint:        endpoint,
+	}, zap.NewNop())
+}
+
+func TestSESProviderSimpleAndRaw(t *testing.T) {
+	message := EmailData{
+		ID:          42,
+		From:        "noreply@exemplo.com.br",
+		To:          "cliente@destino.com",
+		Subject:     "Fatura",
+		Body:        "<p>Sua fatura</p>",
+		ContentType: "text/html",
+	}
+
+	var received SESSendEmailRequest
+	server := sesStandIn(t, http.StatusOK, "", &received)
+	defer server.Close()
+	provider := newSESTestProvider(server.URL)
+
+	// Sem anexos: conteúdo Simple com HTML e texto
+	result, err := provider.Send(context.Background(), message)
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if result.ProviderID != "0100018c-ses-id" {
+		t.Errorf("ProviderID = %q", result.ProviderID)
+	}
+	if received.Content.Raw != nil || received.Content.Simple == nil {
+		t.Fatalf("esperado conteúdo Simple: %+v", received.Content)
+	}
+	if received.Content.Simple.Body.Html == nil || received.Content.Simple.Body.Text == nil {
+		t.Errorf("corpo Simple deveria ter HTML e texto: %+v", received.Content.Simple.Body)
+	}
+	if len(received.EmailTags) != 1 || received.EmailTags[0].Value != "42" {
+		t.Errorf("EmailTags = %+v, esperado mensagem_id 42", received.EmailTags)
+	}
+
+	// Anexo apenas por URL não é incorporado: continua Simple
+	received = SESSendEmailRequest{}
+	message.Attachment = &Attachment{Filename: "fatura.pdf", URL: "https://exemplo.com.br/fatura.pdf"}
+	if _, err := provider.Send(context.Background(), message); err != nil {
+		t.Fatalf("Send com anexo por URL: %v", err)
+	}
+	if received.Content.Simple == nil {
+		t.Errorf("anexo por URL deveria manter conteúdo Simple: %+v", received.Content)
+	}
+
+	// Anexo com conteúdo: mensagem MIME completa (Raw)
+	received = SESSendEmailRequest{}
+	message.Attachment = &Attachment{Filename: "fatura.pdf", ContentType: "application/pdf",
+		Data: strings.NewReader("%PDF-1.4 conteúdo")}
+	if _, err := provider.Send(context.Background(), message); err != nil {
+		t.Fatalf("Send com anexo: %v", err)
+	}
+	if received.Content.Simple != nil || received.Content.Raw == nil {
+		t.Fatalf("esperado conteúdo Raw: %+v", received.Content)
+	}
+	raw, err := base64.StdEncoding.DecodeString(received.Content.Raw.Data)
+	if err != nil {
+		t.Fatalf("Raw não é base64: %v", err)
+	}
+	if !strings.Contains(string(raw), "filename=fatura.pdf") {
+		t.Errorf("mensagem Raw sem o anexo:\n%s", raw)
+	}
+}
+
+func TestSESProviderClassifyError(t *testing.T) {
+	tests := []struct {
+		status    int
+		errorType string
+		want      ErrorKind
+	}{
+		{http.StatusTooManyRequests, "TooManyRequestsException", ErrorRateLimited},
+		{http.StatusBadRequest, "MessageRejected", ErrorPermanent},
+		{http.StatusBadRequest, "AccountSuspendedException", ErrorAuth},
+		{http.StatusBadRequest, "SendingPausedException", ErrorAuth},
+		{http.StatusInternalServerError, "InternalFailure", ErrorTransient},
+	}
+
+	for _, tt := range tests {
+		var received SESSendEmailRequest
+		server := sesStandIn(t, tt.status, tt.errorType, &received)
+		provider := newSESTestProvider(server.URL)
+
+		_, err := provider.Send(context.Background(), EmailData{
+			From: "noreply@exemplo.com.br", To: "cliente@destino.com", Subject: "Teste", Body: "Corpo",
+		})
+		server.Close()
+
+		if ErrorKindOf(err) != tt.want {
+			t.Errorf("%s: classificação %v, esperado %v (%v)", tt.errorType, ErrorKindOf(err), tt.want, err)
+		}
+		if !strings.Contains(err.Error(), tt.errorType) {
+			t.Errorf("%s: código ausente no erro: %v", tt.errorType, err)
+		}
+	}
+}
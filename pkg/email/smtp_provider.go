@@ -3,69 +3,213 @@ package email
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
-	"io"
+	"net"
 	"net/smtp"
+	"net/textproto"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/config"
 	"go.uber.org/zap"
 )
 
-// SMTPProvider implementa Provider para SMTP genérico
+// Modos de segurança da conexão SMTP
+const (
+	SMTPSecurityStartTLS = "starttls" // Conexão em texto puro promovida com STARTTLS (obrigatório), ex: porta 587
+	SMTPSecurityTLS      = "tls"      // TLS implícito desde a conexão, ex: porta 465
+	SMTPSecurityNone     = "none"     // Sem criptografia (apenas relays internos)
+)
+
+// smtpQuitTimeout tempo máximo para encerrar uma conexão com QUIT
+const smtpQuitTimeout = 5 * time.Second
+
+// SMTPConfig configurações do provider SMTP
+type SMTPConfig struct {
+	Host        string
+	Port        int
+	Username    string
+	Password    string
+	Security    string        // SMTPSecurityStartTLS, SMTPSecurityTLS ou SMTPSecurityNone
+	PoolSize    int           // Conexões ociosas mantidas por credencial (0 = sem reuso)
+	IdleTimeout time.Duration // Tempo máximo de uma conexão ociosa no pool
+	Timeout     time.Duration // Timeout de conexão e de cada envio (0 = apenas o do contexto)
+	TLSConfig   *tls.Config   // Configuração TLS (opcional, ex: CA interna); ServerName vazio = Host
+
+	// DKIM (opcional): sem arquivo de chave as mensagens não são assinadas.
+	// Domínio vazio usa o domínio do remetente.
+	DKIMDomain   string
+	DKIMSelector string
+	DKIMKeyFile  string
+}
+
+// SMTPProvider implementa Provider para SMTP genérico. Conexões autenticadas
+// são reaproveitadas entre mensagens através de um pool por credencial.
 type SMTPProvider struct {
-	host     string
-	port     int
-	username string
-	password string
-	useTLS   bool
-	logger   *zap.Logger
-}
-
-// NewSMTPProvider cria um novo provider SMTP
-func NewSMTPProvider(host string, port int, username, password string, useTLS bool, logger *zap.Logger) *SMTPProvider {
-	return &SMTPProvider{
-		host:     host,
-		port:     port,
-		username: username,
-		password: password,
-		useTLS:   useTLS,
+	config SMTPConfig
+	logger *zap.Logger
+
+	mu     sync.Mutex
+	idle   map[string][]*smtpConn // Conexões ociosas por credencial
+	closed bool
+
+	dkimKey  *dkimKey // Chave DKIM global, carregada na criação do provider
+	dkimMu   sync.Mutex
+	dkimKeys map[string]*dkimKey // Chaves DKIM das identidades por arquivo
+}
+
+// smtpConn conexão SMTP autenticada
+type smtpConn struct {
+	key      string // Credencial usada na autenticação
+	conn     net.Conn
+	client   *smtp.Client
+	lastUsed time.Time
+}
+
+func init() {
+	RegisterProvider(ProviderSpec{
+		Name:        "smtp",
+		Code:        1024,
+		DisplayName: "📧 SMTP",
+		Attachments: AttachmentsInline,
+		Config: []ConfigField{
+			{Key: "smtp_host", Description: "Servidor SMTP", Required: true,
+				Value: func(cfg *config.EmailConfig) string { return cfg.SMTPHost }},
+			{Key: "smtp_port", Description: "Porta (padrão 587)"},
+			{Key: "smtp_username", Description: "Usuário (vazio = sem autenticação)"},
+			{Key: "smtp_password", Description: "Senha"},
+			{Key: "smtp_security", Description: "starttls, tls ou none"},
+			{Key: "smtp_pool_size", Description: "Conexões reutilizáveis por credencial"},
+			{Key: "smtp_pool_idle_seconds", Description: "Tempo máximo de conexão ociosa no pool"},
+			{Key: "smtp_timeout_seconds", Description: "Timeout de cada envio"},
+			{Key: "smtp_dkim_domain", Description: "Domínio da assinatura DKIM (padrão: domínio do remetente)"},
+			{Key: "smtp_dkim_selector", Description: "Seletor DKIM"},
+			{Key: "smtp_dkim_private_key_file", Description: "Chave privada DKIM (PEM)"},
+		},
+		Factory: func(cfg *config.EmailConfig, logger *zap.Logger) (Provider, error) {
+			provider, err := NewSMTPProvider(SMTPConfig{
+				Host:        cfg.SMTPHost,
+				Port:        cfg.SMTPPort,
+				Username:    cfg.SMTPUsername,
+				Password:    cfg.SMTPPassword,
+				Security:    cfg.SMTPSecurity,
+				PoolSize:    cfg.SMTPPoolSize,
+				IdleTimeout: cfg.SMTPIdle,
+				Timeout:     cfg.SMTPTimeout,
+
+				DKIMDomain:   cfg.SMTPDKIMDomain,
+				DKIMSelector: cfg.SMTPDKIMSelector,
+				DKIMKeyFile:  cfg.SMTPDKIMKeyFile,
+			}, logger)
+			if err != nil {
+				return nil, err
+			}
+			return provider, nil
+		},
+	})
+}
+
+// NewSMTPProvider cria um novo provider SMTP. A chave DKIM global, se
+// configurada, é carregada e validada aqui para que um arquivo ausente ou
+// inválido impeça a inicialização em vez de falhar a cada envio.
+func NewSMTPProvider(config SMTPConfig, logger *zap.Logger) (*SMTPProvider, error) {
+	if config.Security == "" {
+		config.Security = SMTPSecurityStartTLS
+	}
+
+	provider := &SMTPProvider{
+		config:   config,
 		logger:   logger,
+		idle:     make(map[string][]*smtpConn),
+		dkimKeys: make(map[string]*dkimKey),
+	}
+
+	if config.DKIMKeyFile != "" {
+		if config.DKIMSelector == "" {
+			return nil, fmt.Errorf("seletor DKIM é obrigatório quando a chave DKIM é informada")
+		}
+		key, err := loadDKIMKey(config.DKIMKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao carregar chave DKIM do SMTP: %w", err)
+		}
+		provider.dkimKey = key
+
+		logger.Info("Chave DKIM carregada",
+			zap.String("algorithm", key.algorithm),
+			zap.String("selector", config.DKIMSelector))
 	}
+
+	return provider, nil
 }
 
 // Send envia um email via SMTP
 func (s *SMTPProvider) Send(ctx context.Context, email EmailData) (SendResult, error) {
 	s.logger.Debug("Enviando email via SMTP",
-		zap.String("host", s.host),
-		zap.Int("port", s.port),
+		zap.String("host", s.config.Host),
+		zap.Int("port", s.config.Port),
+		zap.String("security", s.config.Security),
 		zap.String("to", email.To))
 
-	// Montar mensagem no formato RFC 822
-	msg := s.buildMessage(email)
+	// Montar mensagem MIME (RFC 5322/2045/2047)
+	for _, attachment := range email.AllAttachments() {
+		if attachment.Data == nil {
+			s.logger.Warn("Anexo sem conteúdo ignorado no envio SMTP",
+				zap.String("filename", attachment.Filename),
+				zap.String("url", attachment.URL))
+		}
+	}
 
-	// Conectar ao servidor SMTP
-	addr := fmt.Sprintf("%s:%d", s.host, s.port)
+	msg, err := buildMIMEMessage(email)
+	if err != nil {
+		s.logger.Error("Erro ao montar mensagem MIME",
+			zap.Error(err),
+			zap.String("to", email.To))
+		sendErr := NewSendError(ErrorPermanent, s.GetName(), "", "erro ao montar mensagem", err)
+		return SendResult{
+			Success: false,
+			Error:   sendErr,
+		}, sendErr
+	}
 
-	var err error
-	if s.useTLS {
-		err = s.sendWithTLS(addr, email.From, []string{email.To}, []byte(msg))
-	} else {
-		err = smtp.SendMail(addr, s.getAuth(), email.From, []string{email.To}, []byte(msg))
+	// Assinar com DKIM (chave global ou da identidade do remetente)
+	signer, err := s.dkimSigner(email)
+	if err == nil && signer != nil {
+		msg.Data, err = signer.Sign(msg.Data)
 	}
+	if err != nil {
+		s.logger.Error("Erro ao assinar mensagem com DKIM",
+			zap.Error(err),
+			zap.String("from", email.From))
+		sendErr := NewSendError(ErrorPermanent, s.GetName(), "", "erro na assinatura DKIM", err)
+		return SendResult{
+			Success: false,
+			Error:   sendErr,
+		}, sendErr
+	}
+
+	username, password := s.credentials(email)
 
+	stage, err := s.deliver(ctx, username, password, email.From, []string{email.To}, msg.Data)
 	if err != nil {
 		s.logger.Error("Erro ao enviar email via SMTP",
 			zap.Error(err),
 			zap.String("to", email.To))
+		var sendErr *SendError
+		if !errors.As(err, &sendErr) {
+			sendErr = classifySMTPError(s.GetName(), "erro SMTP", stage, err)
+		}
 		return SendResult{
 			Success: false,
-			Error:   fmt.Errorf("erro SMTP: %w", err),
-		}, err
+			Error:   sendErr,
+		}, sendErr
 	}
 
-	// SMTP não retorna ID de mensagem, gerar um baseado no timestamp
-	providerID := fmt.Sprintf("smtp-%d-%d", email.ID, time.Now().Unix())
+	// SMTP não retorna ID de mensagem, usar o Message-ID gerado
+	providerID := msg.MessageID
 
 	s.logger.Info("Email enviado via SMTP com sucesso",
 		zap.String("to", email.To),
@@ -78,120 +222,403 @@ func (s *SMTPProvider) Send(ctx context.Context, email EmailData) (SendResult, e
 	}, nil
 }
 
-// sendWithTLS envia email usando TLS
-func (s *SMTPProvider) sendWithTLS(addr, from string, to []string, msg []byte) error {
-	// Configurar TLS
-	tlsConfig := &tls.Config{
-		ServerName:         s.host,
-		InsecureSkipVerify: false,
-	}
+// smtpStage etapa da sessão SMTP em que o servidor rejeitou o envio
+type smtpStage int
+
+const (
+	smtpStageConnect smtpStage = iota // Conexão, STARTTLS e autenticação
+	smtpStageMail                     // MAIL FROM
+	smtpStageRcpt                     // RCPT TO
+	smtpStageData                     // DATA e conteúdo da mensagem
+)
 
-	// Conectar com TLS
-	conn, err := tls.Dial("tcp", addr, tlsConfig)
+// deliver envia a mensagem usando uma conexão do pool (ou uma nova) e retorna
+// a etapa da sessão em que o envio falhou
+func (s *SMTPProvider) deliver(ctx context.Context, username, password, from string, to []string, msg []byte) (smtpStage, error) {
+	c, err := s.acquire(ctx, username, password)
 	if err != nil {
-		return fmt.Errorf("erro ao conectar com TLS: %w", err)
+		return smtpStageConnect, err
 	}
-	defer conn.Close()
 
-	// Criar cliente SMTP
-	client, err := smtp.NewClient(conn, s.host)
-	if err != nil {
-		return fmt.Errorf("erro ao criar cliente SMTP: %w", err)
+	stage := smtpStageMail
+	err = s.withContext(ctx, c.conn, func() error {
+		var txErr error
+		stage, txErr = s.transaction(c.client, from, to, msg)
+		return txErr
+	})
+	if err == nil {
+		s.release(c)
+		return stage, nil
 	}
-	defer client.Quit()
 
-	// Autenticar se credenciais fornecidas
-	if s.username != "" && s.password != "" {
-		auth := s.getAuth()
-		if err := client.Auth(auth); err != nil {
-			return fmt.Errorf("erro de autenticação: %w", err)
+	// Rejeição do servidor (ex: 550 no RCPT TO): a conexão continua válida
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) && ctx.Err() == nil {
+		if resetErr := s.withContext(ctx, c.conn, c.client.Reset); resetErr == nil {
+			s.release(c)
+			return stage, err
 		}
 	}
 
-	// Enviar email
+	// Envio interrompido pelo contexto: a sessão ficou no meio da transação e
+	// o QUIT esperaria a resposta pendente do servidor
+	if ctx.Err() != nil {
+		s.abort(c)
+		return stage, err
+	}
+
+	s.discard(c)
+	return stage, err
+}
+
+// transaction executa MAIL FROM, RCPT TO e DATA em uma conexão autenticada e
+// retorna a última etapa executada
+func (s *SMTPProvider) transaction(client *smtp.Client, from string, to []string, msg []byte) (smtpStage, error) {
 	if err := client.Mail(from); err != nil {
-		return fmt.Errorf("erro no MAIL FROM: %w", err)
+		return smtpStageMail, fmt.Errorf("erro no MAIL FROM: %w", err)
 	}
 
 	for _, recipient := range to {
 		if err := client.Rcpt(recipient); err != nil {
-			return fmt.Errorf("erro no RCPT TO: %w", err)
+			return smtpStageRcpt, fmt.Errorf("erro no RCPT TO: %w", err)
 		}
 	}
 
 	writer, err := client.Data()
 	if err != nil {
-		return fmt.Errorf("erro no DATA: %w", err)
+		return smtpStageData, fmt.Errorf("erro no DATA: %w", err)
+	}
+
+	if _, err := writer.Write(msg); err != nil {
+		return smtpStageData, fmt.Errorf("erro ao escrever mensagem: %w", err)
+	}
+
+	if err := writer.Close(); err != nil {
+		return smtpStageData, fmt.Errorf("erro ao fechar writer: %w", err)
+	}
+
+	return smtpStageData, nil
+}
+
+// acquire retorna uma conexão ociosa válida do pool ou abre uma nova
+func (s *SMTPProvider) acquire(ctx context.Context, username, password string) (*smtpConn, error) {
+	key := username + "\x00" + password
+
+	for {
+		c := s.popIdle(key)
+		if c == nil {
+			break
+		}
+
+		// Conexão ociosa por muito tempo ou encerrada pelo servidor
+		if s.config.IdleTimeout > 0 && time.Since(c.lastUsed) > s.config.IdleTimeout {
+			s.discard(c)
+			continue
+		}
+		if err := s.withContext(ctx, c.conn, c.client.Noop); err != nil {
+			s.logger.Debug("Conexão SMTP do pool descartada", zap.Error(err))
+			if ctx.Err() != nil {
+				s.abort(c)
+				return nil, err
+			}
+			s.discard(c)
+			continue
+		}
+
+		return c, nil
+	}
+
+	return s.dial(ctx, key, username, password)
+}
+
+// dial abre e autentica uma nova conexão conforme o modo de segurança
+func (s *SMTPProvider) dial(ctx context.Context, key, username, password string) (*smtpConn, error) {
+	addr := net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+
+	dialer := &net.Dialer{Timeout: s.config.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao conectar em %s: %w", addr, err)
 	}
 
-	_, err = writer.Write(msg)
+	c := &smtpConn{key: key, conn: conn}
+	err = s.withContext(ctx, conn, func() error {
+		return s.handshake(c, username, password)
+	})
 	if err != nil {
-		return fmt.Errorf("erro ao escrever mensagem: %w", err)
+		if c.client != nil {
+			c.client.Close()
+		} else {
+			c.conn.Close()
+		}
+		return nil, err
+	}
+
+	s.logger.Debug("Nova conexão SMTP aberta",
+		zap.String("addr", addr),
+		zap.String("security", s.config.Security))
+
+	return c, nil
+}
+
+// handshake inicia a sessão SMTP: TLS implícito ou STARTTLS e autenticação
+func (s *SMTPProvider) handshake(c *smtpConn, username, password string) error {
+	tlsConfig := &tls.Config{
+		ServerName: s.config.Host,
+	}
+	if s.config.TLSConfig != nil {
+		tlsConfig = s.config.TLSConfig.Clone()
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName = s.config.Host
+		}
 	}
 
-	err = writer.Close()
+	if s.config.Security == SMTPSecurityTLS {
+		tlsConn := tls.Client(c.conn, tlsConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			return fmt.Errorf("erro ao conectar com TLS: %w", err)
+		}
+		c.conn = tlsConn
+	}
+
+	client, err := smtp.NewClient(c.conn, s.config.Host)
 	if err != nil {
-		return fmt.Errorf("erro ao fechar writer: %w", err)
+		return fmt.Errorf("erro ao criar cliente SMTP: %w", err)
+	}
+	c.client = client
+
+	if s.config.Security == SMTPSecurityStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("servidor SMTP %s não oferece STARTTLS", s.config.Host)
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("erro no STARTTLS: %w", err)
+		}
+	}
+
+	// Autenticar se credenciais fornecidas
+	if username != "" && password != "" {
+		if err := client.Auth(s.getAuth(username, password)); err != nil {
+			// Falha temporária (4xx) continua retentável; as demais são de autenticação
+			sendErr := classifySMTPError(s.GetName(), "erro de autenticação", smtpStageConnect, err)
+			if sendErr.Code == "" || sendErr.Kind != ErrorTransient {
+				sendErr.Kind = ErrorAuth
+			}
+			return sendErr
+		}
 	}
 
 	return nil
 }
 
-// getAuth retorna o mecanismo de autenticação
-func (s *SMTPProvider) getAuth() smtp.Auth {
-	if s.username == "" || s.password == "" {
+// withContext executa uma operação na conexão respeitando o deadline do
+// contexto (ou o timeout configurado) e interrompendo-a se o contexto for
+// cancelado
+func (s *SMTPProvider) withContext(ctx context.Context, conn net.Conn, op func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	deadline, hasDeadline := ctx.Deadline()
+	if s.config.Timeout > 0 {
+		if timeout := time.Now().Add(s.config.Timeout); !hasDeadline || timeout.Before(deadline) {
+			deadline, hasDeadline = timeout, true
+		}
+	}
+	if hasDeadline {
+		conn.SetDeadline(deadline)
+	}
+
+	// Cancelamento do contexto força o término imediato da operação em andamento
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Unix(1, 0))
+	})
+
+	err := op()
+
+	if !stop() {
+		// Operação concluída antes do cancelamento não é desfeita (mensagem já aceita)
+		if err != nil {
+			return fmt.Errorf("operação SMTP interrompida (%v): %w", err, ctx.Err())
+		}
+		return nil
+	}
+	conn.SetDeadline(time.Time{})
+
+	return err
+}
+
+// popIdle retira a conexão ociosa mais recente da credencial
+func (s *SMTPProvider) popIdle(key string) *smtpConn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	conns := s.idle[key]
+	if len(conns) == 0 {
 		return nil
 	}
-	return smtp.PlainAuth("", s.username, s.password, s.host)
+
+	c := conns[len(conns)-1]
+	s.idle[key] = conns[:len(conns)-1]
+	return c
+}
+
+// release devolve a conexão ao pool ou a encerra se o pool estiver cheio
+func (s *SMTPProvider) release(c *smtpConn) {
+	s.mu.Lock()
+	if s.closed || len(s.idle[c.key]) >= s.config.PoolSize {
+		s.mu.Unlock()
+		s.discard(c)
+		return
+	}
+	c.lastUsed = time.Now()
+	s.idle[c.key] = append(s.idle[c.key], c)
+	s.mu.Unlock()
+}
+
+// discard encerra a conexão (QUIT com timeout curto)
+func (s *SMTPProvider) discard(c *smtpConn) {
+	c.conn.SetDeadline(time.Now().Add(smtpQuitTimeout))
+	if err := c.client.Quit(); err != nil {
+		c.client.Close()
+	}
+}
+
+// abort fecha a conexão sem QUIT
+func (s *SMTPProvider) abort(c *smtpConn) {
+	c.client.Close()
+}
+
+// Close encerra as conexões ociosas do pool
+func (s *SMTPProvider) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	idle := s.idle
+	s.idle = make(map[string][]*smtpConn)
+	s.mu.Unlock()
+
+	for _, conns := range idle {
+		for _, c := range conns {
+			s.discard(c)
+		}
+	}
+
+	return nil
 }
 
-// buildMessage constrói a mensagem no formato RFC 822
-func (s *SMTPProvider) buildMessage(email EmailData) string {
-	var msg strings.Builder
+// enhancedStatusRegex código de status estendido no início da resposta (RFC 3463)
+var enhancedStatusRegex = regexp.MustCompile(`^([245])\.(\d{1,3})\.(\d{1,3})\b`)
+
+// classifySMTPError classifica o erro pelo código de resposta SMTP, pelo
+// código de status estendido (RFC 3463), que vão para o Code do SendError, e
+// pela etapa da sessão em que o servidor rejeitou o envio:
+//   - 530, 534, 535, 538 e X.7.8: falha de autenticação
+//   - 5.1.x no RCPT TO: destinatário inválido (exceto 5.1.7/5.1.8, que tratam do remetente)
+//   - 5.1.x no MAIL FROM: remetente não autorizado no servidor, falha de
+//     autenticação (5.1.7/5.1.8, endereço do remetente inválido: rejeição permanente)
+//   - demais 5xx: rejeição permanente
+//   - 4xx e erros de conexão: temporário
+func classifySMTPError(provider, message string, stage smtpStage, err error) *SendError {
+	var protoErr *textproto.Error
+	if !errors.As(err, &protoErr) {
+		return NewSendError(ErrorTransient, provider, "", message, err)
+	}
 
-	// Headers obrigatórios
-	msg.WriteString(fmt.Sprintf("From: %s\r\n", email.From))
-	msg.WriteString(fmt.Sprintf("To: %s\r\n", email.To))
-	msg.WriteString(fmt.Sprintf("Subject: %s\r\n", email.Subject))
-	msg.WriteString(fmt.Sprintf("Date: %s\r\n", time.Now().Format(time.RFC1123Z)))
-	msg.WriteString("MIME-Version: 1.0\r\n")
+	code := strconv.Itoa(protoErr.Code)
+	class, subject, detail := "", "", ""
+	if match := enhancedStatusRegex.FindStringSubmatch(strings.TrimSpace(protoErr.Msg)); match != nil {
+		class, subject, detail = match[1], match[2], match[3]
+		code += " " + class + "." + subject + "." + detail
+	}
+
+	kind := ErrorTransient
+	switch {
+	case protoErr.Code == 530 || protoErr.Code == 534 || protoErr.Code == 535 || protoErr.Code == 538,
+		subject == "7" && detail == "8":
+		kind = ErrorAuth
+	case protoErr.Code >= 500 && subject == "1" && (detail == "7" || detail == "8"):
+		kind = ErrorPermanent
+	case protoErr.Code >= 500 && subject == "1" && stage == smtpStageRcpt:
+		kind = ErrorInvalidRecipient
+	case protoErr.Code >= 500 && subject == "1" && stage == smtpStageMail:
+		kind = ErrorAuth
+	case protoErr.Code >= 500:
+		kind = ErrorPermanent
+	}
 
-	// Se houver anexo, usar multipart
-	if email.Attachment != nil {
-		boundary := fmt.Sprintf("boundary-%d", time.Now().Unix())
-		msg.WriteString(fmt.Sprintf("Content-Type: multipart/mixed; boundary=\"%s\"\r\n", boundary))
-		msg.WriteString("\r\n")
+	return NewSendError(kind, provider, code, message, err)
+}
+
+// credentials retorna usuário e senha SMTP, priorizando os da identidade do remetente
+func (s *SMTPProvider) credentials(email EmailData) (string, string) {
+	if username := email.Setting(s.GetName(), "username"); username != "" {
+		return username, email.Setting(s.GetName(), "password")
+	}
+	return s.config.Username, s.config.Password
+}
 
-		// Parte do corpo
-		msg.WriteString(fmt.Sprintf("--%s\r\n", boundary))
-		msg.WriteString(fmt.Sprintf("Content-Type: %s; charset=\"UTF-8\"\r\n", email.ContentType))
-		msg.WriteString("\r\n")
-		msg.WriteString(email.Body)
-		msg.WriteString("\r\n")
+// dkimSigner retorna o assinador DKIM da mensagem ou nil se DKIM não estiver
+// configurado. Configurações da identidade do remetente (smtp.dkim_*) têm
+// precedência sobre as globais.
+func (s *SMTPProvider) dkimSigner(email EmailData) (*DKIMSigner, error) {
+	key := s.dkimKey
+	if keyFile := email.Setting(s.GetName(), "dkim_private_key_file"); keyFile != "" {
+		var err error
+		if key, err = s.identityDKIMKey(keyFile); err != nil {
+			return nil, err
+		}
+	}
+	if key == nil {
+		return nil, nil
+	}
 
-		// Parte do anexo
-		msg.WriteString(fmt.Sprintf("--%s\r\n", boundary))
-		msg.WriteString(fmt.Sprintf("Content-Type: %s; name=\"%s\"\r\n", email.Attachment.ContentType, email.Attachment.Filename))
-		msg.WriteString("Content-Transfer-Encoding: base64\r\n")
-		msg.WriteString(fmt.Sprintf("Content-Disposition: attachment; filename=\"%s\"\r\n", email.Attachment.Filename))
-		msg.WriteString("\r\n")
+	selector := s.config.DKIMSelector
+	if value := email.Setting(s.GetName(), "dkim_selector"); value != "" {
+		selector = value
+	}
 
-		// Ler e codificar anexo (simplificado - em produção use base64)
-		if email.Attachment.Data != nil {
-			data, _ := io.ReadAll(email.Attachment.Data)
-			msg.WriteString(string(data))
+	domain := s.config.DKIMDomain
+	if value := email.Setting(s.GetName(), "dkim_domain"); value != "" {
+		domain = value
+	}
+	if domain == "" {
+		if at := strings.LastIndex(email.From, "@"); at >= 0 {
+			domain = strings.ToLower(email.From[at+1:])
 		}
-		msg.WriteString("\r\n")
+	}
+
+	return newDKIMSigner(domain, selector, key)
+}
+
+// identityDKIMKey retorna a chave DKIM de uma identidade do remetente,
+// carregando o arquivo na primeira mensagem que a utiliza
+func (s *SMTPProvider) identityDKIMKey(keyFile string) (*dkimKey, error) {
+	s.dkimMu.Lock()
+	defer s.dkimMu.Unlock()
 
-		msg.WriteString(fmt.Sprintf("--%s--\r\n", boundary))
-	} else {
-		// Sem anexo - mensagem simples
-		msg.WriteString(fmt.Sprintf("Content-Type: %s; charset=\"UTF-8\"\r\n", email.ContentType))
-		msg.WriteString("\r\n")
-		msg.WriteString(email.Body)
+	if key, ok := s.dkimKeys[keyFile]; ok {
+		return key, nil
 	}
 
-	return msg.String()
+	key, err := loadDKIMKey(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	s.dkimKeys[keyFile] = key
+
+	s.logger.Info("Chave DKIM da identidade carregada",
+		zap.String("algorithm", key.algorithm),
+		zap.String("key_file", keyFile))
+
+	return key, nil
+}
+
+// getAuth retorna o mecanismo de autenticação
+func (s *SMTPProvider) getAuth(username, password string) smtp.Auth {
+	if username == "" || password == "" {
+		return nil
+	}
+	return smtp.PlainAuth("", username, password, s.config.Host)
 }
 
 // GetName retorna o nome do provider
@@ -0,0 +1,393 @@
+package email
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// smtpStandIn servidor SMTP mínimo para os testes do provider
+type smtpStandIn struct {
+	listener  net.Listener
+	tlsConfig *tls.Config // nil = não oferece STARTTLS
+
+	// holdData, se definido, deixa o DATA sem resposta até ser fechado;
+	// dataReceived é sinalizado quando a mensagem termina de chegar
+	holdData     chan struct{}
+	dataReceived chan struct{}
+
+	mu          sync.Mutex
+	connections int
+	tlsSessions int
+	noops       int
+	messages    []string
+}
+
+func newSMTPStandIn(t *testing.T, tlsConfig *tls.Config) *smtpStandIn {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("erro ao abrir listener: %v", err)
+	}
+	server := &smtpStandIn{listener: listener, tlsConfig: tlsConfig}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			server.mu.Lock()
+			server.connections++
+			server.mu.Unlock()
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+// provider cria um SMTPProvider apontado para o servidor
+func (s *smtpStandIn) provider(t *testing.T, config SMTPConfig) *SMTPProvider {
+	t.Helper()
+
+	addr := s.listener.Addr().(*net.TCPAddr)
+	config.Host = "127.0.0.1"
+	config.Port = addr.Port
+	config.Username = "usuario"
+	config.Password = "senha"
+
+	provider, err := NewSMTPProvider(config, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewSMTPProvider: %v", err)
+	}
+	t.Cleanup(func() { provider.Close() })
+	return provider
+}
+
+func (s *smtpStandIn) serve(conn net.Conn) {
+	defer conn.Close()
+
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 standin ESMTP")
+
+	secure := false
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			tp.PrintfLine("250-standin")
+			if s.tlsConfig != nil && !secure {
+				tp.PrintfLine("250-STARTTLS")
+			}
+			tp.PrintfLine("250 AUTH PLAIN")
+		case "STARTTLS":
+			tp.PrintfLine("220 2.0.0 Ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			tp = textproto.NewConn(tlsConn)
+			secure = true
+			s.mu.Lock()
+			s.tlsSessions++
+			s.mu.Unlock()
+		case "AUTH":
+			if arg == "PLAIN "+base64.StdEncoding.EncodeToString([]byte("\x00usuario\x00senha")) {
+				tp.PrintfLine("235 2.7.0 Authentication successful")
+			} else {
+				tp.PrintfLine("535 5.7.8 Authentication credentials invalid")
+			}
+		case "MAIL":
+			if strings.Contains(arg, "naoautorizado") {
+				tp.PrintfLine("550 5.1.0 Sender address rejected: not owned by user")
+			} else {
+				tp.PrintfLine("250 2.1.0 Ok")
+			}
+		case "RCPT":
+			if strings.Contains(arg, "inexistente") {
+				tp.PrintfLine("550 5.1.1 User unknown")
+			} else {
+				tp.PrintfLine("250 2.1.5 Ok")
+			}
+		case "DATA":
+			tp.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, string(data))
+			s.mu.Unlock()
+			if s.dataReceived != nil {
+				s.dataReceived <- struct{}{}
+			}
+			if s.holdData != nil {
+				<-s.holdData
+				return
+			}
+			tp.PrintfLine("250 2.0.0 Ok: queued")
+		case "NOOP":
+			s.mu.Lock()
+			s.noops++
+			s.mu.Unlock()
+			tp.PrintfLine("250 2.0.0 Ok")
+		case "RSET":
+			tp.PrintfLine("250 2.0.0 Ok")
+		case "QUIT":
+			tp.PrintfLine("221 2.0.0 Bye")
+			return
+		default:
+			tp.PrintfLine("502 5.5.2 Command not recognized")
+		}
+	}
+}
+
+// stats retorna conexões, sessões TLS, NOOPs e mensagens recebidas
+func (s *smtpStandIn) stats() (connections, tlsSessions, noops int, messages []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.connections, s.tlsSessions, s.noops, append([]string(nil), s.messages...)
+}
+
+// smtpTestTLS gera um certificado autoassinado para 127.0.0.1 e retorna as
+// configurações TLS do servidor e do cliente (que confia no certificado)
+func smtpTestTLS(t *testing.T) (*tls.Config, *tls.Config) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("erro ao gerar chave: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "standin"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("erro ao criar certificado: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("erro ao ler certificado: %v", err)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	server := &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	return server, &tls.Config{RootCAs: roots}
+}
+
+func smtpTestEmail() EmailData {
+	return EmailData{
+		From:        "noreply@exemplo.com.br",
+		To:          "cliente@destino.com",
+		Subject:     "Teste",
+		Body:        "Corpo da mensagem",
+		ContentType: "text/plain",
+	}
+}
+
+func TestSMTPProviderSecurityModes(t *testing.T) {
+	serverTLS, clientTLS := smtpTestTLS(t)
+
+	tests := []struct {
+		security  string
+		serverTLS *tls.Config
+		clientTLS *tls.Config
+		wantTLS   int
+	}{
+		{SMTPSecurityNone, nil, nil, 0},
+		{SMTPSecurityStartTLS, serverTLS, clientTLS, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.security, func(t *testing.T) {
+			server := newSMTPStandIn(t, tt.serverTLS)
+			provider := server.provider(t, SMTPConfig{Security: tt.security, TLSConfig: tt.clientTLS})
+
+			result, err := provider.Send(context.Background(), smtpTestEmail())
+			if err != nil {
+				t.Fatalf("Send: %v", err)
+			}
+			if !result.Success || !strings.HasSuffix(result.ProviderID, "@exemplo.com.br") {
+				t.Errorf("resultado inesperado: %+v", result)
+			}
+
+			_, tlsSessions, _, messages := server.stats()
+			if tlsSessions != tt.wantTLS {
+				t.Errorf("sessões TLS = %d, esperado %d", tlsSessions, tt.wantTLS)
+			}
+			if len(messages) != 1 || !strings.Contains(messages[0], "Corpo da mensagem") {
+				t.Errorf("mensagens recebidas = %q", messages)
+			}
+		})
+	}
+}
+
+func TestSMTPProviderStartTLSRequired(t *testing.T) {
+	server := newSMTPStandIn(t, nil)
+	provider := server.provider(t, SMTPConfig{Security: SMTPSecurityStartTLS})
+
+	if _, err := provider.Send(context.Background(), smtpTestEmail()); err == nil {
+		t.Fatal("Send deveria falhar quando o servidor não oferece STARTTLS")
+	}
+	if _, _, _, messages := server.stats(); len(messages) != 0 {
+		t.Errorf("mensagem não deveria ser enviada sem TLS: %q", messages)
+	}
+}
+
+func TestSMTPProviderPoolReuse(t *testing.T) {
+	server := newSMTPStandIn(t, nil)
+	provider := server.provider(t, SMTPConfig{Security: SMTPSecurityNone, PoolSize: 1})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := provider.Send(ctx, smtpTestEmail()); err != nil {
+			t.Fatalf("Send %d: %v", i+1, err)
+		}
+	}
+
+	// Rejeição do destinatário mantém a conexão no pool (RSET)
+	invalid := smtpTestEmail()
+	invalid.To = "inexistente@destino.com"
+	_, err := provider.Send(ctx, invalid)
+	if ErrorKindOf(err) != ErrorInvalidRecipient {
+		t.Errorf("erro = %v, esperado destinatário inválido", err)
+	}
+
+	if _, err := provider.Send(ctx, smtpTestEmail()); err != nil {
+		t.Fatalf("Send após rejeição: %v", err)
+	}
+
+	connections, _, noops, messages := server.stats()
+	if connections != 1 {
+		t.Errorf("conexões = %d, esperado 1 (reuso do pool)", connections)
+	}
+	if noops != 3 {
+		t.Errorf("NOOPs = %d, esperado 3 (verificação a cada reuso)", noops)
+	}
+	if len(messages) != 3 {
+		t.Errorf("mensagens = %d, esperado 3", len(messages))
+	}
+}
+
+func TestSMTPProviderRejectionStage(t *testing.T) {
+	server := newSMTPStandIn(t, nil)
+	provider := server.provider(t, SMTPConfig{Security: SMTPSecurityNone})
+
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		wantKind ErrorKind
+	}{
+		// Mesmo código estendido 5.1.x: o comando rejeitado define a classificação
+		{"destinatário recusado no RCPT TO", "noreply@exemplo.com.br", "inexistente@destino.com", ErrorInvalidRecipient},
+		{"remetente recusado no MAIL FROM", "naoautorizado@exemplo.com.br", "cliente@destino.com", ErrorAuth},
+	}
+
+	for _, tt := range tests {
+		message := smtpTestEmail()
+		message.From, message.To = tt.from, tt.to
+		_, err := provider.Send(context.Background(), message)
+		if kind := ErrorKindOf(err); kind != tt.wantKind {
+			t.Errorf("%s: classificação %v (%v), esperado %v", tt.name, kind, err, tt.wantKind)
+		}
+	}
+}
+
+func TestSMTPProviderCancelDuringData(t *testing.T) {
+	server := newSMTPStandIn(t, nil)
+	server.holdData = make(chan struct{})
+	server.dataReceived = make(chan struct{}, 1)
+	defer close(server.holdData)
+
+	provider := server.provider(t, SMTPConfig{Security: SMTPSecurityNone, PoolSize: 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-server.dataReceived
+		cancel()
+	}()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := provider.Send(ctx, smtpTestEmail())
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("erro = %v, esperado context.Canceled", err)
+		}
+		if ErrorKindOf(err) != ErrorTransient {
+			t.Errorf("classificação = %v, esperado temporário", ErrorKindOf(err))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Send não foi interrompido pelo cancelamento do contexto")
+	}
+
+	// A conexão interrompida não volta ao pool
+	if idle := provider.popIdle("usuario\x00senha"); idle != nil {
+		t.Error("conexão interrompida não deveria voltar ao pool")
+	}
+}
+
+func TestClassifySMTPError(t *testing.T) {
+	tests := []struct {
+		stage    smtpStage
+		err      error
+		wantKind ErrorKind
+		wantCode string
+	}{
+		{smtpStageConnect, &textproto.Error{Code: 535, Msg: "5.7.8 Authentication credentials invalid"}, ErrorAuth, "535 5.7.8"},
+		{smtpStageRcpt, &textproto.Error{Code: 550, Msg: "5.1.1 User unknown"}, ErrorInvalidRecipient, "550 5.1.1"},
+		{smtpStageData, &textproto.Error{Code: 552, Msg: "5.3.4 Message size exceeds fixed limit"}, ErrorPermanent, "552 5.3.4"},
+		{smtpStageRcpt, &textproto.Error{Code: 421, Msg: "4.7.0 Try again later"}, ErrorTransient, "421 4.7.0"},
+		{smtpStageMail, &textproto.Error{Code: 553, Msg: "5.1.8 Bad sender address"}, ErrorPermanent, "553 5.1.8"},
+		{smtpStageRcpt, &textproto.Error{Code: 553, Msg: "5.1.8 Bad sender address"}, ErrorPermanent, "553 5.1.8"},
+		// 5.1.x fora do RCPT TO não trata do destinatário
+		{smtpStageMail, &textproto.Error{Code: 550, Msg: "5.1.0 Sender address rejected: not owned by user"}, ErrorAuth, "550 5.1.0"},
+		{smtpStageData, &textproto.Error{Code: 550, Msg: "5.1.1 Rejected by content filter"}, ErrorPermanent, "550 5.1.1"},
+		{smtpStageConnect, &textproto.Error{Code: 554, Msg: "5.1.0 Relay access denied"}, ErrorPermanent, "554 5.1.0"},
+		{smtpStageMail, errors.New("connection reset by peer"), ErrorTransient, ""},
+	}
+
+	for _, tt := range tests {
+		sendErr := classifySMTPError("SMTP", "erro SMTP", tt.stage, tt.err)
+		if sendErr.Kind != tt.wantKind || sendErr.Code != tt.wantCode {
+			t.Errorf("etapa %d, %v: classificação %v/%q, esperado %v/%q",
+				tt.stage, tt.err, sendErr.Kind, sendErr.Code, tt.wantKind, tt.wantCode)
+		}
+	}
+}
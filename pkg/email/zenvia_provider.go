@@ -10,6 +10,7 @@ import (
 	"regexp"
 	"time"
 
+	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/config"
 	"go.uber.org/zap"
 )
 
@@ -68,6 +69,22 @@ type ZenviaErrorResponse struct {
 	} `json:"details,omitempty"`
 }
 
+func init() {
+	RegisterProvider(ProviderSpec{
+		Name:        "zenvia",
+		Code:        4096,
+		DisplayName: "🇧🇷 Zenvia",
+		Attachments: AttachmentsURL,
+		Config: []ConfigField{
+			{Key: "zenvia_api_token", Description: "Token da API (pode ser definido por identidade em zenvia.api_token)"},
+			{Key: "zenvia_callback_token", Description: "Token do webhook de status no dashboard (opcional)"},
+		},
+		Factory: func(cfg *config.EmailConfig, logger *zap.Logger) (Provider, error) {
+			return NewZenviaProvider(cfg.ZenviaAPIToken, logger), nil
+		},
+	})
+}
+
 // NewZenviaProvider cria um novo provider Zenvia
 func NewZenviaProvider(apiToken string, logger *zap.Logger) *ZenviaProvider {
 	return &ZenviaProvider{
@@ -121,10 +138,11 @@ func (z *ZenviaProvider) Send(ctx context.Context, email EmailData) (SendResult,
 				zap.Int("html_length", newLength),
 				zap.Int("max_length", zenviaMaxHTMLLength))
 
+			sendErr := NewSendError(ErrorPermanent, z.GetName(), "", errorMsg, nil)
 			return SendResult{
 				Success: false,
-				Error:   fmt.Errorf(errorMsg),
-			}, fmt.Errorf(errorMsg)
+				Error:   sendErr,
+			}, sendErr
 		}
 	}
 
@@ -170,10 +188,11 @@ func (z *ZenviaProvider) Send(ctx context.Context, email EmailData) (SendResult,
 	if err != nil {
 		z.logger.Error("Erro ao serializar requisição Zenvia",
 			zap.Error(err))
+		sendErr := NewSendError(ErrorPermanent, z.GetName(), "", "erro ao serializar requisição", err)
 		return SendResult{
 			Success: false,
-			Error:   fmt.Errorf("erro ao serializar requisição: %w", err),
-		}, err
+			Error:   sendErr,
+		}, sendErr
 	}
 
 	// Logar JSON enviado para debug
@@ -185,25 +204,32 @@ func (z *ZenviaProvider) Send(ctx context.Context, email EmailData) (SendResult,
 	if err != nil {
 		z.logger.Error("Erro ao criar requisição HTTP",
 			zap.Error(err))
+		sendErr := NewSendError(ErrorTransient, z.GetName(), "", "erro ao criar requisição HTTP", err)
 		return SendResult{
 			Success: false,
-			Error:   fmt.Errorf("erro ao criar requisição HTTP: %w", err),
-		}, err
+			Error:   sendErr,
+		}, sendErr
 	}
 
 	// Configurar headers
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("X-API-TOKEN", z.apiToken)
+	// Token da identidade do remetente tem precedência
+	apiToken := z.apiToken
+	if identityToken := email.Setting(z.GetName(), "api_token"); identityToken != "" {
+		apiToken = identityToken
+	}
+	httpReq.Header.Set("X-API-TOKEN", apiToken)
 
 	// Enviar requisição
 	resp, err := z.httpClient.Do(httpReq)
 	if err != nil {
 		z.logger.Error("Erro ao enviar requisição para Zenvia",
 			zap.Error(err))
+		sendErr := NewSendError(ErrorTransient, z.GetName(), "", "erro ao enviar requisição", err)
 		return SendResult{
 			Success: false,
-			Error:   fmt.Errorf("erro ao enviar requisição: %w", err),
-		}, err
+			Error:   sendErr,
+		}, sendErr
 	}
 	defer resp.Body.Close()
 
@@ -213,10 +239,11 @@ func (z *ZenviaProvider) Send(ctx context.Context, email EmailData) (SendResult,
 		z.logger.Error("Erro ao ler resposta da Zenvia",
 			zap.Error(err),
 			zap.Int("status_code", resp.StatusCode))
+		sendErr := NewSendError(ErrorTransient, z.GetName(), "", "erro ao ler resposta", err)
 		return SendResult{
 			Success: false,
-			Error:   fmt.Errorf("erro ao ler resposta: %w", err),
-		}, err
+			Error:   sendErr,
+		}, sendErr
 	}
 
 	// Verificar status code
@@ -229,13 +256,17 @@ func (z *ZenviaProvider) Send(ctx context.Context, email EmailData) (SendResult,
 				zap.String("error_code", errorResp.Code),
 				zap.String("error_message", errorResp.Message))
 
-			errorMsg := fmt.Sprintf("Zenvia API error: %s - %s", errorResp.Code, errorResp.Message)
+			sendErr := httpStatusError(z.GetName(), resp.StatusCode, errorResp.Message)
+			if errorResp.Code != "" {
+				sendErr.Code = errorResp.Code
+			}
 
 			// Verificar se é erro de email inválido
 			if errorResp.Code == "VALIDATION_ERROR" {
 				for _, detail := range errorResp.Details {
 					if detail.Path == "to" {
-						errorMsg = "email inválido: " + detail.Message
+						sendErr.Kind = ErrorInvalidRecipient
+						sendErr.Message = detail.Message
 						break
 					}
 				}
@@ -243,20 +274,21 @@ func (z *ZenviaProvider) Send(ctx context.Context, email EmailData) (SendResult,
 
 			return SendResult{
 				Success: false,
-				Error:   fmt.Errorf(errorMsg),
-			}, fmt.Errorf(errorMsg)
+				Error:   sendErr,
+			}, sendErr
 		}
 
 		// Erro genérico
-		errorMsg := fmt.Sprintf("Zenvia API returned status %d: %s", resp.StatusCode, string(body))
+		errorMsg := string(body)
 		z.logger.Error("Erro na resposta da Zenvia",
 			zap.Int("status_code", resp.StatusCode),
 			zap.String("body", string(body)))
 
+		sendErr := httpStatusError(z.GetName(), resp.StatusCode, errorMsg)
 		return SendResult{
 			Success: false,
-			Error:   fmt.Errorf(errorMsg),
-		}, fmt.Errorf(errorMsg)
+			Error:   sendErr,
+		}, sendErr
 	}
 
 	// Parsear resposta de sucesso
@@ -265,10 +297,11 @@ func (z *ZenviaProvider) Send(ctx context.Context, email EmailData) (SendResult,
 		z.logger.Error("Erro ao parsear resposta de sucesso da Zenvia",
 			zap.Error(err),
 			zap.String("body", string(body)))
+		sendErr := NewSendError(ErrorTransient, z.GetName(), "", "erro ao parsear resposta", err)
 		return SendResult{
 			Success: false,
-			Error:   fmt.Errorf("erro ao parsear resposta: %w", err),
-		}, err
+			Error:   sendErr,
+		}, sendErr
 	}
 
 	z.logger.Info("Email enviado via Zenvia com sucesso",
@@ -0,0 +1,198 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const zenviaSubscriptionsAPIURL = "https://api.zenvia.com/v2/subscriptions"
+
+// zenviaDeliveryStatus mapeia o messageStatus.code do webhook para o tipo de evento
+var zenviaDeliveryStatus = map[string]DeliveryEventType{
+	"SENT":          DeliverySent,
+	"DELIVERED":     DeliveryDelivered,
+	"NOT_DELIVERED": DeliveryBounced,
+	"READ":          DeliveryOpened,
+	"CLICKED":       DeliveryClicked,
+	"REJECTED":      DeliveryDropped,
+}
+
+// ZenviaStatusEvent evento MESSAGE_STATUS enviado ao webhook da assinatura
+type ZenviaStatusEvent struct {
+	ID             string `json:"id"`
+	Timestamp      string `json:"timestamp"`
+	Type           string `json:"type"`
+	SubscriptionID string `json:"subscriptionId"`
+	Channel        string `json:"channel"`
+	MessageID      string `json:"messageId"`
+	MessageStatus  struct {
+		Timestamp   string `json:"timestamp"`
+		Code        string `json:"code"`
+		Description string `json:"description"`
+		Causes      []struct {
+			ChannelErrorCode string `json:"channelErrorCode"`
+			Reason           string `json:"reason"`
+			Details          string `json:"details"`
+		} `json:"causes"`
+	} `json:"messageStatus"`
+}
+
+// ParseZenviaStatusEvent interpreta o corpo do webhook MESSAGE_STATUS da Zenvia.
+// O email é localizado pelo messageId (ID_PROVIDER gravado no envio). Eventos
+// de outros tipos ou canais não geram eventos de entrega.
+func ParseZenviaStatusEvent(body []byte) ([]DeliveryEvent, error) {
+	var statusEvent ZenviaStatusEvent
+	if err := json.Unmarshal(body, &statusEvent); err != nil {
+		return nil, fmt.Errorf("webhook Zenvia inválido: %w", err)
+	}
+	if statusEvent.Type != "MESSAGE_STATUS" || !strings.EqualFold(statusEvent.Channel, "email") {
+		return nil, nil
+	}
+	if statusEvent.MessageID == "" {
+		return nil, fmt.Errorf("webhook Zenvia sem messageId")
+	}
+
+	status := statusEvent.MessageStatus
+	detail := status.Description
+	for _, cause := range status.Causes {
+		detail = strings.TrimSpace(strings.Join([]string{detail, cause.ChannelErrorCode, cause.Reason, cause.Details}, " "))
+	}
+
+	timestamp := status.Timestamp
+	if timestamp == "" {
+		timestamp = statusEvent.Timestamp
+	}
+
+	return []DeliveryEvent{{
+		Provider:   "zenvia",
+		ProviderID: statusEvent.MessageID,
+		Type:       zenviaDeliveryStatus[strings.ToUpper(status.Code)],
+		Status:     status.Code,
+		Detail:     detail,
+		Timestamp:  parseCallbackTime(timestamp),
+	}}, nil
+}
+
+// ZenviaSubscription assinatura de webhook da Zenvia
+type ZenviaSubscription struct {
+	ID        string                     `json:"id,omitempty"`
+	EventType string                     `json:"eventType"`
+	Webhook   ZenviaWebhook              `json:"webhook"`
+	Criteria  ZenviaSubscriptionCriteria `json:"criteria"`
+	Status    string                     `json:"status,omitempty"`
+	CreatedAt string                     `json:"createdAt,omitempty"`
+}
+
+// ZenviaWebhook destino dos eventos da assinatura
+type ZenviaWebhook struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// ZenviaSubscriptionCriteria filtro de eventos da assinatura
+type ZenviaSubscriptionCriteria struct {
+	Channel string `json:"channel"`
+}
+
+// ZenviaSubscriptionClient cria e lista assinaturas de webhook na API da Zenvia
+type ZenviaSubscriptionClient struct {
+	apiToken   string
+	apiURL     string
+	httpClient *http.Client
+}
+
+// NewZenviaSubscriptionClient cria o cliente de assinaturas (apiURL vazio = API
+// da Zenvia)
+func NewZenviaSubscriptionClient(apiToken, apiURL string) *ZenviaSubscriptionClient {
+	if apiURL == "" {
+		apiURL = zenviaSubscriptionsAPIURL
+	}
+	return &ZenviaSubscriptionClient{
+		apiToken: apiToken,
+		apiURL:   strings.TrimRight(apiURL, "/"),
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// List retorna as assinaturas da conta
+func (c *ZenviaSubscriptionClient) List(ctx context.Context) ([]ZenviaSubscription, error) {
+	var subscriptions []ZenviaSubscription
+	if err := c.do(ctx, http.MethodGet, nil, &subscriptions); err != nil {
+		return nil, fmt.Errorf("erro ao listar assinaturas Zenvia: %w", err)
+	}
+	return subscriptions, nil
+}
+
+// CreateStatusWebhook cria a assinatura MESSAGE_STATUS do canal de e-mail para
+// a URL informada. O token, se informado, vai no header X-Callback-Token.
+func (c *ZenviaSubscriptionClient) CreateStatusWebhook(ctx context.Context, url, token string) (*ZenviaSubscription, error) {
+	subscription := ZenviaSubscription{
+		EventType: "MESSAGE_STATUS",
+		Webhook:   ZenviaWebhook{URL: url},
+		Criteria:  ZenviaSubscriptionCriteria{Channel: "email"},
+		Status:    "ACTIVE",
+	}
+	if token != "" {
+		subscription.Webhook.Headers = map[string]string{"X-Callback-Token": token}
+	}
+
+	var created ZenviaSubscription
+	if err := c.do(ctx, http.MethodPost, subscription, &created); err != nil {
+		return nil, fmt.Errorf("erro ao criar assinatura Zenvia: %w", err)
+	}
+	return &created, nil
+}
+
+// do executa a requisição na API de assinaturas e decodifica a resposta
+func (c *ZenviaSubscriptionClient) do(ctx context.Context, method string, payload, result interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("erro ao serializar requisição: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.apiURL, body)
+	if err != nil {
+		return fmt.Errorf("erro ao criar requisição: %w", err)
+	}
+	req.Header.Set("X-API-TOKEN", c.apiToken)
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("erro ao enviar requisição: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("erro ao ler resposta: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var errorResp ZenviaErrorResponse
+		if err := json.Unmarshal(respBody, &errorResp); err == nil && errorResp.Message != "" {
+			return fmt.Errorf("status %d: %s: %s", resp.StatusCode, errorResp.Code, errorResp.Message)
+		}
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	if err := json.Unmarshal(respBody, result); err != nil {
+		return fmt.Errorf("erro ao parsear resposta: %w", err)
+	}
+	return nil
+}
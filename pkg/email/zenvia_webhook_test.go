@@ -0,0 +1,122 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// zenviaStandIn simula a API de assinaturas da Zenvia
+func zenviaStandIn(t *testing.T, subscriptions *[]ZenviaSubscription) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-TOKEN") != "token-api" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"code":"UNAUTHORIZED","message":"Invalid token"}`))
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			json.NewEncoder(w).Encode(subscriptions)
+		case http.MethodPost:
+			var subscription ZenviaSubscription
+			if err := json.NewDecoder(r.Body).Decode(&subscription); err != nil {
+				t.Errorf("corpo da requisição inválido: %v", err)
+			}
+			subscription.ID = "sub-1"
+			*subscriptions = append(*subscriptions, subscription)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(subscription)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+}
+
+func TestZenviaSubscriptionClientCreateAndList(t *testing.T) {
+	var subscriptions []ZenviaSubscription
+	server := zenviaStandIn(t, &subscriptions)
+	defer server.Close()
+
+	client := NewZenviaSubscriptionClient("token-api", server.URL)
+	ctx := context.Background()
+
+	created, err := client.CreateStatusWebhook(ctx, "https://crm.exemplo.com/api/callback/zenvia", "segredo")
+	if err != nil {
+		t.Fatalf("CreateStatusWebhook: %v", err)
+	}
+	if created.ID != "sub-1" {
+		t.Errorf("ID = %q, esperado sub-1", created.ID)
+	}
+	if created.EventType != "MESSAGE_STATUS" || created.Criteria.Channel != "email" || created.Status != "ACTIVE" {
+		t.Errorf("assinatura criada inesperada: %+v", created)
+	}
+	if created.Webhook.Headers["X-Callback-Token"] != "segredo" {
+		t.Errorf("header X-Callback-Token = %q, esperado segredo", created.Webhook.Headers["X-Callback-Token"])
+	}
+
+	listed, err := client.List(ctx)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(listed) != 1 || listed[0].Webhook.URL != "https://crm.exemplo.com/api/callback/zenvia" {
+		t.Errorf("List = %+v, esperada a assinatura criada", listed)
+	}
+}
+
+func TestZenviaSubscriptionClientError(t *testing.T) {
+	var subscriptions []ZenviaSubscription
+	server := zenviaStandIn(t, &subscriptions)
+	defer server.Close()
+
+	client := NewZenviaSubscriptionClient("token-errado", server.URL)
+	_, err := client.List(context.Background())
+	if err == nil {
+		t.Fatal("List com token inválido deveria falhar")
+	}
+	if !strings.Contains(err.Error(), "401") || !strings.Contains(err.Error(), "Invalid token") {
+		t.Errorf("erro = %v, esperado status 401 e mensagem da API", err)
+	}
+}
+
+func TestParseZenviaStatusEvent(t *testing.T) {
+	tests := []struct {
+		code string
+		want DeliveryEventType
+	}{
+		{"SENT", DeliverySent},
+		{"DELIVERED", DeliveryDelivered},
+		{"NOT_DELIVERED", DeliveryBounced},
+		{"READ", DeliveryOpened},
+	}
+
+	for _, tt := range tests {
+		body := `{"id":"evt","type":"MESSAGE_STATUS","channel":"email","messageId":"msg-123",
+			"messageStatus":{"timestamp":"2025-12-15T10:00:00.000Z","code":"` + tt.code + `",
+			"description":"desc","causes":[{"reason":"mailbox unavailable"}]}}`
+
+		events, err := ParseZenviaStatusEvent([]byte(body))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.code, err)
+		}
+		if len(events) != 1 {
+			t.Fatalf("%s: %d eventos, esperado 1", tt.code, len(events))
+		}
+		event := events[0]
+		if event.Type != tt.want || event.ProviderID != "msg-123" || event.Provider != "zenvia" {
+			t.Errorf("%s: evento inesperado %+v", tt.code, event)
+		}
+		if event.Timestamp.IsZero() || !strings.Contains(event.Detail, "mailbox unavailable") {
+			t.Errorf("%s: data/detalhes não lidos: %+v", tt.code, event)
+		}
+	}
+
+	events, err := ParseZenviaStatusEvent([]byte(`{"type":"MESSAGE","channel":"email","messageId":"x"}`))
+	if err != nil || len(events) != 0 {
+		t.Errorf("evento MESSAGE deveria ser ignorado: %v %v", events, err)
+	}
+}
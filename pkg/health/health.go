@@ -8,25 +8,35 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/email"
 	"go.uber.org/zap"
 )
 
 type HealthStatus struct {
-	Status    string    `json:"status"`
-	Timestamp time.Time `json:"timestamp"`
-	Database  string    `json:"database"`
-	Uptime    string    `json:"uptime"`
+	Status    string                       `json:"status"`
+	Timestamp time.Time                    `json:"timestamp"`
+	Database  string                       `json:"database"`
+	Uptime    string                       `json:"uptime"`
+	Providers []email.ProviderCircuitState `json:"providers,omitempty"`
+}
+
+// ProviderCircuitSource interface para consultar o circuit breaker dos providers
+type ProviderCircuitSource interface {
+	CircuitStates() []email.ProviderCircuitState
 }
 
 type HealthChecker struct {
 	db        *sql.DB
+	circuits  ProviderCircuitSource
 	logger    *zap.Logger
 	startTime time.Time
 }
 
-func NewHealthChecker(db *sql.DB, logger *zap.Logger) *HealthChecker {
+// NewHealthChecker cria o health checker. circuits pode ser nil.
+func NewHealthChecker(db *sql.DB, circuits ProviderCircuitSource, logger *zap.Logger) *HealthChecker {
 	return &HealthChecker{
 		db:        db,
+		circuits:  circuits,
 		logger:    logger,
 		startTime: time.Now(),
 	}
@@ -36,7 +46,7 @@ func (h *HealthChecker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	status := h.Check()
 
 	w.Header().Set("Content-Type", "application/json")
-	if status.Status != "healthy" {
+	if status.Status == "unhealthy" {
 		w.WriteHeader(http.StatusServiceUnavailable)
 	}
 
@@ -64,6 +74,26 @@ func (h *HealthChecker) Check() HealthStatus {
 		}
 	}
 
+	// Verificar circuit breaker dos providers: "degraded" se algum estiver
+	// aberto, "unhealthy" se todos estiverem (nenhum envio é possível)
+	if h.circuits != nil {
+		status.Providers = h.circuits.CircuitStates()
+
+		open := 0
+		for _, provider := range status.Providers {
+			if provider.State == email.CircuitOpen {
+				open++
+			}
+		}
+
+		if open > 0 && status.Status == "healthy" {
+			status.Status = "degraded"
+		}
+		if len(status.Providers) > 0 && open == len(status.Providers) {
+			status.Status = "unhealthy"
+		}
+	}
+
 	return status
 }
 
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/cliente"
+	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/email"
 	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/message"
 	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/template"
 	"go.uber.org/zap"
@@ -23,10 +24,11 @@ type Handler struct {
 	macroProcessor *template.MacroProcessor
 	logger         *zap.Logger
 	providerName   string // Nome do provider configurado (mock, smtp, sendgrid, zenvia, pontaltech)
+	defaultFrom    string // Remetente padrão (default_from) gravado em REMETENTE
 }
 
 // NewHandler cria uma nova instância do handler
-func NewHandler(clienteRepo *cliente.Repository, emailRepo *message.Repository, templateRepo *template.Repository, macroProcessor *template.MacroProcessor, providerName string) *Handler {
+func NewHandler(clienteRepo *cliente.Repository, emailRepo *message.Repository, templateRepo *template.Repository, macroProcessor *template.MacroProcessor, providerName, defaultFrom string) *Handler {
 	logger, _ := zap.NewProduction()
 	return &Handler{
 		clienteRepo:    clienteRepo,
@@ -35,6 +37,7 @@ func NewHandler(clienteRepo *cliente.Repository, emailRepo *message.Repository,
 		macroProcessor: macroProcessor,
 		logger:         logger,
 		providerName:   providerName,
+		defaultFrom:    defaultFrom,
 	}
 }
 
@@ -87,6 +90,17 @@ type StatusEmailResponse struct {
 	Tentativas int    `json:"tentativas,omitempty"`
 	ErroMsg    string `json:"erroMsg,omitempty"`
 	IDProvedor string `json:"idProvedor,omitempty"`
+	Eventos    []EventoStatus `json:"eventos,omitempty"` // Linha do tempo (EVENTOEMAIL)
+}
+
+// EventoStatus é um evento da linha do tempo do e-mail
+type EventoStatus struct {
+	Tipo      string `json:"tipo"`
+	Descricao string `json:"descricao"`
+	Data      string `json:"data"`
+	Provider  string `json:"provider,omitempty"`
+	Codigo    string `json:"codigo,omitempty"`
+	Detalhes  string `json:"detalhes,omitempty"`
 }
 
 // ServeHTTP serve a página HTML de disparo manual
@@ -98,8 +112,11 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 // ProviderInfoResponse é a resposta com informações do provider
 type ProviderInfoResponse struct {
-	ProviderName string `json:"providerName"`
-	Status       string `json:"status"`
+	ProviderName   string `json:"providerName"`
+	DisplayName    string `json:"displayName"`
+	Code           int    `json:"code"`
+	AttachmentMode string `json:"attachmentMode"` // none, inline ou url
+	Status         string `json:"status"`
 }
 
 // GetProviderInfo retorna informações do provider configurado
@@ -109,10 +126,18 @@ func (h *Handler) GetProviderInfo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	respondJSON(w, http.StatusOK, ProviderInfoResponse{
-		ProviderName: h.providerName,
-		Status:       "online",
-	})
+	info := ProviderInfoResponse{
+		ProviderName:   h.providerName,
+		DisplayName:    email.ProviderDisplayName(h.providerName),
+		AttachmentMode: string(email.AttachmentsInline),
+		Status:         "online",
+	}
+	if spec, ok := email.LookupProvider(h.providerName); ok {
+		info.Code = spec.Code
+		info.AttachmentMode = string(spec.Attachments)
+	}
+
+	respondJSON(w, http.StatusOK, info)
 }
 
 // ValidarCliente valida um cliente pelo código ou CPF/CNPJ
@@ -375,13 +400,10 @@ func (h *Handler) DispararEmail(w http.ResponseWriter, r *http.Request) {
 		zap.String("ip", clientIP),
 		zap.Int("cliente", req.CliCodigo))
 
-	// Converte o provider configurado para código numérico
-	providerCode := message.ProviderStringToCode(h.providerName)
-
 	// Cria o registro de e-mail
 	email := &message.Email{
 		CliCodigo:       sql.NullInt64{Int64: int64(req.CliCodigo), Valid: true},
-		Remetente:       "noreply@sistema.com.br", // TODO: tornar configurável
+		Remetente:       h.defaultFrom,
 		Destinatario:    req.Email,
 		Assunto:         assunto,
 		Corpo:           mensagem,
@@ -390,7 +412,7 @@ func (h *Handler) DispararEmail(w http.ResponseWriter, r *http.Request) {
 		DataCadastro:    time.Now(),
 		DataAgendamento: sql.NullTime{Time: time.Now(), Valid: true},
 		Prioridade:      2, // Normal
-		MetodoEnvio:     sql.NullInt64{Int64: int64(providerCode), Valid: true},
+		MetodoEnvio:     sql.NullInt64{}, // NULL: provider escolhido no envio (divisão de tráfego/failover)
 		IPOrigem:        sql.NullString{String: clientIP, Valid: clientIP != ""},
 		AnexoReferencia: anexoReferencia,
 		AnexoNome:       anexoNome,
@@ -487,6 +509,23 @@ func (h *Handler) ConsultarStatus(w http.ResponseWriter, r *http.Request) {
 		idProvedor = email.IDProvider.String
 	}
 
+	// Linha do tempo (histórico indisponível não impede a consulta do status)
+	var eventos []EventoStatus
+	events, err := h.emailRepo.Events().ListByEmail(ctx, emailID)
+	if err != nil {
+		h.logger.Warn("Erro ao buscar eventos do e-mail", zap.Error(err), zap.Int64("emailId", emailID))
+	}
+	for _, event := range events {
+		eventos = append(eventos, EventoStatus{
+			Tipo:      string(event.Type),
+			Descricao: getEventDescription(event.Type),
+			Data:      event.Timestamp.Format("02/01/2006 15:04:05"),
+			Provider:  event.Provider,
+			Codigo:    event.Code,
+			Detalhes:  event.Detail,
+		})
+	}
+
 	respondJSON(w, http.StatusOK, StatusEmailResponse{
 		Success:    true,
 		Status:     int(email.StatusEnvio),
@@ -495,6 +534,7 @@ func (h *Handler) ConsultarStatus(w http.ResponseWriter, r *http.Request) {
 		Tentativas: email.QTDTentativas,
 		ErroMsg:    erroMsg,
 		IDProvedor: idProvedor,
+		Eventos:    eventos,
 	})
 }
 
@@ -516,6 +556,40 @@ func getStatusDescription(status message.EmailStatus) string {
 	}
 }
 
+// getEventDescription retorna a descrição do evento da linha do tempo
+func getEventDescription(eventType message.EventType) string {
+	switch eventType {
+	case message.EventQueued:
+		return "Na fila"
+	case message.EventAttemptStarted:
+		return "Tentativa de envio"
+	case message.EventAccepted:
+		return "Aceito pelo provedor"
+	case message.EventFailed:
+		return "Falha no envio"
+	case message.EventType(email.DeliverySent):
+		return "Repassado ao destino"
+	case message.EventDelivered:
+		return "Entregue"
+	case message.EventType(email.DeliveryDeferred):
+		return "Entrega adiada"
+	case message.EventBounced:
+		return "Bounce (rejeitado pelo destino)"
+	case message.EventType(email.DeliveryDropped):
+		return "Descartado pelo provedor"
+	case message.EventOpened:
+		return "Aberto"
+	case message.EventClicked:
+		return "Link clicado"
+	case message.EventType(email.DeliverySpamReport):
+		return "Marcado como spam"
+	case message.EventType(email.DeliveryUnsubscribed):
+		return "Descadastrado"
+	default:
+		return string(eventType)
+	}
+}
+
 // respondJSON envia uma resposta JSON
 func respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -372,6 +372,29 @@ const manualSendHTML = `<!DOCTYPE html>
             color: #880e4f;
         }
 
+        .timeline {
+            list-style: none;
+            margin-top: 12px;
+            padding-left: 14px;
+            border-left: 2px solid #d1d5f0;
+            font-size: 0.85rem;
+        }
+
+        .timeline li {
+            margin-bottom: 8px;
+        }
+
+        .timeline .timeline-data {
+            color: #666;
+            margin-right: 6px;
+        }
+
+        .timeline .timeline-detalhes {
+            display: block;
+            color: #888;
+            word-break: break-all;
+        }
+
         .spinner {
             border: 3px solid #f3f3f3;
             border-top: 3px solid #667eea;
@@ -955,7 +978,7 @@ const manualSendHTML = `<!DOCTYPE html>
                     atualizarStatusDisplay(data);
 
                     // Para a consulta se o status for final
-                    if (data.status === 2 || data.status === 4 || data.status === 125) {
+                    if (data.status === 2 || data.status === 4 || data.status === 125 || data.status === 126 || data.status === 127) {
                         if (statusCheckInterval) {
                             clearInterval(statusCheckInterval);
                             statusCheckInterval = null;
@@ -989,6 +1012,14 @@ const manualSendHTML = `<!DOCTYPE html>
                     badgeClass = 'status-invalido';
                     statusHTML = '✗ E-mail inválido';
                     break;
+                case 126:
+                    badgeClass = 'status-erro';
+                    statusHTML = '✗ Provider exigido não configurado';
+                    break;
+                case 127:
+                    badgeClass = 'status-erro';
+                    statusHTML = '✗ Remetente não permitido';
+                    break;
                 case 3:
                     badgeClass = 'status-erro';
                     statusHTML = '⚠ Erro (tentando novamente...)';
@@ -1019,29 +1050,45 @@ const manualSendHTML = `<!DOCTYPE html>
                 html += '<br><small style="color: #666;">ID Provedor: ' + data.idProvedor + '</small>';
             }
 
+            if (data.eventos && data.eventos.length > 0) {
+                html += '<ul class="timeline">';
+                data.eventos.forEach(evento => {
+                    html += '<li><span class="timeline-data">' + escapeHtml(evento.data) + '</span>' +
+                        '<strong>' + escapeHtml(evento.descricao) + '</strong>';
+                    if (evento.provider) {
+                        html += ' (' + escapeHtml(evento.provider) + ')';
+                    }
+                    if (evento.codigo || evento.detalhes) {
+                        html += '<span class="timeline-detalhes">' +
+                            escapeHtml([evento.codigo, evento.detalhes].filter(Boolean).join(' - ')) + '</span>';
+                    }
+                    html += '</li>';
+                });
+                html += '</ul>';
+            }
+
             statusValue.innerHTML = html;
         }
 
+        // escapeHtml evita que detalhes vindos dos provedores sejam interpretados como HTML
+        function escapeHtml(texto) {
+            const div = document.createElement('div');
+            div.textContent = texto || '';
+            return div.innerHTML;
+        }
+
         async function carregarProviderInfo() {
             try {
                 const response = await fetch('/api/manual/provider-info');
                 if (response.ok) {
                     const data = await response.json();
 
-                    const providerNames = {
-                        'mock': '🧪 Mock (Teste)',
-                        'smtp': '📧 SMTP',
-                        'sendgrid': '📨 SendGrid',
-                        'zenvia': '🇧🇷 Zenvia',
-                        'pontaltech': '📡 Pontaltech'
-                    };
-
-                    const displayName = providerNames[data.providerName] || data.providerName.toUpperCase();
+                    const displayName = data.displayName || data.providerName.toUpperCase();
                     document.getElementById('provider-name').textContent = displayName;
 
                     // Atualizar provider atual e alternar campos de anexo
                     currentProvider = data.providerName;
-                    toggleAnexoFields(currentProvider);
+                    toggleAnexoFields(data.attachmentMode);
 
                     const statusElement = document.getElementById('connection-status');
                     if (data.status === 'online') {
@@ -1067,12 +1114,12 @@ const manualSendHTML = `<!DOCTYPE html>
             }
         }
 
-        function toggleAnexoFields(providerName) {
+        function toggleAnexoFields(attachmentMode) {
             const fileGroup = document.getElementById('anexoFileGroup');
             const urlGroup = document.getElementById('anexoUrlGroup');
 
-            if (providerName === 'zenvia') {
-                // Zenvia: mostrar campo de URL, esconder upload de arquivo
+            if (attachmentMode === 'url') {
+                // Provider com anexo por URL (ex: Zenvia): mostrar campo de URL, esconder upload de arquivo
                 fileGroup.style.display = 'none';
                 urlGroup.style.display = 'block';
             } else {
@@ -0,0 +1,109 @@
+package message
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/config"
+	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/metrics"
+	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/ratelimit"
+)
+
+// domainConcurrencyRetryDelay tempo de adiamento quando o limite de envios
+// simultâneos de um domínio está esgotado
+const domainConcurrencyRetryDelay = 5 * time.Second
+
+// DomainThrottler aplica limites de taxa e de concorrência por domínio do
+// destinatário. Não bloqueia: quando um domínio está no limite, o envio deve
+// ser adiado para que os workers sigam atendendo os demais domínios.
+type DomainThrottler struct {
+	groups  map[string]*domainGroup // domínio -> grupo de limite
+	metrics *metrics.PerformanceMetrics
+}
+
+// domainGroup estado de limite de um grupo de domínios
+type domainGroup struct {
+	name           string
+	limiter        *ratelimit.TokenBucket
+	maxConcurrency int
+
+	mu       sync.Mutex
+	inFlight int
+}
+
+// NewDomainThrottler cria o limitador por domínio. Retorna nil se não houver
+// regras; todos os métodos aceitam receptor nil.
+func NewDomainThrottler(cfg config.DomainThrottleConfig, metricsCollector *metrics.PerformanceMetrics) *DomainThrottler {
+	if !cfg.Enabled || len(cfg.Rules) == 0 {
+		return nil
+	}
+
+	throttler := &DomainThrottler{
+		groups:  make(map[string]*domainGroup),
+		metrics: metricsCollector,
+	}
+
+	for _, rule := range cfg.Rules {
+		group := &domainGroup{
+			name:           strings.Join(rule.Domains, ","),
+			limiter:        ratelimit.NewTokenBucket(rule.RatePerMin, 1),
+			maxConcurrency: rule.MaxConcurrency,
+		}
+		for _, domain := range rule.Domains {
+			throttler.groups[domain] = group
+		}
+	}
+
+	return throttler
+}
+
+// Acquire tenta reservar um envio para o destinatário. Se permitido, retorna
+// release (que deve ser chamado ao fim do envio) e ok=true. Caso contrário
+// retorna ok=false e o tempo sugerido de adiamento.
+func (t *DomainThrottler) Acquire(recipient string) (release func(), ok bool, retryAfter time.Duration) {
+	noop := func() {}
+	if t == nil {
+		return noop, true, 0
+	}
+
+	group, found := t.groups[recipientDomain(recipient)]
+	if !found {
+		return noop, true, 0
+	}
+
+	group.mu.Lock()
+	defer group.mu.Unlock()
+
+	if group.maxConcurrency > 0 && group.inFlight >= group.maxConcurrency {
+		t.metrics.RecordDomainDeferred(group.name)
+		return nil, false, domainConcurrencyRetryDelay
+	}
+
+	if allowed, wait := group.limiter.TryTake(); !allowed {
+		t.metrics.RecordDomainDeferred(group.name)
+		return nil, false, wait
+	}
+
+	group.inFlight++
+	t.metrics.RecordDomainDispatched(group.name, group.inFlight)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			group.mu.Lock()
+			group.inFlight--
+			t.metrics.SetDomainInFlight(group.name, group.inFlight)
+			group.mu.Unlock()
+		})
+	}, true, 0
+}
+
+// recipientDomain extrai o domínio (em minúsculas) de um endereço de email
+func recipientDomain(address string) string {
+	at := strings.LastIndex(address, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(address[at+1:]))
+}
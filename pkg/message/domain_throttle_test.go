@@ -0,0 +1,111 @@
+package message
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/config"
+	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/metrics"
+)
+
+func TestNewDomainThrottlerDisabled(t *testing.T) {
+	rules := []config.DomainThrottleRule{{Domains: []string{"uol.com.br"}, MaxConcurrency: 1}}
+
+	tests := []struct {
+		name string
+		cfg  config.DomainThrottleConfig
+	}{
+		{"desabilitado", config.DomainThrottleConfig{Enabled: false, Rules: rules}},
+		{"sem regras", config.DomainThrottleConfig{Enabled: true}},
+	}
+	for _, tt := range tests {
+		throttler := NewDomainThrottler(tt.cfg, metrics.NewPerformanceMetrics())
+		if throttler != nil {
+			t.Errorf("%s: esperado nil (sem limite)", tt.name)
+		}
+		// Receptor nil libera todos os envios
+		for i := 0; i < 3; i++ {
+			release, ok, _ := throttler.Acquire("ana@uol.com.br")
+			if !ok {
+				t.Fatalf("%s: envio %d bloqueado", tt.name, i+1)
+			}
+			release()
+		}
+	}
+}
+
+func TestDomainThrottlerConcurrency(t *testing.T) {
+	collector := metrics.NewPerformanceMetrics()
+	throttler := NewDomainThrottler(config.DomainThrottleConfig{
+		Enabled: true,
+		Rules:   []config.DomainThrottleRule{{Domains: []string{"uol.com.br", "bol.com.br"}, MaxConcurrency: 2}},
+	}, collector)
+
+	// Domínios do mesmo grupo compartilham o limite; maiúsculas não importam
+	first, ok, _ := throttler.Acquire("ana@uol.com.br")
+	if !ok {
+		t.Fatal("primeiro envio bloqueado")
+	}
+	if _, ok, _ := throttler.Acquire("bruno@BOL.com.br"); !ok {
+		t.Fatal("segundo envio bloqueado")
+	}
+	_, ok, retryAfter := throttler.Acquire("carla@uol.com.br")
+	if ok || retryAfter != domainConcurrencyRetryDelay {
+		t.Errorf("terceiro envio = %v/%v, esperado adiado por %v", ok, retryAfter, domainConcurrencyRetryDelay)
+	}
+
+	// Domínio sem regra não é limitado
+	if _, ok, _ := throttler.Acquire("davi@gmail.com"); !ok {
+		t.Error("domínio sem regra bloqueado")
+	}
+
+	// release libera a vaga uma única vez, mesmo se chamado de novo
+	first()
+	first()
+	if _, ok, _ := throttler.Acquire("carla@uol.com.br"); !ok {
+		t.Error("envio bloqueado após release")
+	}
+	if _, ok, _ := throttler.Acquire("eva@uol.com.br"); ok {
+		t.Error("release chamado duas vezes liberou duas vagas")
+	}
+
+	stats := collector.GetDomainStats()
+	if len(stats) != 1 || stats[0].Dispatched != 3 || stats[0].Deferred != 2 || stats[0].InFlight != 2 {
+		t.Errorf("métricas = %+v, esperado 3 liberados, 2 adiados e 2 em andamento", stats)
+	}
+}
+
+func TestDomainThrottlerRate(t *testing.T) {
+	throttler := NewDomainThrottler(config.DomainThrottleConfig{
+		Enabled: true,
+		Rules:   []config.DomainThrottleRule{{Domains: []string{"terra.com.br"}, RatePerMin: 60}},
+	}, metrics.NewPerformanceMetrics())
+
+	release, ok, _ := throttler.Acquire("ana@terra.com.br")
+	if !ok {
+		t.Fatal("primeiro envio bloqueado")
+	}
+	release()
+
+	// Limite de taxa não depende de release: o próximo token vem em ~1s
+	_, ok, retryAfter := throttler.Acquire("bruno@terra.com.br")
+	if ok || retryAfter <= 0 || retryAfter > time.Second {
+		t.Errorf("segundo envio = %v/%v, esperado adiado por até 1s", ok, retryAfter)
+	}
+}
+
+func TestRecipientDomain(t *testing.T) {
+	tests := []struct {
+		address string
+		want    string
+	}{
+		{"ana@UOL.com.br", "uol.com.br"},
+		{"nome+tag@sub.exemplo.com ", "sub.exemplo.com"},
+		{"sem-arroba", ""},
+	}
+	for _, tt := range tests {
+		if got := recipientDomain(tt.address); got != tt.want {
+			t.Errorf("recipientDomain(%q) = %q, esperado %q", tt.address, got, tt.want)
+		}
+	}
+}
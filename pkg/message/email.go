@@ -6,17 +6,21 @@ import (
 	"regexp"
 	"strings"
 	"time"
+
+	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/email"
 )
 
 // EmailStatus representa o status de envio de um email
 type EmailStatus int
 
 const (
-	StatusPending          EmailStatus = 0   // Pendente
-	StatusSent             EmailStatus = 2   // Enviado com sucesso
-	StatusError            EmailStatus = 3   // Erro temporário (retentar)
-	StatusPermanentFailure EmailStatus = 4   // Falha permanente
-	StatusInvalidEmail     EmailStatus = 125 // E-mail inválido
+	StatusPending               EmailStatus = 0   // Pendente
+	StatusSent                  EmailStatus = 2   // Enviado com sucesso
+	StatusError                 EmailStatus = 3   // Erro temporário (retentar)
+	StatusPermanentFailure      EmailStatus = 4   // Falha permanente
+	StatusInvalidEmail          EmailStatus = 125 // E-mail inválido
+	StatusProviderNotConfigured EmailStatus = 126 // METODO_ENVIO exige provider não configurado
+	StatusSenderNotAllowed      EmailStatus = 127 // REMETENTE não é uma identidade permitida
 )
 
 // Email representa uma mensagem de email
@@ -42,6 +46,7 @@ type Email struct {
 	AnexoTipo        sql.NullString
 	IPOrigem         sql.NullString
 	TemplateID       sql.NullInt64 // ID do template utilizado
+	DataProximaTentativa sql.NullTime // Data mínima da próxima tentativa (retry agendado)
 }
 
 // Priority constants
@@ -51,40 +56,32 @@ const (
 	PriorityLow    = 3
 )
 
+// RequestedProvider retorna o nome do provider exigido pela mensagem via
+// METODO_ENVIO preenchido na inserção. NULL ou 0 indicam ausência de
+// preferência (o código 0 do mock não pode ser exigido); códigos
+// desconhecidos retornam "unknown".
+func (e *Email) RequestedProvider() string {
+	if !e.MetodoEnvio.Valid || e.MetodoEnvio.Int64 == 0 {
+		return ""
+	}
+	return ProviderCodeToString(int(e.MetodoEnvio.Int64))
+}
+
 // ProviderStringToCode converte nome do provider para código numérico
+// (registro de providers em pkg/email). Nomes desconhecidos retornam 0.
 func ProviderStringToCode(provider string) int {
-	switch strings.ToLower(provider) {
-	case "mock":
-		return 0
-	case "smtp":
-		return 1024
-	case "sendgrid":
-		return 2048
-	case "zenvia":
-		return 4096
-	case "pontaltech":
-		return 8192
-	default:
-		return 0
+	if spec, ok := email.LookupProvider(provider); ok {
+		return spec.Code
 	}
+	return 0
 }
 
 // ProviderCodeToString converte código numérico para nome do provider
 func ProviderCodeToString(code int) string {
-	switch code {
-	case 0:
-		return "mock"
-	case 1024:
-		return "smtp"
-	case 2048:
-		return "sendgrid"
-	case 4096:
-		return "zenvia"
-	case 8192:
-		return "pontaltech"
-	default:
-		return "unknown"
+	if spec, ok := email.LookupProviderCode(code); ok {
+		return spec.Name
 	}
+	return "unknown"
 }
 
 // ValidateEmail valida formato de e-mail
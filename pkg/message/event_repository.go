@@ -0,0 +1,175 @@
+package message
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/email"
+	"go.uber.org/zap"
+)
+
+// EventType tipo de evento do ciclo de vida de um email (EVENTOEMAIL.TIPO)
+type EventType string
+
+const (
+	EventQueued         EventType = "queued"          // Inserido em MENSAGEMEMAIL (trigger no banco)
+	EventAttemptStarted EventType = "attempt_started" // Tentativa de envio iniciada
+	EventAccepted       EventType = "accepted"        // Aceito pelo provider
+	EventFailed         EventType = "failed"          // Tentativa falhou ou email rejeitado antes do envio
+
+	// Eventos de entrega recebidos dos providers (mesmos valores de email.DeliveryEventType)
+	EventDelivered = EventType(email.DeliveryDelivered)
+	EventBounced   = EventType(email.DeliveryBounced)
+	EventOpened    = EventType(email.DeliveryOpened)
+	EventClicked   = EventType(email.DeliveryClicked)
+)
+
+// maxEventDetailLength tamanho de EVENTOEMAIL.DETALHES
+const maxEventDetailLength = 4000
+
+// Event evento do histórico de um email
+type Event struct {
+	ID        int64     `json:"id"`
+	EmailID   int64     `json:"emailId"`
+	Type      EventType `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	Provider  string    `json:"provider,omitempty"`
+	Code      string    `json:"code,omitempty"`
+	Detail    string    `json:"detail,omitempty"`
+}
+
+// execer executa comandos no banco ou em uma transação
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// EventRepository gerencia o histórico de eventos dos emails (EVENTOEMAIL)
+type EventRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+// NewEventRepository cria um novo repository de eventos
+func NewEventRepository(db *sql.DB, logger *zap.Logger) *EventRepository {
+	return &EventRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Record grava um evento no histórico do email (Timestamp zero = agora)
+func (r *EventRepository) Record(ctx context.Context, event Event) error {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	if len(event.Detail) > maxEventDetailLength {
+		event.Detail = event.Detail[:maxEventDetailLength]
+	}
+
+	query := `
+		INSERT INTO EVENTOEMAIL (
+			ID, ID_MENSAGEM, TIPO, DATA_EVENTO, PROVIDER, CODIGO, DETALHES
+		) VALUES (
+			SEQ_EVENTOEMAIL.NEXTVAL, :1, :2, :3, :4, :5, :6
+		)`
+
+	_, err := r.db.ExecContext(ctx, query,
+		event.EmailID, string(event.Type), event.Timestamp,
+		event.Provider, event.Code, event.Detail)
+	if err != nil {
+		return fmt.Errorf("erro ao gravar evento do email: %w", err)
+	}
+
+	r.logger.Debug("Evento do email gravado",
+		zap.Int64("email_id", event.EmailID),
+		zap.String("tipo", string(event.Type)))
+	return nil
+}
+
+// RecordDelivery grava no histórico o evento de entrega recebido do provider,
+// para cada email enviado por ele que corresponde ao evento (pelo ID devolvido
+// ou por ID_PROVIDER). Retorna a quantidade de eventos gravados (0 = nenhum
+// email corresponde ao evento).
+func (r *EventRepository) RecordDelivery(ctx context.Context, event email.DeliveryEvent) (int64, error) {
+	return r.recordDelivery(ctx, r.db, event)
+}
+
+// recordDelivery grava o evento de entrega usando db (banco ou transação)
+func (r *EventRepository) recordDelivery(ctx context.Context, db execer, event email.DeliveryEvent) (int64, error) {
+	occurredAt := event.Timestamp
+	if occurredAt.IsZero() {
+		occurredAt = time.Now()
+	}
+
+	detail := event.Detail
+	if event.Recipient != "" {
+		detail = event.Recipient + ": " + detail
+	}
+	if len(detail) > maxEventDetailLength {
+		detail = detail[:maxEventDetailLength]
+	}
+
+	match, key := "ID_PROVIDER", interface{}(event.ProviderID)
+	if event.MessageID > 0 {
+		match, key = "ID", event.MessageID
+	}
+
+	query := `
+		INSERT INTO EVENTOEMAIL (
+			ID, ID_MENSAGEM, TIPO, DATA_EVENTO, PROVIDER, CODIGO, DETALHES
+		)
+		SELECT SEQ_EVENTOEMAIL.NEXTVAL, ID, :1, :2, :3, :4, :5
+		FROM MENSAGEMEMAIL
+		WHERE ` + match + ` = :6
+		AND METODO_ENVIO = :7`
+
+	result, err := db.ExecContext(ctx, query,
+		string(event.Type), occurredAt, event.Provider, event.Status, detail,
+		key, ProviderStringToCode(event.Provider))
+	if err != nil {
+		return 0, fmt.Errorf("erro ao gravar evento de entrega no histórico: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("erro ao verificar evento de entrega gravado: %w", err)
+	}
+	return rows, nil
+}
+
+// ListByEmail retorna o histórico de eventos do email em ordem cronológica
+func (r *EventRepository) ListByEmail(ctx context.Context, emailID int64) ([]Event, error) {
+	query := `
+		SELECT ID, ID_MENSAGEM, TIPO, DATA_EVENTO, PROVIDER, CODIGO, DETALHES
+		FROM EVENTOEMAIL
+		WHERE ID_MENSAGEM = :1
+		ORDER BY DATA_EVENTO, ID`
+
+	rows, err := r.db.QueryContext(ctx, query, emailID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar eventos do email: %w", err)
+	}
+	defer rows.Close()
+
+	events := []Event{}
+	for rows.Next() {
+		var event Event
+		var eventType string
+		var provider, code, detail sql.NullString
+		if err := rows.Scan(&event.ID, &event.EmailID, &eventType, &event.Timestamp,
+			&provider, &code, &detail); err != nil {
+			return nil, fmt.Errorf("erro ao ler evento do email: %w", err)
+		}
+		event.Type = EventType(eventType)
+		event.Provider = provider.String
+		event.Code = code.String
+		event.Detail = detail.String
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao iterar eventos do email: %w", err)
+	}
+	return events, nil
+}
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -12,7 +14,9 @@ import (
 	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/config"
 	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/email"
 	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/metrics"
+	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/ratelimit"
 	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/retry"
+	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/template"
 	"go.uber.org/zap"
 )
 
@@ -23,17 +27,48 @@ type Processor struct {
 	metrics     *metrics.PerformanceMetrics
 	config      *config.PerformanceConfig
 	logger      *zap.Logger
-	defaultFrom string // Remetente padrão configurado
+	senders     *SenderResolver // Identidades de remetente permitidas (REMETENTE)
+	templates   TemplateSource  // Templates (TEMPLATE_ID) para agrupar em lote emails gerados com macros
 
 	ctx            context.Context
 	cancel         context.CancelFunc
 	wg             sync.WaitGroup
-	jobQueue       chan *Email
+	highQueue      chan job // Fila PriorityHigh
+	normalQueue    chan job // Fila PriorityNormal
+	lowQueue       chan job // Fila PriorityLow
 	isRunning      bool
 	mu             sync.Mutex
 	circuitBreaker *CircuitBreaker
+	rateLimiter    *ratelimit.TokenBucket // Limite global de envios (compartilhado pelos workers)
+	domainThrottle *DomainThrottler       // Limites por domínio do destinatário
+
+	claimedMu sync.Mutex
+	claimed   map[int64]struct{} // Emails reservados nas filas ou em envio (reservas renovadas)
+
+	templatesMu   sync.Mutex
+	templateCache map[int64]*cachedTemplate
+}
+
+// TemplateSource busca os templates de email (TEMPLATE_ID)
+type TemplateSource interface {
+	GetByID(ctx context.Context, id int64) (*template.Template, error)
 }
 
+// templateCacheTTL tempo que um template fica em cache para o envio em lote
+const templateCacheTTL = 5 * time.Minute
+
+// cachedTemplate template usado para recuperar as macros dos emails gerados a
+// partir dele (matcher nil = template indisponível)
+type cachedTemplate struct {
+	content  string
+	matcher  *template.MacroMatcher
+	loadedAt time.Time
+}
+
+// job unidade das filas de processamento: um email ou um lote de emails
+// enviados na mesma chamada ao provider (enable_batching)
+type job []*Email
+
 // CircuitBreaker proteção contra falhas em cascata
 type CircuitBreaker struct {
 	mu              sync.RWMutex
@@ -50,7 +85,9 @@ func NewProcessor(
 	sender *email.Sender,
 	metricsCollector *metrics.PerformanceMetrics,
 	config *config.PerformanceConfig,
-	defaultFrom string,
+	domainThrottle *DomainThrottler,
+	senders *SenderResolver,
+	templates TemplateSource,
 	logger *zap.Logger,
 ) *Processor {
 	ctx, cancel := context.WithCancel(context.Background())
@@ -60,17 +97,24 @@ func NewProcessor(
 		sender:      sender,
 		metrics:     metricsCollector,
 		config:      config,
-		defaultFrom: defaultFrom,
+		senders:     senders,
+		templates:   templates,
 		logger:      logger,
 		ctx:         ctx,
 		cancel:      cancel,
-		jobQueue:    make(chan *Email, config.BatchSize*2),
+		highQueue:   make(chan job, config.BatchSize*2),
+		normalQueue: make(chan job, config.BatchSize*2),
+		lowQueue:    make(chan job, config.BatchSize*2),
 		isRunning:   false,
 		circuitBreaker: &CircuitBreaker{
 			state:     "closed",
 			threshold: config.CircuitBreakerThreshold,
 			timeout:   time.Duration(config.CircuitBreakerTimeoutSeconds) * time.Second,
 		},
+		rateLimiter:    ratelimit.NewTokenBucket(config.EmailRateLimitPerMin, config.EmailRateLimitBurst),
+		domainThrottle: domainThrottle,
+		claimed:        make(map[int64]struct{}),
+		templateCache:  make(map[int64]*cachedTemplate),
 	}
 }
 
@@ -85,20 +129,52 @@ func (p *Processor) Start() error {
 	p.mu.Unlock()
 
 	p.logger.Info("Iniciando processador de Email",
+		zap.String("instancia", p.config.InstanceID),
+		zap.Int("lease_seconds", p.config.LeaseSeconds),
 		zap.Int("workers", p.config.WorkerCount),
+		zap.Int("workers_prioridade_alta", p.config.PriorityHighWorkers),
+		zap.Int("workers_prioridade_normal", p.config.PriorityNormalWorkers),
+		zap.Int("workers_prioridade_baixa", p.config.PriorityLowWorkers),
 		zap.Int("batch_size", p.config.BatchSize),
-		zap.Int("circuit_breaker_threshold", p.config.CircuitBreakerThreshold))
+		zap.Bool("envio_em_lote", p.config.EnableBatching),
+		zap.Int("circuit_breaker_threshold", p.config.CircuitBreakerThreshold),
+		zap.Int("rate_limit_per_min", p.config.EmailRateLimitPerMin),
+		zap.Int("rate_limit_burst", p.config.EmailRateLimitBurst))
 
-	// Iniciar workers
+	// Iniciar workers compartilhados (atendem todas as filas, sempre a mais prioritária primeiro)
+	workerID := 0
 	for i := 0; i < p.config.WorkerCount; i++ {
 		p.wg.Add(1)
-		go p.worker(i)
+		go p.worker(workerID)
+		workerID++
+	}
+
+	// Iniciar workers dedicados por prioridade (capacidade reservada)
+	lanes := []struct {
+		name    string
+		queue   chan job
+		workers int
+	}{
+		{"alta", p.highQueue, p.config.PriorityHighWorkers},
+		{"normal", p.normalQueue, p.config.PriorityNormalWorkers},
+		{"baixa", p.lowQueue, p.config.PriorityLowWorkers},
+	}
+	for _, lane := range lanes {
+		for i := 0; i < lane.workers; i++ {
+			p.wg.Add(1)
+			go p.laneWorker(workerID, lane.name, lane.queue)
+			workerID++
+		}
 	}
 
 	// Iniciar dispatcher
 	p.wg.Add(1)
 	go p.dispatcher()
 
+	// Renovar as reservas dos emails que aguardam nas filas ou no limite de envio
+	p.wg.Add(1)
+	go p.leaseRenewer()
+
 	return nil
 }
 
@@ -132,6 +208,30 @@ func (p *Processor) Stop() error {
 			zap.Duration("timeout", shutdownTimeout))
 	}
 
+	// Liberar reservas de emails que ficaram nas filas sem processamento
+	var pending []Email
+	for _, queue := range []chan job{p.highQueue, p.normalQueue, p.lowQueue} {
+		for drained := false; !drained; {
+			select {
+			case queued, ok := <-queue:
+				if !ok {
+					drained = true
+				} else {
+					for _, emailMsg := range queued {
+						pending = append(pending, *emailMsg)
+					}
+				}
+			default:
+				drained = true
+			}
+		}
+	}
+	if len(pending) > 0 {
+		p.logger.Info("Liberando reservas de emails não processados",
+			zap.Int("total", len(pending)))
+		p.releaseClaims(pending)
+	}
+
 	p.mu.Lock()
 	p.isRunning = false
 	p.mu.Unlock()
@@ -153,7 +253,9 @@ func (p *Processor) dispatcher() {
 		select {
 		case <-p.ctx.Done():
 			p.logger.Info("Dispatcher finalizado")
-			close(p.jobQueue)
+			close(p.highQueue)
+			close(p.normalQueue)
+			close(p.lowQueue)
 			return
 
 		case <-ticker.C:
@@ -175,7 +277,8 @@ func (p *Processor) fetchAndDispatch() {
 
 	// Medir tempo de execução da query
 	queryStartTime := time.Now()
-	emailList, err := p.repo.GetPendingEmails(ctx, p.config.BatchSize, p.config.DataDisparoOffset, p.config.MaxTentativas)
+	emailList, err := p.repo.ClaimPendingEmails(ctx, p.config.InstanceID, p.config.BatchSize,
+		p.config.DataDisparoOffset, p.config.MaxTentativas, time.Duration(p.config.LeaseSeconds)*time.Second)
 	queryDuration := time.Since(queryStartTime)
 
 	// Registrar métrica de query executada
@@ -193,82 +296,430 @@ func (p *Processor) fetchAndDispatch() {
 		return
 	}
 
-	p.logger.Info("Emails pendentes encontrados",
-		zap.Int("total", len(emailList)))
+	p.logger.Info("Emails pendentes reservados",
+		zap.Int("total", len(emailList)),
+		zap.String("instancia", p.config.InstanceID))
+	p.trackClaims(emailList)
 
-	// Enviar para fila de processamento
-	for i := range emailList {
+	// Enviar para a fila da prioridade de cada email (ou lote)
+	jobs := p.buildJobs(emailList)
+	var notQueued []Email
+	for i, queued := range jobs {
 		select {
 		case <-p.ctx.Done():
+			for _, pending := range jobs[i:] {
+				for _, emailMsg := range pending {
+					notQueued = append(notQueued, *emailMsg)
+				}
+			}
+			p.releaseClaims(notQueued)
 			return
-		case p.jobQueue <- &emailList[i]:
-			// Email adicionado à fila
+		case p.queueFor(queued[0].Prioridade) <- queued:
+			// Email (ou lote) adicionado à fila
 		default:
-			// Fila cheia, processar no próximo ciclo
-			p.logger.Warn("Fila de processamento cheia, aguardando próximo ciclo")
-			return
+			// Fila desta prioridade cheia; as demais filas seguem recebendo
+			for _, emailMsg := range queued {
+				notQueued = append(notQueued, *emailMsg)
+			}
 		}
 	}
 
+	if len(notQueued) > 0 {
+		p.logger.Warn("Fila de processamento cheia, aguardando próximo ciclo",
+			zap.Int("nao_enfileirados", len(notQueued)))
+		p.releaseClaims(notQueued)
+	}
+
 	p.circuitBreaker.recordSuccess()
 }
 
-// worker processa emails da fila
+// releaseClaims libera as reservas de emails que não foram enfileirados, para
+// que fiquem disponíveis imediatamente (sem esperar a lease expirar)
+func (p *Processor) releaseClaims(emails []Email) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for i := range emails {
+		if err := p.repo.ReleaseClaim(ctx, emails[i].ID, p.config.InstanceID); err != nil {
+			p.logger.Warn("Erro ao liberar reserva do email",
+				zap.Int64("email_id", emails[i].ID),
+				zap.Error(err))
+		}
+		p.untrackClaim(emails[i].ID)
+	}
+}
+
+// trackClaims registra os emails reservados para renovação da reserva
+func (p *Processor) trackClaims(emails []Email) {
+	p.claimedMu.Lock()
+	defer p.claimedMu.Unlock()
+	for i := range emails {
+		p.claimed[emails[i].ID] = struct{}{}
+	}
+}
+
+// untrackClaim remove um email concluído (ou liberado) da renovação
+func (p *Processor) untrackClaim(id int64) {
+	p.claimedMu.Lock()
+	defer p.claimedMu.Unlock()
+	delete(p.claimed, id)
+}
+
+// leaseRenewer prorroga periodicamente (a cada terço de lease_seconds) as
+// reservas dos emails ainda nas filas ou em envio, para que a espera nas filas
+// e no limite de envio não faça a reserva expirar e o email ser reservado
+// (e enviado) por outra instância
+func (p *Processor) leaseRenewer() {
+	defer p.wg.Done()
+
+	lease := time.Duration(p.config.LeaseSeconds) * time.Second
+	interval := lease / 3
+	if interval < time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+			p.claimedMu.Lock()
+			ids := make([]int64, 0, len(p.claimed))
+			for id := range p.claimed {
+				ids = append(ids, id)
+			}
+			p.claimedMu.Unlock()
+			if len(ids) == 0 {
+				continue
+			}
+
+			ctx, cancel := context.WithTimeout(p.ctx, 30*time.Second)
+			if err := p.repo.RenewLeases(ctx, p.config.InstanceID, ids, lease); err != nil {
+				p.logger.Warn("Erro ao renovar reservas dos emails",
+					zap.Int("total", len(ids)),
+					zap.Error(err))
+			}
+			cancel()
+		}
+	}
+}
+
+// buildJobs monta as unidades de trabalho. Com enable_batching, emails que o
+// provider consegue enviar na mesma chamada (mesma chave de lote e mesma fila
+// de prioridade) formam um lote; os demais seguem individualmente.
+func (p *Processor) buildJobs(emailList []Email) []job {
+	jobs := make([]job, 0, len(emailList))
+	if !p.config.EnableBatching {
+		for i := range emailList {
+			jobs = append(jobs, job{&emailList[i]})
+		}
+		return jobs
+	}
+
+	type groupKey struct {
+		key   string
+		queue chan job
+	}
+	type group struct {
+		index      int // Posição do lote aberto em jobs
+		max        int
+		recipients map[string]bool
+	}
+	groups := make(map[groupKey]*group)
+
+	for i := range emailList {
+		message := &emailList[i]
+		key, max := p.batchKey(message)
+		if key == "" || max < 2 {
+			jobs = append(jobs, job{message})
+			continue
+		}
+
+		gk := groupKey{key: key, queue: p.queueFor(message.Prioridade)}
+		recipient := strings.ToLower(strings.TrimSpace(message.Destinatario))
+		g, ok := groups[gk]
+		if ok && g.recipients[recipient] {
+			// Mesmo destinatário duas vezes no lote: enviar separadamente
+			jobs = append(jobs, job{message})
+			continue
+		}
+		if !ok || len(jobs[g.index]) >= g.max {
+			g = &group{index: len(jobs), max: max, recipients: make(map[string]bool)}
+			groups[gk] = g
+			jobs = append(jobs, job{})
+		}
+		jobs[g.index] = append(jobs[g.index], message)
+		g.recipients[recipient] = true
+	}
+
+	return jobs
+}
+
+// batchKey retorna a chave de lote do email no provider para onde ele será
+// roteado (vazio = enviar individualmente). Emails com anexo, remetente não
+// permitido ou provider exigido não configurado não entram em lotes.
+func (p *Processor) batchKey(message *Email) (string, int) {
+	if message.AnexoReferencia.Valid && message.AnexoReferencia.String != "" {
+		return "", 0
+	}
+	identity, allowed := p.senders.Resolve(message.Remetente)
+	if !allowed {
+		return "", 0
+	}
+	return p.sender.BatchKey(p.emailData(message, identity))
+}
+
+// queueFor retorna a fila correspondente à PRIORIDADE do email
+func (p *Processor) queueFor(prioridade int) chan job {
+	switch {
+	case prioridade <= PriorityHigh:
+		return p.highQueue
+	case prioridade >= PriorityLow:
+		return p.lowQueue
+	default:
+		return p.normalQueue
+	}
+}
+
+// nextJob obtém o próximo email (ou lote) respeitando prioridade estrita: a
+// fila alta é sempre consultada antes da normal, e a normal antes da baixa
+func (p *Processor) nextJob() (job, bool) {
+	select {
+	case queued, ok := <-p.highQueue:
+		return queued, ok
+	default:
+	}
+
+	select {
+	case queued, ok := <-p.highQueue:
+		return queued, ok
+	case queued, ok := <-p.normalQueue:
+		return queued, ok
+	default:
+	}
+
+	select {
+	case <-p.ctx.Done():
+		return nil, false
+	case queued, ok := <-p.highQueue:
+		return queued, ok
+	case queued, ok := <-p.normalQueue:
+		return queued, ok
+	case queued, ok := <-p.lowQueue:
+		return queued, ok
+	}
+}
+
+// worker processa emails de todas as filas, da mais para a menos prioritária
 func (p *Processor) worker(id int) {
 	defer p.wg.Done()
 
 	p.logger.Info("Worker iniciado", zap.Int("worker_id", id))
 
+	for {
+		if p.ctx.Err() != nil {
+			p.logger.Info("Worker finalizado", zap.Int("worker_id", id))
+			return
+		}
+
+		queued, ok := p.nextJob()
+		if !ok {
+			p.logger.Info("Worker finalizado", zap.Int("worker_id", id))
+			return
+		}
+
+		p.processJob(queued, id)
+	}
+}
+
+// laneWorker processa apenas emails de uma fila de prioridade (capacidade reservada)
+func (p *Processor) laneWorker(id int, lane string, queue chan job) {
+	defer p.wg.Done()
+
+	p.logger.Info("Worker dedicado iniciado",
+		zap.Int("worker_id", id),
+		zap.String("prioridade", lane))
+
 	for {
 		select {
 		case <-p.ctx.Done():
 			p.logger.Info("Worker finalizado", zap.Int("worker_id", id))
 			return
 
-		case emailMsg, ok := <-p.jobQueue:
+		case queued, ok := <-queue:
 			if !ok {
 				p.logger.Info("Canal de jobs fechado", zap.Int("worker_id", id))
 				return
 			}
 
-			p.processEmail(emailMsg, id)
+			p.processJob(queued, id)
+		}
+	}
+}
+
+// processJob processa um email ou um lote de emails
+func (p *Processor) processJob(queued job, workerID int) {
+	defer func() {
+		for _, message := range queued {
+			p.untrackClaim(message.ID)
 		}
+	}()
+
+	if len(queued) == 1 {
+		p.processEmail(queued[0], workerID)
+		return
 	}
+	p.processBatch(queued, workerID)
 }
 
 // processEmail processa um único email
 func (p *Processor) processEmail(message *Email, workerID int) {
 	startTime := time.Now()
 
+	emailData, release, ok := p.prepareEmail(message)
+	if !ok {
+		return
+	}
+	defer release()
+
 	p.logger.Info("Processando email",
 		zap.Int("worker_id", workerID),
 		zap.Int64("email_id", message.ID),
 		zap.String("to", maskEmail(message.Destinatario)),
 		zap.Int("tentativa", message.QTDTentativas+1))
 
-	// Criar contexto com timeout para envio
+	// Divisão de tráfego: registrar o provider escolhido para o email
+	p.metrics.RecordProviderRouted(strings.ToLower(p.sender.Route(emailData).GetName()))
+
+	if !p.waitSendSlots([]*Email{message}) {
+		return
+	}
+
+	p.sendEmail(message, emailData, startTime, false)
+}
+
+// processBatch envia um lote de emails em uma única chamada ao provider. Se
+// a chamada falhar, cada email é enviado individualmente (com retry e failover).
+func (p *Processor) processBatch(messages []*Email, workerID int) {
+	startTime := time.Now()
+
+	// Mesmas verificações do envio individual: emails rejeitados ou adiados saem do lote
+	var batch []*Email
+	var batchData []email.EmailData
+	var releases []func()
+	for _, message := range messages {
+		emailData, release, ok := p.prepareEmail(message)
+		if !ok {
+			continue
+		}
+		batch = append(batch, message)
+		batchData = append(batchData, emailData)
+		releases = append(releases, release)
+	}
+	if len(batch) == 0 {
+		return
+	}
+
+	p.logger.Info("Processando lote de emails",
+		zap.Int("worker_id", workerID),
+		zap.Int("total", len(batch)),
+		zap.Int64("primeiro_email_id", batch[0].ID))
+
+	for _, emailData := range batchData {
+		p.metrics.RecordProviderRouted(strings.ToLower(p.sender.Route(emailData).GetName()))
+	}
+
+	// Cada email do lote consome um envio do limite global. Se o envio em lote
+	// falhar, o envio individual usa os envios já reservados.
+	if !p.waitSendSlots(batch) {
+		for _, release := range releases {
+			release()
+		}
+		return
+	}
+
+	if len(batch) == 1 {
+		p.sendEmail(batch[0], batchData[0], startTime, false)
+		releases[0]()
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(p.ctx, time.Duration(p.config.SendTimeoutSeconds)*time.Second)
 	defer cancel()
 
-	// Configurar retry
-	retryConfig := retry.Config{
-		MaxAttempts:     2,
-		InitialInterval: 1 * time.Second,
-		MaxInterval:     5 * time.Second,
-		Multiplier:      2.0,
-		MaxElapsedTime:  time.Duration(p.config.SendTimeoutSeconds) * time.Second,
+	for i, message := range batch {
+		p.recordEvent(ctx, Event{
+			EmailID:  message.ID,
+			Type:     EventAttemptStarted,
+			Provider: strings.ToLower(p.sender.Route(batchData[i]).GetName()),
+			Detail:   fmt.Sprintf("tentativa %d (lote de %d)", message.QTDTentativas+1, len(batch)),
+		})
+	}
+	results, err := p.sender.SendBatch(ctx, batchData)
+	if err != nil {
+		p.logger.Warn("Envio em lote falhou, enviando individualmente",
+			zap.Int("total", len(batch)),
+			zap.Error(err))
+		// A tentativa já foi registrada para o lote; o limite do domínio de
+		// cada email é liberado ao fim do seu envio
+		for i := range batch {
+			p.sendEmail(batch[i], batchData[i], startTime, true)
+			releases[i]()
+		}
+		return
 	}
 
-	// Preparar dados para envio
-	emailData := email.EmailData{
-		ID:          message.ID,
-		From:        p.defaultFrom, // Usar o remetente padrão da configuração
-		To:          message.Destinatario,
-		Subject:     message.Assunto,
-		Body:        message.Corpo,
-		ContentType: message.TipoCorpo,
+	// Gravar os resultados mesmo se o serviço estiver parando: emails já
+	// aceitos pelo provider não podem voltar para a fila
+	recordCtx, cancelRecord := context.WithTimeout(context.Background(), time.Duration(p.config.SendTimeoutSeconds)*time.Second)
+	defer cancelRecord()
+
+	for i, result := range results {
+		p.metrics.RecordProviderSend(strings.ToLower(p.usedProviderName(result)), result.Success)
+		p.recordResult(recordCtx, batch[i], result, result.Error, startTime)
+		releases[i]()
+	}
+}
+
+// prepareEmail faz as verificações anteriores ao envio (provider exigido,
+// remetente, circuit breaker e limite por domínio) e monta os dados do
+// email. Retorna ok=false se o email foi rejeitado ou adiado; caso contrário
+// release deve ser chamado ao final do envio.
+func (p *Processor) prepareEmail(message *Email) (emailData email.EmailData, release func(), ok bool) {
+	// Provider exigido pela mensagem (METODO_ENVIO preenchido na inserção)
+	requestedProvider := message.RequestedProvider()
+	if requestedProvider != "" && p.sender.Lookup(requestedProvider) == nil {
+		p.rejectEmail(message, StatusProviderNotConfigured,
+			fmt.Sprintf("provider exigido (METODO_ENVIO=%d) não está configurado", message.MetodoEnvio.Int64))
+		return emailData, nil, false
+	}
+
+	// Remetente: REMETENTE precisa ser uma identidade permitida
+	identity, allowedSender := p.senders.Resolve(message.Remetente)
+	if !allowedSender {
+		p.rejectEmail(message, StatusSenderNotAllowed,
+			fmt.Sprintf("remetente %q não é uma identidade de remetente configurada", message.Remetente))
+		return emailData, nil, false
+	}
+
+	// Circuit breaker: todos os providers da mensagem indisponíveis, adiar sem consumir tentativa
+	if wait := p.sender.CircuitWait(email.EmailData{ID: message.ID, Provider: requestedProvider}); wait > 0 {
+		p.deferEmail(message, wait, "circuit breaker do provider aberto")
+		return emailData, nil, false
+	}
+
+	// Limite por domínio: adiar sem bloquear o worker
+	release, allowed, retryAfter := p.domainThrottle.Acquire(message.Destinatario)
+	if !allowed {
+		p.deferEmail(message, retryAfter, "limite do domínio do destinatário")
+		return emailData, nil, false
 	}
 
+	// Preparar dados para envio
+	emailData = p.emailData(message, identity)
+
 	// Carregar anexo se houver
 	if message.AnexoReferencia.Valid && message.AnexoReferencia.String != "" {
 		// Verificar se é URL (Zenvia) ou base64 (SendGrid, Pontaltech)
@@ -305,21 +756,172 @@ func (p *Processor) processEmail(message *Email, workerID int) {
 		}
 	}
 
+	return emailData, release, true
+}
+
+// emailData monta os dados de envio do email (sem anexo) com a identidade do remetente
+func (p *Processor) emailData(message *Email, identity config.SenderIdentity) email.EmailData {
+	bodyTemplate, variables := p.bodyTemplate(message)
+	return email.EmailData{
+		ID:          message.ID,
+		From:        identity.Email,
+		FromName:    identity.DisplayName,
+		ReplyTo:     identity.ReplyTo,
+		To:          message.Destinatario,
+		Subject:     message.Assunto,
+		Body:        message.Corpo,
+		ContentType: message.TipoCorpo,
+		Provider:    message.RequestedProvider(),
+		Settings:    identity.Settings,
+
+		BodyTemplate: bodyTemplate,
+		Variables:    variables,
+	}
+}
+
+// bodyTemplate retorna o template (TEMPLATE_ID) de onde o corpo do email foi
+// gerado e os valores das macros, para que emails do mesmo template formem um
+// lote. Vazio sem enable_batching, sem template ou se o corpo não corresponde
+// ao template atual (editado após a inserção).
+func (p *Processor) bodyTemplate(message *Email) (string, map[string]string) {
+	if !p.config.EnableBatching || p.templates == nil || !message.TemplateID.Valid {
+		return "", nil
+	}
+	id := message.TemplateID.Int64
+
+	p.templatesMu.Lock()
+	cached, ok := p.templateCache[id]
+	p.templatesMu.Unlock()
+
+	if !ok || time.Since(cached.loadedAt) > templateCacheTTL {
+		cached = &cachedTemplate{loadedAt: time.Now()}
+
+		ctx, cancel := context.WithTimeout(p.ctx, 5*time.Second)
+		tmpl, err := p.templates.GetByID(ctx, id)
+		cancel()
+		if err != nil {
+			p.logger.Warn("Erro ao buscar template para envio em lote",
+				zap.Int64("template_id", id),
+				zap.Error(err))
+		} else if matcher, err := template.NewMacroMatcher(tmpl.GetFullHTML()); err != nil {
+			p.logger.Warn("Template não pode ser usado no envio em lote",
+				zap.Int64("template_id", id),
+				zap.Error(err))
+		} else {
+			cached.content = tmpl.GetFullHTML()
+			cached.matcher = matcher
+		}
+
+		p.templatesMu.Lock()
+		p.templateCache[id] = cached
+		p.templatesMu.Unlock()
+	}
+
+	if cached.matcher == nil {
+		return "", nil
+	}
+	variables, ok := cached.matcher.Extract(message.Corpo)
+	if !ok {
+		return "", nil
+	}
+	return cached.content, variables
+}
+
+// sendEmail envia um email pela cadeia de providers (com retry) e registra o
+// resultado. O limite global de envios já deve ter sido aguardado (waitSendSlots).
+// attemptRecorded indica que o evento da primeira tentativa já foi registrado
+// (envio em lote que falhou).
+func (p *Processor) sendEmail(message *Email, emailData email.EmailData, startTime time.Time, attemptRecorded bool) {
+	// Criar contexto com timeout para envio
+	ctx, cancel := context.WithTimeout(p.ctx, time.Duration(p.config.SendTimeoutSeconds)*time.Second)
+	defer cancel()
+
+	// Configurar retry
+	retryConfig := retry.Config{
+		MaxAttempts:     2,
+		InitialInterval: 1 * time.Second,
+		MaxInterval:     5 * time.Second,
+		Multiplier:      2.0,
+		MaxElapsedTime:  time.Duration(p.config.SendTimeoutSeconds) * time.Second,
+	}
+
 	var result email.SendResult
 	err := retry.Retry(ctx, retryConfig, func() error {
+		if !attemptRecorded {
+			p.recordEvent(ctx, Event{
+				EmailID:  message.ID,
+				Type:     EventAttemptStarted,
+				Provider: strings.ToLower(p.sender.Route(emailData).GetName()),
+				Detail:   fmt.Sprintf("tentativa %d", message.QTDTentativas+1),
+			})
+		}
+		attemptRecorded = false
+
 		result = p.sender.Send(ctx, emailData)
+		if errors.Is(result.Error, email.ErrNoProviderAvailable) {
+			// Circuito abriu durante o processamento: não retentar agora
+			return nil
+		}
+		p.metrics.RecordProviderSend(strings.ToLower(p.usedProviderName(result)), result.Success)
 		if !result.Success {
 			return result.Error
 		}
 		return nil
 	}, p.logger)
 
+	// Circuito aberto: adiar sem incrementar QTD_TENTATIVAS
+	if errors.Is(result.Error, email.ErrNoProviderAvailable) {
+		wait := p.sender.CircuitWait(emailData)
+		if wait < time.Second {
+			wait = time.Second
+		}
+		p.deferEmail(message, wait, "circuit breaker do provider aberto")
+		return
+	}
+
+	// Gravar o resultado mesmo se o serviço estiver parando (Stop cancela
+	// p.ctx): email já aceito pelo provider não pode voltar para a fila
+	recordCtx, cancelRecord := context.WithTimeout(context.Background(), time.Duration(p.config.SendTimeoutSeconds)*time.Second)
+	defer cancelRecord()
+
+	p.recordResult(recordCtx, message, result, err, startTime)
+}
+
+// waitSendSlots aguarda o limite global de envios por minuto para os emails,
+// antes de iniciar o timeout de envio. Se a espera for interrompida (serviço
+// parando), os emails são adiados sem consumir tentativa e retorna false.
+func (p *Processor) waitSendSlots(messages []*Email) bool {
+	if err := p.waitRateLimit(p.ctx, len(messages)); err != nil {
+		for _, message := range messages {
+			p.deferEmail(message, 0, "serviço finalizado durante a espera do limite de envio")
+		}
+		return false
+	}
+	return true
+}
+
+// waitRateLimit aguarda o limite global de envios por minuto para n envios
+func (p *Processor) waitRateLimit(ctx context.Context, n int) error {
+	for i := 0; i < n; i++ {
+		waited, err := p.rateLimiter.Wait(ctx)
+		if err != nil {
+			return fmt.Errorf("aguardando limite de envio: %w", err)
+		}
+		if waited > 0 {
+			p.metrics.RecordRateLimitWait(waited)
+		}
+	}
+	return nil
+}
+
+// recordResult grava o resultado do envio no banco e nas métricas
+func (p *Processor) recordResult(ctx context.Context, message *Email, result email.SendResult, err error, startTime time.Time) {
 	// Processar resultado
 	if err == nil && result.Success {
 		// Sucesso
-		providerName := p.sender.GetProvider().GetName()
+		providerName := p.usedProviderName(result)
 		providerCode := ProviderStringToCode(providerName)
-		if err := p.repo.MarkAsSent(ctx, message.ID, result.ProviderID, providerCode); err != nil {
+		if err := p.repo.MarkAsSent(ctx, message.ID, p.config.InstanceID, result.ProviderID, providerCode); err != nil {
 			p.logger.Error("Erro ao marcar email como enviado", zap.Error(err))
 			p.metrics.RecordMessageProcessed(false, false, time.Since(startTime))
 		} else {
@@ -332,69 +934,152 @@ func (p *Processor) processEmail(message *Email, workerID int) {
 				zap.String("provider", providerName),
 				zap.Int("provider_code", providerCode),
 				zap.Duration("duracao", sendDuration))
+			p.recordEvent(ctx, Event{
+				EmailID:  message.ID,
+				Type:     EventAccepted,
+				Provider: strings.ToLower(providerName),
+				Detail:   "ID_PROVIDER: " + result.ProviderID,
+			})
 		}
 	} else {
 		// Erro
 		processDuration := time.Since(startTime)
-		providerName := p.sender.GetProvider().GetName()
+		providerName := p.usedProviderName(result)
 		providerCode := ProviderStringToCode(providerName)
 
+		sendErr := result.Error
+		if sendErr == nil {
+			sendErr = err
+		}
+
 		errorMsg := "erro desconhecido"
-		if result.Error != nil {
-			errorMsg = result.Error.Error()
-		} else if err != nil {
-			errorMsg = err.Error()
+		if sendErr != nil {
+			errorMsg = sendErr.Error()
 		}
 
-		// Determinar tipo de erro
-		isInvalidEmail := isInvalidEmailError(errorMsg)
-		if isInvalidEmail {
-			if err := p.repo.MarkAsInvalid(ctx, message.ID, errorMsg, providerCode); err != nil {
+		// Determinar tipo de erro pela classificação do provider
+		errorKind := email.ErrorKindOf(sendErr)
+		if errorKind == email.ErrorInvalidRecipient {
+			if err := p.repo.MarkAsInvalid(ctx, message.ID, p.config.InstanceID, errorMsg, providerCode); err != nil {
 				p.logger.Error("Erro ao marcar email como inválido", zap.Error(err))
 			}
 			p.metrics.RecordMessageProcessed(false, true, processDuration)
-		} else if message.QTDTentativas+1 >= p.config.MaxTentativas {
-			if err := p.repo.MarkAsPermanentFailure(ctx, message.ID, errorMsg, providerCode); err != nil {
+		} else if errorKind == email.ErrorPermanent || message.QTDTentativas+1 >= p.config.MaxTentativas {
+			if err := p.repo.MarkAsPermanentFailure(ctx, message.ID, p.config.InstanceID, errorMsg, providerCode); err != nil {
 				p.logger.Error("Erro ao marcar email como falha permanente", zap.Error(err))
 			}
 			p.metrics.RecordMessageProcessed(false, false, processDuration)
 		} else {
-			// Erro temporário, marcar para retry
-			if err := p.repo.MarkAsError(ctx, message.ID, errorMsg, providerCode); err != nil {
+			// Erro temporário, agendar retry com backoff exponencial
+			retryDelay := p.nextRetryDelay(message.QTDTentativas + 1)
+			if err := p.repo.MarkAsError(ctx, message.ID, p.config.InstanceID, errorMsg, retryDelay); err != nil {
 				p.logger.Error("Erro ao marcar email com erro", zap.Error(err))
+			} else {
+				p.logger.Info("Nova tentativa agendada",
+					zap.Int64("email_id", message.ID),
+					zap.Duration("em", retryDelay))
 			}
 			p.metrics.RecordMessageProcessed(false, false, processDuration)
 		}
 
 		p.metrics.RecordEmailSend(false, processDuration, 0)
 
+		code := ""
+		var classified *email.SendError
+		if errors.As(sendErr, &classified) {
+			code = classified.Code
+		}
+		p.recordEvent(ctx, Event{
+			EmailID:  message.ID,
+			Type:     EventFailed,
+			Provider: strings.ToLower(providerName),
+			Code:     code,
+			Detail:   errorMsg,
+		})
+
 		p.logger.Warn("Falha ao enviar email",
 			zap.Int64("email_id", message.ID),
 			zap.String("provider", providerName),
 			zap.Int("provider_code", providerCode),
 			zap.String("erro", errorMsg),
+			zap.String("classificacao", errorKind.String()),
 			zap.Int("tentativas", message.QTDTentativas+1))
 	}
 }
 
-// isInvalidEmailError verifica se erro é de email inválido
-func isInvalidEmailError(errMsg string) bool {
-	invalidPhrases := []string{
-		"email inválido",
-		"invalid email",
-		"invalid address",
-		"invalid recipient",
-		"malformed email",
+// recordEvent grava um evento no histórico do email. Falha ao gravar o
+// histórico não interrompe o processamento.
+func (p *Processor) recordEvent(ctx context.Context, event Event) {
+	if err := p.repo.Events().Record(ctx, event); err != nil {
+		p.logger.Warn("Erro ao gravar evento do email",
+			zap.Int64("email_id", event.EmailID),
+			zap.String("tipo", string(event.Type)),
+			zap.Error(err))
 	}
+}
 
-	errLower := strings.ToLower(errMsg)
-	for _, phrase := range invalidPhrases {
-		if strings.Contains(errLower, phrase) {
-			return true
-		}
+// usedProviderName retorna o provider que efetivamente processou o envio
+// (pode ser diferente do principal em caso de failover)
+func (p *Processor) usedProviderName(result email.SendResult) string {
+	if result.Provider != "" {
+		return result.Provider
+	}
+	return p.sender.GetProvider().GetName()
+}
+
+// rejectEmail marca um email como rejeitado antes do envio, sem incrementar QTD_TENTATIVAS
+func (p *Processor) rejectEmail(message *Email, status EmailStatus, motivo string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := p.repo.MarkAsRejected(ctx, message.ID, p.config.InstanceID, status, motivo); err != nil {
+		p.logger.Error("Erro ao marcar email como rejeitado",
+			zap.Int64("email_id", message.ID),
+			zap.Error(err))
+	}
+
+	p.metrics.RecordMessageProcessed(false, false, 0)
+
+	p.recordEvent(ctx, Event{
+		EmailID: message.ID,
+		Type:    EventFailed,
+		Code:    strconv.Itoa(int(status)),
+		Detail:  motivo,
+	})
+
+	p.logger.Warn("Email rejeitado",
+		zap.Int64("email_id", message.ID),
+		zap.Int("status", int(status)),
+		zap.String("motivo", motivo))
+}
+
+// deferEmail adia um email sem incrementar QTD_TENTATIVAS
+func (p *Processor) deferEmail(message *Email, delay time.Duration, motivo string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := p.repo.Defer(ctx, message.ID, p.config.InstanceID, delay); err != nil {
+		p.logger.Error("Erro ao adiar email",
+			zap.Int64("email_id", message.ID),
+			zap.Error(err))
+		return
 	}
 
-	return false
+	p.logger.Debug("Email adiado",
+		zap.Int64("email_id", message.ID),
+		zap.String("to", maskEmail(message.Destinatario)),
+		zap.String("motivo", motivo),
+		zap.Duration("em", delay))
+}
+
+// nextRetryDelay calcula o tempo até a próxima tentativa de um email que já
+// teve `tentativas` tentativas realizadas (backoff exponencial por QTD_TENTATIVAS)
+func (p *Processor) nextRetryDelay(tentativas int) time.Duration {
+	return retry.Backoff(tentativas, retry.Config{
+		InitialInterval: time.Duration(p.config.RetryBackoffInitialSeconds) * time.Second,
+		MaxInterval:     time.Duration(p.config.RetryBackoffMaxSeconds) * time.Second,
+		Multiplier:      p.config.RetryBackoffMultiplier,
+	})
 }
 
 // maskEmail mascara email para logs
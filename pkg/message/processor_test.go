@@ -0,0 +1,164 @@
+package message
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/config"
+	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/email"
+	"go.uber.org/zap"
+)
+
+// batchStandIn provider que agrupa mensagens pelo assunto e corpo
+type batchStandIn struct {
+	maxBatch int
+}
+
+func (b *batchStandIn) Send(ctx context.Context, message email.EmailData) (email.SendResult, error) {
+	return email.SendResult{Success: true}, nil
+}
+
+func (b *batchStandIn) SendBatch(ctx context.Context, messages []email.EmailData) ([]email.SendResult, error) {
+	return make([]email.SendResult, len(messages)), nil
+}
+
+func (b *batchStandIn) BatchKey(message email.EmailData) string {
+	return message.Subject + "\x00" + message.Body
+}
+
+func (b *batchStandIn) MaxBatchSize() int                  { return b.maxBatch }
+func (b *batchStandIn) GetName() string                    { return "Lote" }
+func (b *batchStandIn) ValidateEmail(address string) error { return email.ValidateEmail(address) }
+
+// newTestProcessor cria um Processor sem banco para testar o agendamento
+func newTestProcessor(cfg config.PerformanceConfig, senders []config.SenderIdentity) *Processor {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Processor{
+		sender:        email.NewSender([]email.Provider{&batchStandIn{maxBatch: 3}}, nil, 5, time.Minute, zap.NewNop()),
+		config:        &cfg,
+		senders:       NewSenderResolver(senders, "noreply@exemplo.com.br"),
+		logger:        zap.NewNop(),
+		ctx:           ctx,
+		cancel:        cancel,
+		highQueue:     make(chan job, 10),
+		normalQueue:   make(chan job, 10),
+		lowQueue:      make(chan job, 10),
+		templateCache: make(map[int64]*cachedTemplate),
+	}
+}
+
+func TestNextRetryDelay(t *testing.T) {
+	p := newTestProcessor(config.PerformanceConfig{
+		RetryBackoffInitialSeconds: 60,
+		RetryBackoffMaxSeconds:     3600,
+		RetryBackoffMultiplier:     2,
+	}, nil)
+
+	tests := []struct {
+		tentativas int
+		want       time.Duration
+	}{
+		{0, time.Minute},
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{6, 32 * time.Minute},
+		{7, time.Hour}, // 64 min limitado a retry_backoff_max_seconds
+		{20, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := p.nextRetryDelay(tt.tentativas); got != tt.want {
+			t.Errorf("nextRetryDelay(%d) = %v, esperado %v", tt.tentativas, got, tt.want)
+		}
+	}
+}
+
+func TestNextJobPriority(t *testing.T) {
+	p := newTestProcessor(config.PerformanceConfig{}, nil)
+
+	// Enfileirados da menor para a maior prioridade: saem na ordem inversa
+	for _, prioridade := range []int{PriorityLow, PriorityNormal, PriorityLow, PriorityHigh, 0, 9} {
+		p.queueFor(prioridade) <- job{{ID: int64(prioridade), Prioridade: prioridade}}
+	}
+
+	want := []int{PriorityHigh, 0, PriorityNormal, PriorityLow, PriorityLow, 9}
+	for i, prioridade := range want {
+		queued, ok := p.nextJob()
+		if !ok || queued[0].Prioridade != prioridade {
+			t.Fatalf("job %d = %v/%v, esperado prioridade %d", i+1, queued, ok, prioridade)
+		}
+	}
+
+	// Filas vazias e serviço parando: nextJob retorna sem bloquear
+	p.cancel()
+	if queued, ok := p.nextJob(); ok || queued != nil {
+		t.Errorf("nextJob após cancelamento = %v/%v, esperado nil/false", queued, ok)
+	}
+}
+
+func TestBuildJobs(t *testing.T) {
+	newEmail := func(id int64, destinatario string, prioridade int) Email {
+		return Email{ID: id, Destinatario: destinatario, Assunto: "Promoção", Corpo: "Oferta", Prioridade: prioridade}
+	}
+	withAttachment := newEmail(8, "hugo@destino.com", PriorityNormal)
+	withAttachment.AnexoReferencia = sql.NullString{String: "https://arquivos.exemplo.com/boleto.pdf", Valid: true}
+	otherContent := newEmail(9, "ines@destino.com", PriorityNormal)
+	otherContent.Corpo = "Outra oferta"
+	unknownSender := newEmail(10, "joao@destino.com", PriorityNormal)
+	unknownSender.Remetente = "diretoria@exemplo.com.br"
+
+	emails := []Email{
+		newEmail(1, "ana@destino.com", PriorityNormal),
+		newEmail(2, "bruno@destino.com", PriorityNormal),
+		newEmail(3, "ANA@destino.com", PriorityNormal), // destinatário repetido no lote
+		newEmail(4, "carla@destino.com", PriorityHigh), // outra fila
+		newEmail(5, "davi@destino.com", PriorityNormal),
+		newEmail(6, "eva@destino.com", PriorityNormal), // lote cheio (máximo 3)
+		newEmail(7, "invalido", PriorityNormal),        // rejeitado pelo provider
+		withAttachment,
+		otherContent,
+		unknownSender,
+	}
+
+	tests := []struct {
+		name     string
+		batching bool
+		senders  []config.SenderIdentity
+		want     [][]int64
+	}{
+		{
+			name: "sem envio em lote",
+			want: [][]int64{{1}, {2}, {3}, {4}, {5}, {6}, {7}, {8}, {9}, {10}},
+		},
+		{
+			name:     "envio em lote",
+			batching: true,
+			want:     [][]int64{{1, 2, 5}, {3}, {4}, {6, 10}, {7}, {8}, {9}},
+		},
+		{
+			name:     "remetente não permitido fora do lote",
+			batching: true,
+			senders:  []config.SenderIdentity{{Name: "ofertas", Email: "ofertas@exemplo.com.br"}},
+			want:     [][]int64{{1, 2, 5}, {3}, {4}, {6}, {7}, {8}, {9}, {10}},
+		},
+	}
+
+	for _, tt := range tests {
+		p := newTestProcessor(config.PerformanceConfig{EnableBatching: tt.batching}, tt.senders)
+		list := append([]Email(nil), emails...)
+
+		jobs := p.buildJobs(list)
+		got := make([][]int64, len(jobs))
+		for i, queued := range jobs {
+			for _, message := range queued {
+				got[i] = append(got[i], message.ID)
+			}
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: jobs = %v, esperado %v", tt.name, got, tt.want)
+		}
+	}
+}
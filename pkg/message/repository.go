@@ -3,15 +3,24 @@ package message
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/email"
 	"go.uber.org/zap"
 )
 
+// ErrLeaseLost indica que o email não está mais reservado pela instância
+// (a reserva expirou e o email foi reservado por outra instância)
+var ErrLeaseLost = errors.New("reserva do email pertence a outra instância")
+
 // Repository gerencia operações de banco de dados para emails
 type Repository struct {
 	db     *sql.DB
 	logger *zap.Logger
+	events *EventRepository // Histórico de eventos (EVENTOEMAIL)
 }
 
 // NewRepository cria um novo repository
@@ -19,28 +28,105 @@ func NewRepository(db *sql.DB, logger *zap.Logger) *Repository {
 	return &Repository{
 		db:     db,
 		logger: logger,
+		events: NewEventRepository(db, logger),
 	}
 }
 
-// GetPendingEmails busca emails pendentes para envio
-func (r *Repository) GetPendingEmails(ctx context.Context, limit, daysOffset, maxTentativas int) ([]Email, error) {
-	query := `
+// Events retorna o repository do histórico de eventos dos emails
+func (r *Repository) Events() *EventRepository {
+	return r.events
+}
+
+// ClaimPendingEmails reserva atomicamente um lote de emails pendentes para a
+// instância informada e retorna os emails reservados. Inclui emails com erro
+// temporário (status 3) cuja próxima tentativa já está vencida; status 3 sem
+// DATA_PROXIMA_TENTATIVA (gravados antes das retentativas automáticas) não é
+// retentado.
+//
+// A reserva usa SELECT ... FOR UPDATE SKIP LOCKED dentro de uma transação, de
+// forma que várias instâncias podem buscar ao mesmo tempo sem pegar o mesmo
+// email. Reservas expiram após `lease`; emails de uma instância que caiu voltam
+// a ficar disponíveis quando LEASE_ATE vence.
+func (r *Repository) ClaimPendingEmails(ctx context.Context, instanceID string, limit, daysOffset, maxTentativas int, lease time.Duration) ([]Email, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação de reserva: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Oracle não permite FETCH FIRST com FOR UPDATE; lemos apenas `limit` linhas
+	// do cursor (as linhas são bloqueadas à medida que são buscadas)
+	selectQuery := `
+		SELECT ID
+		FROM MENSAGEMEMAIL
+		WHERE STATUS_ENVIO IN (0, 3)
+		  AND QTD_TENTATIVAS < :1
+		  AND (DATA_AGENDAMENTO IS NULL OR DATA_AGENDAMENTO <= SYSDATE + :2)
+		  AND (DATA_PROXIMA_TENTATIVA <= SYSDATE
+		       OR (DATA_PROXIMA_TENTATIVA IS NULL AND STATUS_ENVIO = 0))
+		  AND (LEASE_ATE IS NULL OR LEASE_ATE <= SYSDATE)
+		ORDER BY PRIORIDADE ASC, DATA_CADASTRO ASC
+		FOR UPDATE SKIP LOCKED`
+
+	rows, err := tx.QueryContext(ctx, selectQuery, maxTentativas, daysOffset)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar emails pendentes: %w", err)
+	}
+
+	var ids []int64
+	for len(ids) < limit && rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("erro ao escanear id do email: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, fmt.Errorf("erro ao buscar emails pendentes: %w", err)
+	}
+	rows.Close()
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	// Marcar reserva
+	updateQuery := fmt.Sprintf(`
+		UPDATE MENSAGEMEMAIL
+		SET PROCESSADO_POR = :1,
+			LEASE_ATE = SYSDATE + (:2 / 86400)
+		WHERE ID IN (%s)`, bindList(3, len(ids)))
+
+	args := []interface{}{instanceID, int64(lease.Seconds())}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	if _, err := tx.ExecContext(ctx, updateQuery, args...); err != nil {
+		return nil, fmt.Errorf("erro ao reservar emails: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("erro ao confirmar reserva de emails: %w", err)
+	}
+
+	// Carregar os dados completos dos emails reservados
+	query := fmt.Sprintf(`
 		SELECT
 			ID, CLICODIGO, REMETENTE, DESTINATARIO, ASSUNTO,
 			CORPO, TIPO_CORPO, STATUS_ENVIO, DATA_CADASTRO,
 			DATA_AGENDAMENTO, DATA_ENVIO, QTD_TENTATIVAS,
 			DETALHES_ERRO, ID_PROVIDER, METODO_ENVIO, PRIORIDADE,
-			ANEXO_REFERENCIA, ANEXO_NOME, ANEXO_TIPO, IP_ORIGEM, TEMPLATE_ID
+			ANEXO_REFERENCIA, ANEXO_NOME, ANEXO_TIPO, IP_ORIGEM, TEMPLATE_ID,
+			DATA_PROXIMA_TENTATIVA
 		FROM MENSAGEMEMAIL
-		WHERE STATUS_ENVIO = 0
-		  AND QTD_TENTATIVAS < :1
-		  AND (DATA_AGENDAMENTO IS NULL OR DATA_AGENDAMENTO <= SYSDATE + :2)
-		ORDER BY PRIORIDADE ASC, DATA_CADASTRO ASC
-		FETCH FIRST :3 ROWS ONLY`
+		WHERE ID IN (%s)
+		ORDER BY PRIORIDADE ASC, DATA_CADASTRO ASC`, bindList(1, len(ids)))
 
-	rows, err := r.db.QueryContext(ctx, query, maxTentativas, daysOffset, limit)
+	rows, err = r.db.QueryContext(ctx, query, args[2:]...)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao buscar emails pendentes: %w", err)
+		return nil, fmt.Errorf("erro ao carregar emails reservados: %w", err)
 	}
 	defer rows.Close()
 
@@ -53,6 +139,7 @@ func (r *Repository) GetPendingEmails(ctx context.Context, limit, daysOffset, ma
 			&e.DataAgendamento, &e.DataEnvio, &e.QTDTentativas,
 			&e.DetalhesErro, &e.IDProvider, &e.MetodoEnvio, &e.Prioridade,
 			&e.AnexoReferencia, &e.AnexoNome, &e.AnexoTipo, &e.IPOrigem, &e.TemplateID,
+			&e.DataProximaTentativa,
 		)
 		if err != nil {
 			r.logger.Error("Erro ao escanear email", zap.Error(err))
@@ -61,11 +148,119 @@ func (r *Repository) GetPendingEmails(ctx context.Context, limit, daysOffset, ma
 		emails = append(emails, e)
 	}
 
+	r.logger.Debug("Emails reservados",
+		zap.String("instancia", instanceID),
+		zap.Int("total", len(ids)))
+
 	return emails, rows.Err()
 }
 
+// RenewLeases prorroga as reservas da instância para os emails informados,
+// que continuam nas filas ou em envio
+func (r *Repository) RenewLeases(ctx context.Context, instanceID string, ids []int64, lease time.Duration) error {
+	// Oracle limita a lista do IN a 1000 itens
+	const maxIDs = 1000
+	for start := 0; start < len(ids); start += maxIDs {
+		end := start + maxIDs
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		query := fmt.Sprintf(`
+			UPDATE MENSAGEMEMAIL
+			SET LEASE_ATE = SYSDATE + (:1 / 86400)
+			WHERE PROCESSADO_POR = :2
+			  AND ID IN (%s)`, bindList(3, end-start))
+
+		args := []interface{}{int64(lease.Seconds()), instanceID}
+		for _, id := range ids[start:end] {
+			args = append(args, id)
+		}
+		if _, err := r.db.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("erro ao renovar reservas: %w", err)
+		}
+	}
+
+	r.logger.Debug("Reservas renovadas",
+		zap.String("instancia", instanceID),
+		zap.Int("total", len(ids)))
+	return nil
+}
+
+// ReleaseClaim libera a reserva de um email que não chegou a ser processado
+func (r *Repository) ReleaseClaim(ctx context.Context, id int64, instanceID string) error {
+	query := `
+		UPDATE MENSAGEMEMAIL
+		SET PROCESSADO_POR = NULL,
+			LEASE_ATE = NULL
+		WHERE ID = :1
+		  AND PROCESSADO_POR = :2`
+
+	err := r.execClaimed(ctx, query, id, instanceID)
+	if err != nil {
+		return fmt.Errorf("erro ao liberar reserva do email: %w", err)
+	}
+
+	r.logger.Debug("Reserva do email liberada", zap.Int64("id", id))
+	return nil
+}
+
+// Defer adia o envio de um email sem contar como tentativa, liberando a
+// reserva. O email volta a ser buscado após `delay`.
+func (r *Repository) Defer(ctx context.Context, id int64, instanceID string, delay time.Duration) error {
+	seconds := int64(delay.Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	query := `
+		UPDATE MENSAGEMEMAIL
+		SET DATA_PROXIMA_TENTATIVA = SYSDATE + (:1 / 86400),
+			PROCESSADO_POR = NULL,
+			LEASE_ATE = NULL
+		WHERE ID = :2
+		  AND PROCESSADO_POR = :3`
+
+	err := r.execClaimed(ctx, query, seconds, id, instanceID)
+	if err != nil {
+		return fmt.Errorf("erro ao adiar email: %w", err)
+	}
+
+	r.logger.Debug("Email adiado",
+		zap.Int64("id", id),
+		zap.Int64("segundos", seconds))
+	return nil
+}
+
+// execClaimed executa uma atualização restrita ao email reservado pela
+// instância (último bind = PROCESSADO_POR). Retorna ErrLeaseLost se a reserva
+// não pertence mais à instância.
+func (r *Repository) execClaimed(ctx context.Context, query string, args ...interface{}) error {
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// bindList monta uma lista de bind variables Oracle (:n, :n+1, ...)
+func bindList(start, count int) string {
+	binds := make([]string, count)
+	for i := range binds {
+		binds[i] = fmt.Sprintf(":%d", start+i)
+	}
+	return strings.Join(binds, ", ")
+}
+
 // MarkAsSent marca email como enviado com sucesso
-func (r *Repository) MarkAsSent(ctx context.Context, id int64, providerID string, metodo int) error {
+func (r *Repository) MarkAsSent(ctx context.Context, id int64, instanceID, providerID string, metodo int) error {
 	query := `
 		UPDATE MENSAGEMEMAIL 
 		SET STATUS_ENVIO = 2,
@@ -73,10 +268,14 @@ func (r *Repository) MarkAsSent(ctx context.Context, id int64, providerID string
 			ID_PROVIDER = :1,
 			METODO_ENVIO = :2,
 			QTD_TENTATIVAS = QTD_TENTATIVAS + 1,
-			DETALHES_ERRO = NULL
-		WHERE ID = :3`
-
-	_, err := r.db.ExecContext(ctx, query, providerID, metodo, id)
+			DETALHES_ERRO = NULL,
+			DATA_PROXIMA_TENTATIVA = NULL,
+			PROCESSADO_POR = NULL,
+			LEASE_ATE = NULL
+		WHERE ID = :3
+		  AND PROCESSADO_POR = :4`
+
+	err := r.execClaimed(ctx, query, providerID, metodo, id, instanceID)
 	if err != nil {
 		return fmt.Errorf("erro ao marcar email como enviado: %w", err)
 	}
@@ -85,36 +284,47 @@ func (r *Repository) MarkAsSent(ctx context.Context, id int64, providerID string
 	return nil
 }
 
-// MarkAsError marca email com erro temporário
-func (r *Repository) MarkAsError(ctx context.Context, id int64, errorMsg string, metodo int) error {
+// MarkAsError marca email com erro temporário e agenda a próxima tentativa
+// para daqui a retryDelay (calculado no banco para evitar diferença de relógio).
+// METODO_ENVIO não é alterado: um valor preenchido na inserção exige o provider
+// e precisa ser preservado para as retentativas.
+func (r *Repository) MarkAsError(ctx context.Context, id int64, instanceID, errorMsg string, retryDelay time.Duration) error {
 	query := `
 		UPDATE MENSAGEMEMAIL 
 		SET STATUS_ENVIO = 3,
 			QTD_TENTATIVAS = QTD_TENTATIVAS + 1,
 			DETALHES_ERRO = :1,
-			METODO_ENVIO = :2
-		WHERE ID = :3`
+			DATA_PROXIMA_TENTATIVA = SYSDATE + (:2 / 86400),
+			PROCESSADO_POR = NULL,
+			LEASE_ATE = NULL
+		WHERE ID = :3
+		  AND PROCESSADO_POR = :4`
 
-	_, err := r.db.ExecContext(ctx, query, errorMsg, metodo, id)
+	err := r.execClaimed(ctx, query, errorMsg, int64(retryDelay.Seconds()), id, instanceID)
 	if err != nil {
 		return fmt.Errorf("erro ao marcar email com erro: %w", err)
 	}
 
-	r.logger.Debug("Email marcado com erro", zap.Int64("id", id))
+	r.logger.Debug("Email marcado com erro",
+		zap.Int64("id", id),
+		zap.Duration("proxima_tentativa_em", retryDelay))
 	return nil
 }
 
 // MarkAsInvalid marca email como inválido
-func (r *Repository) MarkAsInvalid(ctx context.Context, id int64, errorMsg string, metodo int) error {
+func (r *Repository) MarkAsInvalid(ctx context.Context, id int64, instanceID, errorMsg string, metodo int) error {
 	query := `
 		UPDATE MENSAGEMEMAIL 
 		SET STATUS_ENVIO = 125,
 			QTD_TENTATIVAS = QTD_TENTATIVAS + 1,
 			DETALHES_ERRO = :1,
-			METODO_ENVIO = :2
-		WHERE ID = :3`
+			METODO_ENVIO = :2,
+			PROCESSADO_POR = NULL,
+			LEASE_ATE = NULL
+		WHERE ID = :3
+		  AND PROCESSADO_POR = :4`
 
-	_, err := r.db.ExecContext(ctx, query, errorMsg, metodo, id)
+	err := r.execClaimed(ctx, query, errorMsg, metodo, id, instanceID)
 	if err != nil {
 		return fmt.Errorf("erro ao marcar email como inválido: %w", err)
 	}
@@ -124,16 +334,19 @@ func (r *Repository) MarkAsInvalid(ctx context.Context, id int64, errorMsg strin
 }
 
 // MarkAsPermanentFailure marca email com falha permanente
-func (r *Repository) MarkAsPermanentFailure(ctx context.Context, id int64, errorMsg string, metodo int) error {
+func (r *Repository) MarkAsPermanentFailure(ctx context.Context, id int64, instanceID, errorMsg string, metodo int) error {
 	query := `
 		UPDATE MENSAGEMEMAIL 
 		SET STATUS_ENVIO = 4,
 			QTD_TENTATIVAS = QTD_TENTATIVAS + 1,
 			DETALHES_ERRO = :1,
-			METODO_ENVIO = :2
-		WHERE ID = :3`
+			METODO_ENVIO = :2,
+			PROCESSADO_POR = NULL,
+			LEASE_ATE = NULL
+		WHERE ID = :3
+		  AND PROCESSADO_POR = :4`
 
-	_, err := r.db.ExecContext(ctx, query, errorMsg, metodo, id)
+	err := r.execClaimed(ctx, query, errorMsg, metodo, id, instanceID)
 	if err != nil {
 		return fmt.Errorf("erro ao marcar email como falha permanente: %w", err)
 	}
@@ -142,6 +355,119 @@ func (r *Repository) MarkAsPermanentFailure(ctx context.Context, id int64, error
 	return nil
 }
 
+// MarkAsRejected marca email rejeitado antes do envio (ex: provider exigido não
+// configurado) com o status informado, sem incrementar QTD_TENTATIVAS
+func (r *Repository) MarkAsRejected(ctx context.Context, id int64, instanceID string, status EmailStatus, errorMsg string) error {
+	query := `
+		UPDATE MENSAGEMEMAIL 
+		SET STATUS_ENVIO = :1,
+			DETALHES_ERRO = :2,
+			DATA_PROXIMA_TENTATIVA = NULL,
+			PROCESSADO_POR = NULL,
+			LEASE_ATE = NULL
+		WHERE ID = :3
+		  AND PROCESSADO_POR = :4`
+
+	err := r.execClaimed(ctx, query, int(status), errorMsg, id, instanceID)
+	if err != nil {
+		return fmt.Errorf("erro ao marcar email como rejeitado: %w", err)
+	}
+
+	r.logger.Debug("Email marcado como rejeitado",
+		zap.Int64("id", id),
+		zap.Int("status", int(status)))
+	return nil
+}
+
+// RecordDeliveryEvent grava o evento de entrega informado pelo provider no
+// histórico (EVENTOEMAIL) e como último evento do email enviado por ele
+// (localizado pelo ID devolvido no evento ou por ID_PROVIDER, sempre com o
+// METODO_ENVIO do provider). Eventos mais antigos que o último gravado entram
+// apenas no histórico. Hard bounce de um email enviado muda STATUS_ENVIO para
+// 125 (e-mail inválido). Retorna false, sem gravar nada, se nenhum email
+// corresponde ao evento.
+func (r *Repository) RecordDeliveryEvent(ctx context.Context, event email.DeliveryEvent) (bool, error) {
+	occurredAt := event.Timestamp
+	if occurredAt.IsZero() {
+		occurredAt = time.Now()
+	}
+
+	detail := event.Detail
+	if detail == "" {
+		detail = event.Status
+	}
+	if len(detail) > 1000 {
+		detail = detail[:1000]
+	}
+
+	hardBounce := 0
+	bounceMsg := ""
+	if event.HardBounce() {
+		hardBounce = 1
+		bounceMsg = fmt.Sprintf("Hard bounce (%s): %s", event.Provider, detail)
+	}
+
+	// ID devolvido pelo provider é preferido: no envio em lote vários emails
+	// compartilham o mesmo ID_PROVIDER
+	match, key := "ID_PROVIDER", interface{}(event.ProviderID)
+	if event.MessageID > 0 {
+		match, key = "ID", event.MessageID
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("erro ao iniciar transação do evento de entrega: %w", err)
+	}
+	defer tx.Rollback()
+
+	// O histórico só recebe o evento dos emails correspondentes (INSERT ...
+	// SELECT FROM MENSAGEMEMAIL); sem correspondência o evento é descartado
+	recorded, err := r.events.recordDelivery(ctx, tx, event)
+	if err != nil {
+		return false, err
+	}
+	if recorded == 0 {
+		return false, nil
+	}
+
+	query := `
+		UPDATE MENSAGEMEMAIL 
+		SET STATUS_ENTREGA = :1,
+			DATA_ENTREGA = :2,
+			DETALHES_ENTREGA = :3,
+			STATUS_ENVIO = CASE WHEN :4 = 1 AND STATUS_ENVIO = 2 THEN 125 ELSE STATUS_ENVIO END,
+			DETALHES_ERRO = CASE WHEN :5 = 1 AND STATUS_ENVIO = 2 THEN :6 ELSE DETALHES_ERRO END
+		WHERE ` + match + ` = :7
+		AND METODO_ENVIO = :8
+		AND (DATA_ENTREGA IS NULL OR DATA_ENTREGA <= :9)`
+
+	result, err := tx.ExecContext(ctx, query,
+		string(event.Type), occurredAt, detail,
+		hardBounce, hardBounce, bounceMsg,
+		key, ProviderStringToCode(event.Provider), occurredAt)
+	if err != nil {
+		return false, fmt.Errorf("erro ao registrar evento de entrega: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("erro ao verificar evento de entrega registrado: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("erro ao confirmar evento de entrega: %w", err)
+	}
+
+	r.logger.Debug("Evento de entrega registrado",
+		zap.String("provider", event.Provider),
+		zap.String("provider_id", event.ProviderID),
+		zap.Int64("mensagem_id", event.MessageID),
+		zap.String("evento", string(event.Type)),
+		zap.Int64("emails_atualizados", rows),
+		zap.Int64("eventos_gravados", recorded))
+	return true, nil
+}
+
 // GetByID busca um email por ID
 func (r *Repository) GetByID(ctx context.Context, id int64) (*Email, error) {
 	query := `
@@ -150,7 +476,8 @@ func (r *Repository) GetByID(ctx context.Context, id int64) (*Email, error) {
 			CORPO, TIPO_CORPO, STATUS_ENVIO, DATA_CADASTRO,
 			DATA_AGENDAMENTO, DATA_ENVIO, QTD_TENTATIVAS,
 			DETALHES_ERRO, ID_PROVIDER, METODO_ENVIO, PRIORIDADE,
-			ANEXO_REFERENCIA, ANEXO_NOME, ANEXO_TIPO, IP_ORIGEM, TEMPLATE_ID
+			ANEXO_REFERENCIA, ANEXO_NOME, ANEXO_TIPO, IP_ORIGEM, TEMPLATE_ID,
+			DATA_PROXIMA_TENTATIVA
 		FROM MENSAGEMEMAIL
 		WHERE ID = :1`
 
@@ -161,6 +488,7 @@ func (r *Repository) GetByID(ctx context.Context, id int64) (*Email, error) {
 		&e.DataAgendamento, &e.DataEnvio, &e.QTDTentativas,
 		&e.DetalhesErro, &e.IDProvider, &e.MetodoEnvio, &e.Prioridade,
 		&e.AnexoReferencia, &e.AnexoNome, &e.AnexoTipo, &e.IPOrigem, &e.TemplateID,
+		&e.DataProximaTentativa,
 	)
 
 	if err == sql.ErrNoRows {
@@ -205,14 +533,16 @@ func (r *Repository) InsertEmail(ctx context.Context, email *Email) (int64, erro
 	return id, nil
 }
 
-// CountPendingEmails conta emails pendentes
+// CountPendingEmails conta emails pendentes (incluindo retentativas vencidas)
 func (r *Repository) CountPendingEmails(ctx context.Context, daysOffset, maxTentativas int) (int64, error) {
 	query := `
 		SELECT COUNT(*)
 		FROM MENSAGEMEMAIL
-		WHERE STATUS_ENVIO = 0
+		WHERE STATUS_ENVIO IN (0, 3)
 		  AND QTD_TENTATIVAS < :1
-		  AND (DATA_AGENDAMENTO IS NULL OR DATA_AGENDAMENTO <= SYSDATE + :2)`
+		  AND (DATA_AGENDAMENTO IS NULL OR DATA_AGENDAMENTO <= SYSDATE + :2)
+		  AND (DATA_PROXIMA_TENTATIVA <= SYSDATE
+		       OR (DATA_PROXIMA_TENTATIVA IS NULL AND STATUS_ENVIO = 0))`
 
 	var count int64
 	err := r.db.QueryRowContext(ctx, query, maxTentativas, daysOffset).Scan(&count)
@@ -0,0 +1,57 @@
+package message
+
+import (
+	"net/mail"
+	"strings"
+
+	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/config"
+)
+
+// SenderResolver resolve o remetente de cada mensagem a partir da coluna
+// REMETENTE. Sem identidades configuradas, mantém o comportamento legado de
+// enviar sempre pelo remetente padrão (default_from).
+type SenderResolver struct {
+	identities  map[string]config.SenderIdentity // email em minúsculas -> identidade
+	defaultFrom string
+}
+
+// NewSenderResolver cria o resolvedor de remetentes
+func NewSenderResolver(identities []config.SenderIdentity, defaultFrom string) *SenderResolver {
+	resolver := &SenderResolver{
+		identities:  make(map[string]config.SenderIdentity, len(identities)),
+		defaultFrom: defaultFrom,
+	}
+
+	for _, identity := range identities {
+		resolver.identities[strings.ToLower(identity.Email)] = identity
+	}
+
+	return resolver
+}
+
+// Resolve retorna a identidade a ser usada para o REMETENTE informado. Aceita
+// "email" ou "Nome <email>"; REMETENTE vazio usa o remetente padrão. Retorna
+// false se houver identidades configuradas e o remetente não for uma delas.
+func (r *SenderResolver) Resolve(remetente string) (config.SenderIdentity, bool) {
+	if len(r.identities) == 0 {
+		return config.SenderIdentity{Email: r.defaultFrom}, true
+	}
+
+	address := strings.TrimSpace(remetente)
+	if address == "" {
+		address = r.defaultFrom
+	} else if parsed, err := mail.ParseAddress(address); err == nil {
+		address = parsed.Address
+	}
+
+	if identity, ok := r.identities[strings.ToLower(address)]; ok {
+		return identity, true
+	}
+
+	// Remetente padrão sempre permitido, mesmo sem identidade própria
+	if strings.EqualFold(address, r.defaultFrom) {
+		return config.SenderIdentity{Email: r.defaultFrom}, true
+	}
+
+	return config.SenderIdentity{}, false
+}
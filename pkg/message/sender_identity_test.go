@@ -0,0 +1,50 @@
+package message
+
+import (
+	"testing"
+
+	"github.com/Vinicius-S-Souza/icrmsenderemail/pkg/config"
+)
+
+func TestSenderResolverResolve(t *testing.T) {
+	identities := []config.SenderIdentity{
+		{Name: "financeiro", Email: "Financeiro@Exemplo.com.br", DisplayName: "Financeiro"},
+		{Name: "marketing", Email: "ofertas@exemplo.com.br"},
+	}
+
+	tests := []struct {
+		name       string
+		identities []config.SenderIdentity
+		remetente  string
+		wantEmail  string
+		wantOK     bool
+	}{
+		// Sem identidades: sempre o remetente padrão (comportamento legado)
+		{"legado com remetente", nil, "outro@exemplo.com.br", "noreply@exemplo.com.br", true},
+		{"legado sem remetente", nil, "", "noreply@exemplo.com.br", true},
+
+		{"identidade exata", identities, "ofertas@exemplo.com.br", "ofertas@exemplo.com.br", true},
+		{"maiúsculas", identities, "FINANCEIRO@exemplo.com.br", "Financeiro@Exemplo.com.br", true},
+		{"nome e email", identities, "Equipe Financeira <financeiro@exemplo.com.br>", "Financeiro@Exemplo.com.br", true},
+		{"espaços", identities, "  ofertas@exemplo.com.br ", "ofertas@exemplo.com.br", true},
+		{"vazio usa o padrão", identities, "", "noreply@exemplo.com.br", true},
+		{"padrão sem identidade própria", identities, "NoReply@exemplo.com.br", "noreply@exemplo.com.br", true},
+		{"não configurado", identities, "diretoria@exemplo.com.br", "", false},
+		{"endereço inválido", identities, "não é email", "", false},
+	}
+
+	for _, tt := range tests {
+		resolver := NewSenderResolver(tt.identities, "noreply@exemplo.com.br")
+		identity, ok := resolver.Resolve(tt.remetente)
+		if ok != tt.wantOK || identity.Email != tt.wantEmail {
+			t.Errorf("%s: Resolve(%q) = %q/%v, esperado %q/%v",
+				tt.name, tt.remetente, identity.Email, ok, tt.wantEmail, tt.wantOK)
+		}
+	}
+
+	// A identidade completa é retornada (nome de exibição, configurações)
+	resolver := NewSenderResolver(identities, "noreply@exemplo.com.br")
+	if identity, _ := resolver.Resolve("financeiro@exemplo.com.br"); identity.Name != "financeiro" || identity.DisplayName != "Financeiro" {
+		t.Errorf("identidade = %+v, esperado a seção financeiro", identity)
+	}
+}
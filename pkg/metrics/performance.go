@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"sort"
 	"sync"
 	"time"
 
@@ -40,6 +41,34 @@ type PerformanceMetrics struct {
 
 	// Custo (se aplicável)
 	TotalCost float64 // em centavos
+
+	// Limitador de taxa (email_rate_limit_per_min)
+	RateLimitWaits       int64         // Envios que precisaram aguardar o limitador
+	TotalRateLimitWait   time.Duration
+	AverageRateLimitWait time.Duration
+
+	// Limites por domínio do destinatário (chave = grupo de domínios)
+	domainStats map[string]*DomainStat
+
+	// Divisão de tráfego entre providers (chave = nome do provider)
+	providerStats map[string]*ProviderStat
+}
+
+// DomainStat contadores de envio de um grupo de domínios com limite configurado
+type DomainStat struct {
+	Domain     string `json:"domain"`
+	Dispatched int64  `json:"dispatched"` // Envios liberados pelo limite
+	Deferred   int64  `json:"deferred"`   // Envios adiados por limite de taxa/concorrência
+	InFlight   int64  `json:"in_flight"`  // Envios em andamento
+}
+
+// ProviderStat contadores de tráfego de um provider (divisão por peso e failover)
+type ProviderStat struct {
+	Provider string `json:"provider"`
+	Weight   int    `json:"weight"`  // Peso configurado em provider_weights
+	Routed   int64  `json:"routed"`  // E-mails direcionados ao provider pela divisão de tráfego
+	Sent     int64  `json:"sent"`    // Envios com sucesso pelo provider
+	Failed   int64  `json:"failed"`  // Envios com falha pelo provider
 }
 
 // NewPerformanceMetrics cria uma nova instância de métricas
@@ -47,6 +76,8 @@ func NewPerformanceMetrics() *PerformanceMetrics {
 	return &PerformanceMetrics{
 		LastCalculationTime: time.Now(),
 		LastProcessTime:     time.Now(),
+		domainStats:         make(map[string]*DomainStat),
+		providerStats:       make(map[string]*ProviderStat),
 	}
 }
 
@@ -102,6 +133,119 @@ func (pm *PerformanceMetrics) RecordEmailSend(success bool, duration time.Durati
 	}
 }
 
+// RecordRateLimitWait registra o tempo que um envio aguardou no limitador de taxa
+func (pm *PerformanceMetrics) RecordRateLimitWait(wait time.Duration) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pm.RateLimitWaits++
+	pm.TotalRateLimitWait += wait
+	pm.AverageRateLimitWait = pm.TotalRateLimitWait / time.Duration(pm.RateLimitWaits)
+}
+
+// domainStat retorna (criando se necessário) os contadores do grupo (deve ser chamado com lock)
+func (pm *PerformanceMetrics) domainStat(domain string) *DomainStat {
+	stat, ok := pm.domainStats[domain]
+	if !ok {
+		stat = &DomainStat{Domain: domain}
+		pm.domainStats[domain] = stat
+	}
+	return stat
+}
+
+// RecordDomainDispatched registra um envio liberado pelo limite do domínio
+func (pm *PerformanceMetrics) RecordDomainDispatched(domain string, inFlight int) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	stat := pm.domainStat(domain)
+	stat.Dispatched++
+	stat.InFlight = int64(inFlight)
+}
+
+// RecordDomainDeferred registra um envio adiado pelo limite do domínio
+func (pm *PerformanceMetrics) RecordDomainDeferred(domain string) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pm.domainStat(domain).Deferred++
+}
+
+// SetDomainInFlight atualiza o número de envios em andamento do domínio
+func (pm *PerformanceMetrics) SetDomainInFlight(domain string, inFlight int) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pm.domainStat(domain).InFlight = int64(inFlight)
+}
+
+// GetDomainStats retorna os contadores por domínio ordenados pelo nome
+func (pm *PerformanceMetrics) GetDomainStats() []DomainStat {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	stats := make([]DomainStat, 0, len(pm.domainStats))
+	for _, stat := range pm.domainStats {
+		stats = append(stats, *stat)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Domain < stats[j].Domain })
+
+	return stats
+}
+
+// providerStat retorna (criando se necessário) os contadores do provider (deve ser chamado com lock)
+func (pm *PerformanceMetrics) providerStat(provider string) *ProviderStat {
+	stat, ok := pm.providerStats[provider]
+	if !ok {
+		stat = &ProviderStat{Provider: provider}
+		pm.providerStats[provider] = stat
+	}
+	return stat
+}
+
+// SetProviderWeight registra o peso configurado de um provider
+func (pm *PerformanceMetrics) SetProviderWeight(provider string, weight int) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pm.providerStat(provider).Weight = weight
+}
+
+// RecordProviderRouted registra um e-mail direcionado ao provider pela divisão de tráfego
+func (pm *PerformanceMetrics) RecordProviderRouted(provider string) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pm.providerStat(provider).Routed++
+}
+
+// RecordProviderSend registra o resultado de um envio pelo provider que efetivamente o processou
+func (pm *PerformanceMetrics) RecordProviderSend(provider string, success bool) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	stat := pm.providerStat(provider)
+	if success {
+		stat.Sent++
+	} else {
+		stat.Failed++
+	}
+}
+
+// GetProviderStats retorna os contadores por provider ordenados pelo nome
+func (pm *PerformanceMetrics) GetProviderStats() []ProviderStat {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	stats := make([]ProviderStat, 0, len(pm.providerStats))
+	for _, stat := range pm.providerStats {
+		stats = append(stats, *stat)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Provider < stats[j].Provider })
+
+	return stats
+}
+
 // calculateAverages calcula médias e taxas (deve ser chamado com lock)
 func (pm *PerformanceMetrics) calculateAverages() {
 	if pm.MessagesProcessed > 0 {
@@ -158,6 +302,10 @@ func (pm *PerformanceMetrics) GetSnapshot() MetricsSnapshot {
 
 		TotalCost: pm.TotalCost,
 
+		RateLimitWaits:       pm.RateLimitWaits,
+		TotalRateLimitWait:   pm.TotalRateLimitWait,
+		AverageRateLimitWait: pm.AverageRateLimitWait,
+
 		LastProcessTime: pm.LastProcessTime,
 	}
 }
@@ -183,6 +331,9 @@ func (pm *PerformanceMetrics) LogMetrics(logger *zap.Logger) {
 		zap.Duration("tempo_medio_email", snapshot.AverageSendTime),
 		zap.Float64("custo_total_centavos", snapshot.TotalCost),
 		zap.Float64("custo_total_reais", snapshot.TotalCost/100),
+		zap.Int64("rate_limit_esperas", snapshot.RateLimitWaits),
+		zap.Duration("rate_limit_espera_total", snapshot.TotalRateLimitWait),
+		zap.Duration("rate_limit_espera_media", snapshot.AverageRateLimitWait),
 	)
 }
 
@@ -207,6 +358,20 @@ func (pm *PerformanceMetrics) Reset() {
 	pm.TotalSendTime = 0
 	pm.AverageSendTime = 0
 	pm.TotalCost = 0
+	pm.RateLimitWaits = 0
+	pm.TotalRateLimitWait = 0
+	pm.AverageRateLimitWait = 0
+	for _, stat := range pm.domainStats {
+		// Envios em andamento não são zerados
+		stat.Dispatched = 0
+		stat.Deferred = 0
+	}
+	for _, stat := range pm.providerStats {
+		// Peso configurado não é zerado
+		stat.Routed = 0
+		stat.Sent = 0
+		stat.Failed = 0
+	}
 	pm.LastCalculationTime = time.Now()
 	pm.LastProcessTime = time.Now()
 }
@@ -233,6 +398,10 @@ type MetricsSnapshot struct {
 
 	TotalCost float64
 
+	RateLimitWaits       int64
+	TotalRateLimitWait   time.Duration
+	AverageRateLimitWait time.Duration
+
 	LastProcessTime time.Time
 }
 
@@ -248,6 +417,9 @@ type Stats struct {
 	QueriesExecuted        int64
 	PushSendSuccessCount   int64 // Mantido por compatibilidade com dashboard
 	PushSendErrorCount     int64 // Mantido por compatibilidade com dashboard
+	RateLimitWaitCount     int64
+	AvgRateLimitWaitMs     float64
+	TotalRateLimitWaitMs   float64
 }
 
 // GetStats retorna estatísticas formatadas para o dashboard
@@ -266,5 +438,8 @@ func (pm *PerformanceMetrics) GetStats() Stats {
 		QueriesExecuted:        pm.QueriesExecuted,
 		PushSendSuccessCount:   pm.EmailSendsSuccess,
 		PushSendErrorCount:     pm.EmailSendsAttempted - pm.EmailSendsSuccess,
+		RateLimitWaitCount:     pm.RateLimitWaits,
+		AvgRateLimitWaitMs:     float64(pm.AverageRateLimitWait.Microseconds()) / 1000.0,
+		TotalRateLimitWaitMs:   float64(pm.TotalRateLimitWait.Microseconds()) / 1000.0,
 	}
 }
@@ -0,0 +1,110 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TokenBucket limitador de taxa do tipo token bucket, seguro para uso
+// concorrente por vários workers
+type TokenBucket struct {
+	mu         sync.Mutex
+	ratePerSec float64   // Tokens repostos por segundo
+	capacity   float64   // Máximo de tokens acumulados (burst)
+	tokens     float64   // Tokens disponíveis (negativo = reservas pendentes)
+	last       time.Time // Última reposição
+}
+
+// NewTokenBucket cria um limitador de ratePerMin envios por minuto, permitindo
+// rajadas de até burst envios. Retorna nil se ratePerMin <= 0 (sem limite);
+// todos os métodos aceitam receptor nil.
+func NewTokenBucket(ratePerMin int, burst int) *TokenBucket {
+	if ratePerMin <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &TokenBucket{
+		ratePerSec: float64(ratePerMin) / 60.0,
+		capacity:   float64(burst),
+		tokens:     float64(burst),
+		last:       time.Now(),
+	}
+}
+
+// refill repõe tokens proporcionalmente ao tempo decorrido (deve ser chamado com lock)
+func (tb *TokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(tb.last).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	tb.tokens += elapsed * tb.ratePerSec
+	if tb.tokens > tb.capacity {
+		tb.tokens = tb.capacity
+	}
+	tb.last = now
+}
+
+// Wait reserva um token, aguardando se necessário. Retorna o tempo aguardado.
+// Se o contexto for cancelado durante a espera, o token é devolvido.
+func (tb *TokenBucket) Wait(ctx context.Context) (time.Duration, error) {
+	if tb == nil {
+		return 0, nil
+	}
+
+	tb.mu.Lock()
+	tb.refill(time.Now())
+	tb.tokens--
+	var wait time.Duration
+	if tb.tokens < 0 {
+		wait = time.Duration(-tb.tokens / tb.ratePerSec * float64(time.Second))
+	}
+	tb.mu.Unlock()
+
+	if wait <= 0 {
+		return 0, nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return wait, nil
+	case <-ctx.Done():
+		tb.mu.Lock()
+		tb.tokens++
+		tb.mu.Unlock()
+		return 0, ctx.Err()
+	}
+}
+
+// TryTake tenta obter um token sem bloquear. Se não houver token disponível,
+// retorna false e o tempo estimado até o próximo token.
+func (tb *TokenBucket) TryTake() (bool, time.Duration) {
+	if tb == nil {
+		return true, 0
+	}
+
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	tb.refill(time.Now())
+	if tb.tokens >= 1 {
+		tb.tokens--
+		return true, 0
+	}
+
+	return false, time.Duration((1 - tb.tokens) / tb.ratePerSec * float64(time.Second))
+}
+
+// RatePerMin retorna a taxa configurada (envios por minuto)
+func (tb *TokenBucket) RatePerMin() int {
+	if tb == nil {
+		return 0
+	}
+	return int(tb.ratePerSec*60 + 0.5)
+}
@@ -0,0 +1,121 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNewTokenBucketDisabled(t *testing.T) {
+	for _, rate := range []int{0, -10} {
+		if tb := NewTokenBucket(rate, 5); tb != nil {
+			t.Errorf("NewTokenBucket(%d) = %+v, esperado nil (sem limite)", rate, tb)
+		}
+	}
+
+	// Receptor nil libera todos os envios
+	var tb *TokenBucket
+	if waited, err := tb.Wait(context.Background()); waited != 0 || err != nil {
+		t.Errorf("Wait = %v/%v, esperado 0/nil", waited, err)
+	}
+	if ok, wait := tb.TryTake(); !ok || wait != 0 {
+		t.Errorf("TryTake = %v/%v, esperado true/0", ok, wait)
+	}
+	if rate := tb.RatePerMin(); rate != 0 {
+		t.Errorf("RatePerMin = %d, esperado 0", rate)
+	}
+}
+
+func TestTokenBucketBurst(t *testing.T) {
+	tests := []struct {
+		ratePerMin int
+		burst      int
+		wantBurst  int
+		wantWait   time.Duration // Espera até o próximo token após a rajada
+	}{
+		{60, 1, 1, time.Second},
+		{60, 5, 5, time.Second},
+		{120, 3, 3, 500 * time.Millisecond},
+		{30, 0, 1, 2 * time.Second}, // burst < 1 vira 1
+		{6, -1, 1, 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		tb := NewTokenBucket(tt.ratePerMin, tt.burst)
+		if tb.RatePerMin() != tt.ratePerMin {
+			t.Errorf("%d/min: RatePerMin = %d", tt.ratePerMin, tb.RatePerMin())
+		}
+
+		for i := 0; i < tt.wantBurst; i++ {
+			if ok, _ := tb.TryTake(); !ok {
+				t.Fatalf("%d/min burst %d: envio %d da rajada bloqueado", tt.ratePerMin, tt.burst, i+1)
+			}
+		}
+
+		ok, wait := tb.TryTake()
+		if ok {
+			t.Errorf("%d/min burst %d: envio além da rajada liberado", tt.ratePerMin, tt.burst)
+		}
+		// Tolerância para o tempo decorrido durante o teste
+		if wait > tt.wantWait || wait < tt.wantWait-50*time.Millisecond {
+			t.Errorf("%d/min burst %d: espera %v, esperado ~%v", tt.ratePerMin, tt.burst, wait, tt.wantWait)
+		}
+	}
+}
+
+func TestTokenBucketRefill(t *testing.T) {
+	tb := NewTokenBucket(60, 2)
+	tb.TryTake()
+	tb.TryTake()
+
+	// Meio segundo depois: meio token reposto
+	tb.last = tb.last.Add(-500 * time.Millisecond)
+	if ok, wait := tb.TryTake(); ok || wait > 500*time.Millisecond {
+		t.Errorf("TryTake = %v/%v, esperado bloqueio de até 500ms", ok, wait)
+	}
+
+	// Muito tempo parado: tokens limitados à capacidade (burst)
+	tb.last = tb.last.Add(-time.Hour)
+	for i := 0; i < 2; i++ {
+		if ok, _ := tb.TryTake(); !ok {
+			t.Fatalf("envio %d bloqueado após reposição", i+1)
+		}
+	}
+	if ok, _ := tb.TryTake(); ok {
+		t.Error("reposição não deveria passar da capacidade")
+	}
+}
+
+func TestTokenBucketWait(t *testing.T) {
+	tb := NewTokenBucket(6000, 1) // Um token a cada 10ms
+
+	if waited, err := tb.Wait(context.Background()); waited != 0 || err != nil {
+		t.Fatalf("primeiro Wait = %v/%v, esperado imediato", waited, err)
+	}
+
+	start := time.Now()
+	waited, err := tb.Wait(context.Background())
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if waited <= 0 || waited > 10*time.Millisecond || time.Since(start) < waited {
+		t.Errorf("Wait aguardou %v (%v decorridos), esperado até 10ms", waited, time.Since(start))
+	}
+}
+
+func TestTokenBucketWaitCanceled(t *testing.T) {
+	tb := NewTokenBucket(60, 1)
+	tb.TryTake()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if waited, err := tb.Wait(ctx); err == nil || waited != 0 {
+		t.Fatalf("Wait = %v/%v, esperado erro do contexto", waited, err)
+	}
+
+	// Token reservado pela espera cancelada é devolvido: o próximo envio
+	// espera apenas o restante do primeiro token, não dois
+	if ok, wait := tb.TryTake(); ok || wait > time.Second {
+		t.Errorf("TryTake = %v/%v, esperado espera de até 1s", ok, wait)
+	}
+}
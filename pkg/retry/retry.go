@@ -2,6 +2,7 @@ package retry
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"time"
@@ -75,7 +76,8 @@ func Retry(ctx context.Context, config Config, operation Operation, logger *zap.
 		lastErr = err
 
 		// Verificar se erro é retentável
-		if retryableErr, ok := err.(RetryableError); ok && !retryableErr.IsRetryable() {
+		var retryableErr RetryableError
+		if errors.As(err, &retryableErr) && !retryableErr.IsRetryable() {
 			// Erro não retentável - falhar imediatamente
 			if logger != nil {
 				logger.Warn("Erro não retentável detectado",
@@ -132,6 +134,15 @@ func calculateBackoff(attempt int, config Config) time.Duration {
 	return time.Duration(backoff)
 }
 
+// Backoff retorna o tempo de espera para a tentativa informada (1 = primeira)
+// seguindo o mesmo backoff exponencial usado por Retry
+func Backoff(attempt int, config Config) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	return calculateBackoff(attempt, config)
+}
+
 // Do é um alias conveniente para Retry com configuração padrão
 func Do(ctx context.Context, operation Operation, logger *zap.Logger) error {
 	return Retry(ctx, DefaultConfig(), operation, logger)
@@ -214,3 +214,54 @@ func GetMacroPreviewData() MacroData {
 		Ano:      now.Format("2006"),
 	}
 }
+
+// MacroMatcher recupera os valores das macros de um conteúdo já processado,
+// comparando-o com o template de origem
+type MacroMatcher struct {
+	pattern *regexp.Regexp
+	names   []string // Nome de cada macro, na ordem dos grupos de pattern
+}
+
+// NewMacroMatcher cria o extrator de macros para o conteúdo do template
+func NewMacroMatcher(content string) (*MacroMatcher, error) {
+	re := regexp.MustCompile(`\{\{([^}]+)\}\}`)
+
+	var pattern strings.Builder
+	var names []string
+	pattern.WriteString(`(?s)^`)
+	last := 0
+	for _, loc := range re.FindAllStringSubmatchIndex(content, -1) {
+		pattern.WriteString(regexp.QuoteMeta(content[last:loc[0]]))
+		pattern.WriteString(`(.*?)`)
+		names = append(names, content[loc[2]:loc[3]])
+		last = loc[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(content[last:]))
+	pattern.WriteString(`$`)
+
+	compiled, err := regexp.Compile(pattern.String())
+	if err != nil {
+		return nil, fmt.Errorf("erro ao compilar macros do template: %w", err)
+	}
+	return &MacroMatcher{pattern: compiled, names: names}, nil
+}
+
+// Extract retorna os valores das macros (nome sem chaves → valor) usados para
+// gerar o conteúdo processado. ok=false se o conteúdo não foi gerado a partir
+// do template (ou se uma macro repetida teria valores diferentes).
+func (m *MacroMatcher) Extract(processed string) (values map[string]string, ok bool) {
+	match := m.pattern.FindStringSubmatch(processed)
+	if match == nil {
+		return nil, false
+	}
+
+	values = make(map[string]string, len(m.names))
+	for i, name := range m.names {
+		value := match[i+1]
+		if previous, seen := values[name]; seen && previous != value {
+			return nil, false
+		}
+		values[name] = value
+	}
+	return values, true
+}
@@ -0,0 +1,47 @@
+package template
+
+import "testing"
+
+func TestMacroMatcherExtract(t *testing.T) {
+	matcher, err := NewMacroMatcher("<p>Olá {{nome}} ({{email}})</p><p>Até logo, {{nome}}. © {{ano}}</p>")
+	if err != nil {
+		t.Fatalf("NewMacroMatcher: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		processed string
+		want      map[string]string
+		ok        bool
+	}{
+		{
+			name:      "corpo gerado pelo template",
+			processed: "<p>Olá Ana Souza (ana@exemplo.com)</p><p>Até logo, Ana Souza. © 2025</p>",
+			want:      map[string]string{"nome": "Ana Souza", "email": "ana@exemplo.com", "ano": "2025"},
+			ok:        true,
+		},
+		{
+			name:      "macro repetida com valores diferentes",
+			processed: "<p>Olá Ana (ana@exemplo.com)</p><p>Até logo, Bruno. © 2025</p>",
+			ok:        false,
+		},
+		{
+			name:      "corpo alterado",
+			processed: "<p>Oi Ana (ana@exemplo.com)</p><p>Até logo, Ana. © 2025</p>",
+			ok:        false,
+		},
+	}
+
+	for _, tt := range tests {
+		values, ok := matcher.Extract(tt.processed)
+		if ok != tt.ok {
+			t.Errorf("%s: ok = %v, esperado %v", tt.name, ok, tt.ok)
+			continue
+		}
+		for name, value := range tt.want {
+			if values[name] != value {
+				t.Errorf("%s: %s = %q, esperado %q", tt.name, name, values[name], value)
+			}
+		}
+	}
+}
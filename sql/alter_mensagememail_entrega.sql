@@ -0,0 +1,25 @@
+-- Alteração da tabela MENSAGEMEMAIL para eventos de entrega dos providers
+-- Data: 15/12/2025 09:00
+-- Versão: 1.4.0
+--
+-- Objetivo: Registrar o último evento de entrega recebido pelos callbacks dos
+-- providers (entregue, aberto, clicado, bounce...). O e-mail é localizado pelo
+-- ID_PROVIDER gravado no envio. Hard bounce muda STATUS_ENVIO de 2 para 125.
+
+-- Último evento de entrega: sent, delivered, deferred, bounced, dropped,
+-- opened, clicked, spamreport, unsubscribed
+ALTER TABLE MENSAGEMEMAIL ADD STATUS_ENTREGA VARCHAR2(30);
+
+-- Data/hora do último evento de entrega (informada pelo provider)
+ALTER TABLE MENSAGEMEMAIL ADD DATA_ENTREGA DATE;
+
+-- Status/motivo original informado pelo provider
+ALTER TABLE MENSAGEMEMAIL ADD DETALHES_ENTREGA VARCHAR2(1000);
+
+-- Índice para localizar o e-mail pelo ID retornado pelo provider
+CREATE INDEX IDX_MENSAGEMEMAIL_ID_PROVIDER ON MENSAGEMEMAIL(ID_PROVIDER);
+
+-- Adicionar comentários nas colunas
+COMMENT ON COLUMN MENSAGEMEMAIL.STATUS_ENTREGA IS 'Último evento de entrega informado pelo provider (delivered, bounced, opened...)';
+COMMENT ON COLUMN MENSAGEMEMAIL.DATA_ENTREGA IS 'Data/hora do último evento de entrega';
+COMMENT ON COLUMN MENSAGEMEMAIL.DETALHES_ENTREGA IS 'Status/motivo original do último evento de entrega';
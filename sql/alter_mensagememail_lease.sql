@@ -0,0 +1,20 @@
+-- Alteração da tabela MENSAGEMEMAIL para processamento com múltiplas instâncias
+-- Data: 13/12/2025 11:00
+-- Versão: 1.4.0
+--
+-- Objetivo: Cada instância do icrmsenderemail reserva (claim) um lote de e-mails
+-- por um tempo limitado (lease). Leases vencidas (instância caiu) voltam a ficar
+-- disponíveis para as demais instâncias.
+
+-- Identificação da instância que reservou o e-mail
+ALTER TABLE MENSAGEMEMAIL ADD PROCESSADO_POR VARCHAR2(100);
+
+-- Data/hora de expiração da reserva
+ALTER TABLE MENSAGEMEMAIL ADD LEASE_ATE DATE;
+
+-- Índice para otimizar a busca de e-mails disponíveis
+CREATE INDEX IDX_MENSAGEMEMAIL_LEASE ON MENSAGEMEMAIL(STATUS_ENVIO, LEASE_ATE);
+
+-- Adicionar comentários nas colunas
+COMMENT ON COLUMN MENSAGEMEMAIL.PROCESSADO_POR IS 'Instância que reservou o e-mail para envio';
+COMMENT ON COLUMN MENSAGEMEMAIL.LEASE_ATE IS 'Data/hora de expiração da reserva (NULL=livre)';
@@ -0,0 +1,14 @@
+-- Alteração da tabela MENSAGEMEMAIL para seleção de provedor por mensagem
+-- Data: 13/12/2025 16:00
+-- Versão: 1.4.0
+--
+-- Objetivo: Documentar o uso de METODO_ENVIO preenchido na inserção para
+-- exigir um provedor específico e o novo status 126 (provedor não configurado)
+--
+-- Códigos (registro de providers em pkg/email): 1024=smtp, 2048=sendgrid,
+-- 4096=zenvia, 8192=pontaltech, 16384=ses, 32768=mailgun, 65536=graph, 131072=http
+-- NULL ou 0 = provedor escolhido automaticamente (divisão de tráfego/failover)
+
+COMMENT ON COLUMN MENSAGEMEMAIL.METODO_ENVIO IS 'Código do provedor utilizado (1024=smtp, 2048=sendgrid, 4096=zenvia, 8192=pontaltech, 16384=ses, 32768=mailgun, 65536=graph, 131072=http). Preenchido na inserção exige o provedor (NULL/0=automático)';
+
+COMMENT ON COLUMN MENSAGEMEMAIL.STATUS_ENVIO IS '0=Pendente, 2=Enviado, 3=Erro, 4=Falha permanente, 125=Email inválido, 126=Provider exigido não configurado';
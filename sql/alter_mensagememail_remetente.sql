@@ -0,0 +1,11 @@
+-- Alteração da tabela MENSAGEMEMAIL para identidades de remetente
+-- Data: 14/12/2025 09:00
+-- Versão: 1.4.0
+--
+-- Objetivo: Documentar o uso da coluna REMETENTE, que passa a ser respeitada
+-- quando corresponde a uma identidade [sender.<nome>] configurada, e o novo
+-- status 127 (remetente não permitido)
+
+COMMENT ON COLUMN MENSAGEMEMAIL.REMETENTE IS 'Endereço de e-mail do remetente (deve ser uma identidade [sender.*] configurada, quando houver)';
+
+COMMENT ON COLUMN MENSAGEMEMAIL.STATUS_ENVIO IS '0=Pendente, 2=Enviado, 3=Erro, 4=Falha permanente, 125=Email inválido, 126=Provider exigido não configurado, 127=Remetente não permitido';
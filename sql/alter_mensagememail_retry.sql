@@ -0,0 +1,33 @@
+-- Alteração da tabela MENSAGEMEMAIL para suportar retentativas agendadas
+-- Data: 13/12/2025 10:00
+-- Versão: 1.4.0
+--
+-- Objetivo: Permitir que e-mails com erro temporário (STATUS_ENVIO = 3)
+-- sejam retentados automaticamente com backoff exponencial
+--
+-- E-mails com erro (3) gravados pelas versões anteriores ficam com
+-- DATA_PROXIMA_TENTATIVA NULL e não são retentados (o serviço só retenta
+-- status 3 com DATA_PROXIMA_TENTATIVA preenchida). Eles são movidos para
+-- falha permanente (4) para não ficarem indefinidamente como erro temporário.
+-- METODO_ENVIO desses e-mails é o provider que falhou (não um provider
+-- exigido na inserção) e é limpo, para que um e-mail reenviado (voltando
+-- STATUS_ENVIO para 0) use a divisão de tráfego e o failover.
+
+-- Adicionar coluna com a data/hora mínima da próxima tentativa
+ALTER TABLE MENSAGEMEMAIL ADD DATA_PROXIMA_TENTATIVA DATE;
+
+-- Índice para otimizar a busca de e-mails pendentes e retentativas vencidas
+CREATE INDEX IDX_MENSAGEMEMAIL_RETRY ON MENSAGEMEMAIL(STATUS_ENVIO, DATA_PROXIMA_TENTATIVA);
+
+-- Adicionar comentário na coluna
+COMMENT ON COLUMN MENSAGEMEMAIL.DATA_PROXIMA_TENTATIVA IS 'Data/hora mínima para a próxima tentativa de envio (NULL=imediato para pendentes; erros sem data não são retentados)';
+
+-- Erros anteriores às retentativas automáticas: falha permanente
+UPDATE MENSAGEMEMAIL
+SET STATUS_ENVIO = 4,
+    METODO_ENVIO = NULL,
+    DETALHES_ERRO = SUBSTRB('Erro anterior às retentativas automáticas: ' || DETALHES_ERRO, 1, 4000)
+WHERE STATUS_ENVIO = 3
+AND DATA_PROXIMA_TENTATIVA IS NULL;
+
+COMMIT;
@@ -0,0 +1,75 @@
+-- Tabela de histórico de eventos dos e-mails (MENSAGEMEMAIL)
+-- Criada em: 15/12/2025 14:00
+-- Versão: 1.4.0
+--
+-- Cada linha é um evento do ciclo de vida do e-mail. MENSAGEMEMAIL mantém
+-- apenas o status atual; o histórico completo fica nesta tabela.
+--
+-- Tipos (TIPO):
+--   queued          - Inserido em MENSAGEMEMAIL (trigger abaixo)
+--   attempt_started - Tentativa de envio iniciada
+--   accepted        - Aceito pelo provider (DETALHES com o ID_PROVIDER)
+--   failed          - Tentativa falhou ou e-mail rejeitado (CODIGO do provider ou status)
+--   sent, delivered, deferred, bounced, dropped, opened, clicked,
+--   spamreport, unsubscribed - Eventos de entrega recebidos dos providers
+
+CREATE TABLE EVENTOEMAIL (
+    -- Identificador único
+    ID NUMBER(18) NOT NULL PRIMARY KEY,
+
+    -- E-mail ao qual o evento pertence
+    ID_MENSAGEM NUMBER NOT NULL,
+
+    -- Tipo do evento
+    TIPO VARCHAR2(30) NOT NULL,
+
+    -- Data/hora do evento (informada pelo provider nos eventos de entrega)
+    DATA_EVENTO DATE NOT NULL,
+
+    -- Provider envolvido no evento
+    PROVIDER VARCHAR2(50),
+
+    -- Código retornado pelo provider (erro, status de entrega) ou status do e-mail
+    CODIGO VARCHAR2(100),
+
+    -- Detalhes do evento (mensagem de erro, motivo do bounce, URL clicada...)
+    DETALHES VARCHAR2(4000),
+
+    -- Data/hora de gravação
+    DATA_REGISTRO DATE DEFAULT SYSDATE NOT NULL,
+
+    CONSTRAINT FK_EVENTOEMAIL_MENSAGEM FOREIGN KEY (ID_MENSAGEM)
+        REFERENCES MENSAGEMEMAIL(ID) ON DELETE CASCADE
+);
+
+-- Sequence para geração de IDs
+CREATE SEQUENCE SEQ_EVENTOEMAIL
+    START WITH 1
+    INCREMENT BY 1
+    CACHE 100
+    NOCYCLE;
+
+-- Índice para a linha do tempo de um e-mail
+CREATE INDEX IDX_EVENTOEMAIL_MENSAGEM ON EVENTOEMAIL(ID_MENSAGEM, DATA_EVENTO);
+
+-- Evento "queued" para todo e-mail inserido (por qualquer sistema)
+CREATE OR REPLACE TRIGGER TRG_MENSAGEMEMAIL_EVENTO
+AFTER INSERT ON MENSAGEMEMAIL
+FOR EACH ROW
+BEGIN
+    INSERT INTO EVENTOEMAIL (ID, ID_MENSAGEM, TIPO, DATA_EVENTO, DETALHES)
+    VALUES (SEQ_EVENTOEMAIL.NEXTVAL, :NEW.ID, 'queued', NVL(:NEW.DATA_CADASTRO, SYSDATE),
+            'Prioridade ' || :NEW.PRIORIDADE);
+END;
+/
+
+-- Comentários nas colunas para documentação
+COMMENT ON TABLE EVENTOEMAIL IS 'Histórico de eventos do ciclo de vida dos e-mails de MENSAGEMEMAIL';
+COMMENT ON COLUMN EVENTOEMAIL.ID IS 'Identificador único do evento';
+COMMENT ON COLUMN EVENTOEMAIL.ID_MENSAGEM IS 'ID do e-mail em MENSAGEMEMAIL';
+COMMENT ON COLUMN EVENTOEMAIL.TIPO IS 'queued, attempt_started, accepted, failed, delivered, bounced, opened, clicked...';
+COMMENT ON COLUMN EVENTOEMAIL.DATA_EVENTO IS 'Data/hora do evento';
+COMMENT ON COLUMN EVENTOEMAIL.PROVIDER IS 'Provider envolvido no evento';
+COMMENT ON COLUMN EVENTOEMAIL.CODIGO IS 'Código retornado pelo provider ou status do e-mail';
+COMMENT ON COLUMN EVENTOEMAIL.DETALHES IS 'Detalhes do evento';
+COMMENT ON COLUMN EVENTOEMAIL.DATA_REGISTRO IS 'Data/hora de gravação do evento';
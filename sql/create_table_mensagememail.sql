@@ -80,19 +80,19 @@ CREATE SEQUENCE SEQ_MENSAGEMEMAIL
 COMMENT ON TABLE MENSAGEMEMAIL IS 'Controle de envio de mensagens de e-mail';
 COMMENT ON COLUMN MENSAGEMEMAIL.ID IS 'Identificador único da mensagem';
 COMMENT ON COLUMN MENSAGEMEMAIL.CLICODIGO IS 'Código do cliente (FK)';
-COMMENT ON COLUMN MENSAGEMEMAIL.REMETENTE IS 'Endereço de e-mail do remetente';
+COMMENT ON COLUMN MENSAGEMEMAIL.REMETENTE IS 'Endereço de e-mail do remetente (deve ser uma identidade [sender.*] configurada, quando houver)';
 COMMENT ON COLUMN MENSAGEMEMAIL.DESTINATARIO IS 'Endereço de e-mail do destinatário';
 COMMENT ON COLUMN MENSAGEMEMAIL.ASSUNTO IS 'Assunto do e-mail';
 COMMENT ON COLUMN MENSAGEMEMAIL.CORPO IS 'Corpo do e-mail (texto ou HTML)';
 COMMENT ON COLUMN MENSAGEMEMAIL.TIPO_CORPO IS 'Tipo do corpo: text/plain ou text/html';
-COMMENT ON COLUMN MENSAGEMEMAIL.STATUS_ENVIO IS '0=Pendente, 2=Enviado, 3=Erro, 4=Falha permanente, 125=Email inválido';
+COMMENT ON COLUMN MENSAGEMEMAIL.STATUS_ENVIO IS '0=Pendente, 2=Enviado, 3=Erro, 4=Falha permanente, 125=Email inválido, 126=Provider exigido não configurado, 127=Remetente não permitido';
 COMMENT ON COLUMN MENSAGEMEMAIL.DATA_CADASTRO IS 'Data/hora de criação do registro';
 COMMENT ON COLUMN MENSAGEMEMAIL.DATA_AGENDAMENTO IS 'Data/hora agendada para envio (NULL=imediato)';
 COMMENT ON COLUMN MENSAGEMEMAIL.DATA_ENVIO IS 'Data/hora do último envio';
 COMMENT ON COLUMN MENSAGEMEMAIL.QTD_TENTATIVAS IS 'Número de tentativas de envio realizadas';
 COMMENT ON COLUMN MENSAGEMEMAIL.DETALHES_ERRO IS 'Última mensagem de erro retornada';
 COMMENT ON COLUMN MENSAGEMEMAIL.ID_PROVIDER IS 'ID da mensagem no provedor de e-mail';
-COMMENT ON COLUMN MENSAGEMEMAIL.METODO_ENVIO IS 'Código do provedor utilizado';
+COMMENT ON COLUMN MENSAGEMEMAIL.METODO_ENVIO IS 'Código do provedor utilizado (1024=smtp, 2048=sendgrid, 4096=zenvia, 8192=pontaltech, 16384=ses, 32768=mailgun, 65536=graph, 131072=http). Preenchido na inserção exige o provedor (NULL/0=automático)';
 COMMENT ON COLUMN MENSAGEMEMAIL.PRIORIDADE IS '1=Alta, 2=Normal, 3=Baixa';
 COMMENT ON COLUMN MENSAGEMEMAIL.ANEXO_REFERENCIA IS 'Anexo em base64 (CLOB para suportar arquivos grandes)';
 COMMENT ON COLUMN MENSAGEMEMAIL.ANEXO_NOME IS 'Nome do arquivo anexo';
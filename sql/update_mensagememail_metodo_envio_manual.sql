@@ -0,0 +1,34 @@
+-- Correção de METODO_ENVIO nos e-mails do disparo manual e com erro
+-- Data: 16/12/2025 10:00
+-- Versão: 1.4.0
+--
+-- Objetivo: O disparo manual gravava em METODO_ENVIO o código do provider
+-- configurado, o que passou a exigir esse provider (sem divisão de tráfego nem
+-- failover) e, se ele fosse removido de "providers", levava ao status 126.
+-- O disparo manual agora insere METODO_ENVIO NULL; este script libera os
+-- e-mails manuais ainda pendentes (0) ou aguardando retentativa (3).
+--
+-- E-mails do disparo manual são identificados por IP_ORIGEM preenchido.
+--
+-- Além disso, as versões anteriores gravavam em METODO_ENVIO o provider que
+-- falhou em todo e-mail com erro (3). Como status 3 passou a ser retentado e
+-- METODO_ENVIO preenchido exige o provider, esses e-mails ficariam presos ao
+-- provider que falhou (sem failover, status 126 se ele foi removido). O valor
+-- é limpo em todos os e-mails com erro.
+--
+-- Execute ANTES de iniciar a versão 1.4.0: depois dela, METODO_ENVIO de um
+-- e-mail com erro é o valor informado na inserção e não deve ser limpo.
+
+UPDATE MENSAGEMEMAIL
+SET METODO_ENVIO = NULL
+WHERE STATUS_ENVIO IN (0, 3)
+AND IP_ORIGEM IS NOT NULL
+AND METODO_ENVIO IS NOT NULL;
+
+-- E-mails com erro: METODO_ENVIO é o provider que falhou, não o exigido
+UPDATE MENSAGEMEMAIL
+SET METODO_ENVIO = NULL
+WHERE STATUS_ENVIO = 3
+AND METODO_ENVIO IS NOT NULL;
+
+COMMIT;